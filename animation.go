@@ -0,0 +1,119 @@
+package ascanvas
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/gif"
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ContentTypeCast is the content type of asciicast recordings
+const ContentTypeCast = "application/x-asciicast"
+
+// MaxAnimationPixels is the largest animated gif that can be exported, all frames included
+const MaxAnimationPixels = 1 << 26
+
+// WriteGIF of frames of the canvas as an animated gif looping forever, as per args once defaults are applied;
+// the format of args is not used
+func (c Canvas) WriteGIF(w io.Writer, frames []Frame, args ImageArgs) error {
+	args = args.WithDefaults()
+	args.Format = ImageGIF
+
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	if width, height := c.bounds(args); width*height*len(frames) > MaxAnimationPixels {
+		return fmt.Errorf("%w: animation of %d frames of %dx%d pixels is too large, use smaller cells", ErrInvalidInput, len(frames), width, height)
+	}
+
+	var anim = gif.GIF{
+		Image: make([]*image.Paletted, len(frames)),
+		Delay: make([]int, len(frames)),
+	}
+
+	for i, f := range frames {
+		var frame = c
+		frame.Content = f.Content
+
+		anim.Image[i] = frame.paletted(args)
+
+		// gif delays are in hundredths of a second
+		anim.Delay[i] = (f.Delay + 5) / 10
+	}
+
+	return gif.EncodeAll(w, &anim)
+}
+
+// WriteCast of frames of the canvas as an asciicast v2 recording, for terminal playback with asciinema;
+// every frame is drawn over the previous one, and the recording lasts until the delay of the last one is over
+func (c Canvas) WriteCast(w io.Writer, frames []Frame) error {
+	var (
+		b   = bufio.NewWriter(w)
+		enc = json.NewEncoder(b)
+		at  int
+	)
+
+	var header = struct {
+		Version int    `json:"version"`
+		Width   int    `json:"width"`
+		Height  int    `json:"height"`
+		Title   string `json:"title,omitempty"`
+	}{
+		Version: 2,
+		Width:   c.Width,
+		Height:  c.Height,
+		Title:   c.Name,
+	}
+
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+
+	for _, f := range frames {
+		var frame = c
+		frame.Content = f.Content
+
+		// cursor home, clear screen, then rows; \r\n as terminals in raw mode need carriage returns
+		var out = "\x1b[H\x1b[2J" + strings.Join(frame.Rows(), "\r\n")
+
+		if err := enc.Encode([]interface{}{float64(at) / 1000, "o", out}); err != nil {
+			return err
+		}
+
+		at += f.Delay
+	}
+
+	if err := enc.Encode([]interface{}{float64(at) / 1000, "o", ""}); err != nil {
+		return err
+	}
+
+	return b.Flush()
+}
+
+// ExportCast of all frames of a canvas, as per WriteCast
+func (s CanvasService) ExportCast(ctx context.Context, id string) ([]byte, error) {
+	s.Logger.Debug("ExportCast::Fetching", zap.String("id", id))
+
+	var canvas, frames, err = s.frames(ctx, id)
+	if err != nil {
+		s.Logger.Debug("ExportCast::Fetch::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	var b strings.Builder
+	if err = canvas.WriteCast(&b, frames); err != nil {
+		s.Logger.Debug("ExportCast::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	s.Logger.Debug("ExportCast::Exported", zap.Int("frames", len(frames)), zap.Int("bytes", b.Len()))
+
+	return []byte(b.String()), nil
+}
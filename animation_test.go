@@ -0,0 +1,141 @@
+package ascanvas_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/gif"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
+)
+
+var animation = []ascanvas.Frame{
+	{Content: "+---+|Hi!|+---+", Delay: 100},
+	{Content: "+---+|Ho!|+---+", Delay: 250},
+	{Content: "+---+|Hu\x01", Delay: 20},
+}
+
+func TestCanvas_WriteGIF(t *testing.T) {
+	var b bytes.Buffer
+
+	if err := imageCanvas.WriteGIF(&b, animation, ascanvas.ImageArgs{CellWidth: 6, CellHeight: 9}); err != nil {
+		t.Fatalf("WriteGIF() error = %v", err)
+	}
+
+	var anim, err = gif.DecodeAll(&b)
+	if err != nil {
+		t.Fatalf("gif.DecodeAll() error = %v", err)
+	}
+
+	if want := []int{10, 25, 2}; !reflect.DeepEqual(anim.Delay, want) {
+		t.Errorf("WriteGIF() delays = %v, want %v", anim.Delay, want)
+	}
+
+	if anim.LoopCount != 0 {
+		t.Errorf("WriteGIF() loop count = %d, want 0 to loop forever", anim.LoopCount)
+	}
+
+	for i, frame := range animation {
+		var (
+			canvas = imageCanvas
+			want   bytes.Buffer
+		)
+
+		canvas.Content = frame.Content
+
+		if err = canvas.WriteImage(&want, ascanvas.ImageArgs{Format: ascanvas.ImageGIF, CellWidth: 6, CellHeight: 9}); err != nil {
+			t.Fatalf("WriteImage() error = %v", err)
+		}
+
+		var still, err = gif.Decode(&want)
+		if err != nil {
+			t.Fatalf("gif.Decode() error = %v", err)
+		}
+
+		if !reflect.DeepEqual(anim.Image[i].Pix, still.(*image.Paletted).Pix) {
+			t.Errorf("WriteGIF() frame %d differs from the canvas drawn with its content", i)
+		}
+	}
+
+	var tooLarge = ascanvas.Canvas{Width: 100, Height: 100}
+	if err = tooLarge.WriteGIF(&b, make([]ascanvas.Frame, 256), ascanvas.ImageArgs{}); !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("WriteGIF() of too many frames error = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestCanvas_WriteCast(t *testing.T) {
+	var b strings.Builder
+
+	if err := imageCanvas.WriteCast(&b, animation); err != nil {
+		t.Fatalf("WriteCast() error = %v", err)
+	}
+
+	var want = `{"version":2,"width":5,"height":3,"title":"Hello \u003cworld\u003e \u0026 co"}
+[0,"o","\u001b[H\u001b[2J+---+\r\n|Hi!|\r\n+---+"]
+[0.1,"o","\u001b[H\u001b[2J+---+\r\n|Ho!|\r\n+---+"]
+[0.35,"o","\u001b[H\u001b[2J+---+\r\n|Hu? \r\n     "]
+[0.37,"o",""]
+`
+
+	if got := b.String(); got != want {
+		t.Errorf("WriteCast() got = %s, want %s", got, want)
+	}
+}
+
+func TestCanvasService_ExportCast(t *testing.T) {
+	var (
+		ctx = context.Background()
+		s   = ascanvas.CanvasService{
+			Repo:   rm.New(),
+			Logger: zaptest.NewLogger(t),
+			Frames: rm.NewFrames(),
+		}
+	)
+
+	if err := s.Repo.Create(ctx, imageCanvas); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// the first frame saved is replaced by the canvas itself
+	var frames = append([]ascanvas.Frame{{Content: "stale", Delay: 100}}, animation[1:]...)
+	if err := s.Frames.Save(ctx, imageCanvas.Id, frames); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var got, err = s.ExportCast(ctx, imageCanvas.Id)
+	if err != nil {
+		t.Fatalf("ExportCast() error = %v", err)
+	}
+
+	var want strings.Builder
+	if err = imageCanvas.WriteCast(&want, animation); err != nil {
+		t.Fatalf("WriteCast() error = %v", err)
+	}
+
+	if string(got) != want.String() {
+		t.Errorf("ExportCast() got = %s, want %s", got, want.String())
+	}
+
+	var img []byte
+	if img, err = s.ExportImage(ctx, imageCanvas.Id, ascanvas.ImageArgs{Format: ascanvas.ImageGIF}); err != nil {
+		t.Fatalf("ExportImage() error = %v", err)
+	}
+
+	var anim *gif.GIF
+	if anim, err = gif.DecodeAll(bytes.NewReader(img)); err != nil {
+		t.Fatalf("gif.DecodeAll() error = %v", err)
+	} else if len(anim.Image) != len(animation) {
+		t.Errorf("ExportImage() as gif got %d frames, want %d", len(anim.Image), len(animation))
+	}
+
+	if _, err = s.ExportCast(ctx, "missing"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("ExportCast() of missing canvas error = %v, want ErrNotFound", err)
+	}
+}
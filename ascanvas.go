@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -31,6 +32,12 @@ var (
 
 	// ErrOutOfBounds is when a cooridnate is out of bounds
 	ErrOutOfBounds = errors.New("out of bounds")
+
+	// ErrLocked is when a region is locked by someone else
+	ErrLocked = errors.New("locked")
+
+	// ErrNotSupported is when a feature has not been enabled
+	ErrNotSupported = errors.New("not supported")
 )
 
 // Canvas is an ascii art drawing
@@ -40,6 +47,31 @@ type Canvas struct {
 	Content string `json:"content"`
 	Width   int    `json:"width"`
 	Height  int    `json:"height"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// CreatedBy and UpdatedBy are the authors given by WithAuthor, if any
+	CreatedBy string `json:"created_by,omitempty"`
+	UpdatedBy string `json:"updated_by,omitempty"`
+}
+
+// UnixNano of t, 0 for the zero time; see FromUnixNano
+func UnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+// FromUnixNano is the UTC time of nanoseconds since the epoch, the zero time for 0; see UnixNano
+func FromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, n).UTC()
 }
 
 func (c Canvas) String() string {
@@ -96,6 +128,10 @@ func (c Canvas) AsLogFields() []zap.Field {
 		zap.String("Content", c.Content),
 		zap.Int("Width", c.Width),
 		zap.Int("Height", c.Height),
+		zap.Time("CreatedAt", c.CreatedAt),
+		zap.Time("UpdatedAt", c.UpdatedAt),
+		zap.String("CreatedBy", c.CreatedBy),
+		zap.String("UpdatedBy", c.UpdatedBy),
 	}
 }
 
@@ -122,6 +158,12 @@ type CanvasRepository interface {
 	Delete(ctx context.Context, id string) error
 }
 
+// Transactor runs changes spanning repositories in one transaction, which repositories sharing it take part in
+// when given the context passed to fn; the changes are committed once fn succeeds and rolled back if it fails
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 // CanvasEventName is the type of event emitted by CanvasEvent
 type CanvasEventName string
 
@@ -140,6 +182,15 @@ const (
 type CanvasEvent struct {
 	Name   CanvasEventName
 	Canvas Canvas
+
+	// Presence is set for JOIN, LEAVE and CURSOR events
+	Presence *Presence `json:",omitempty"`
+
+	// Lock is set for LOCKED and UNLOCKED events
+	Lock *Lock `json:",omitempty"`
+
+	// Frames are set for FRAMES events
+	Frames []FrameDelay `json:",omitempty"`
 }
 
 // CanvasBroadcaster allows changes to be published or observed on a specific canvas
@@ -0,0 +1,454 @@
+package ascanvas
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ContentTypeZip is the content type of backup archives
+const ContentTypeZip = "application/zip"
+
+const (
+	// BackupVersion of the layout of backup archives; archives of later versions cannot be restored
+	BackupVersion = 1
+
+	// BackupManifestPath is where the manifest is in backup archives
+	BackupManifestPath = "manifest.json"
+
+	// MaxRenames is how many ids are tried for a canvas restored with RestoreRename before giving up
+	MaxRenames = 100
+
+	// MaxBackupFileBytes is how much is read of every file of a backup archive once uncompressed
+	MaxBackupFileBytes = 64 << 20
+
+	// MaxBackupBytes is how much is read of all files of a backup archive once uncompressed
+	MaxBackupBytes = 512 << 20
+)
+
+// BackupManifest describes the content of a backup archive
+type BackupManifest struct {
+	// Version of the layout of the archive
+	Version int `json:"version"`
+
+	// App is the version of the application that made the archive
+	App string `json:"app,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// Canvases are ids of all canvases backed up, in order
+	Canvases []string `json:"canvases"`
+
+	// Checksums are hex encoded sha256 sums of all other files of the archive, by path
+	Checksums map[string]string `json:"checksums"`
+}
+
+// Filename of the archive, after the time it was made
+func (m BackupManifest) Filename() string {
+	return "ascanvas-" + m.CreatedAt.UTC().Format("20060102-150405") + ".zip"
+}
+
+// BackupCanvas is a canvas as kept in backup archives, along with its frames if it is animated
+type BackupCanvas struct {
+	Canvas
+	Frames []Frame `json:"frames,omitempty"`
+}
+
+// backupPath of a file of a canvas in backup archives
+func backupPath(id string, ext string) string {
+	return "canvases/" + id + ext
+}
+
+// Backup all canvases into a zip archive, every canvas as json and as text, one row per line, with a manifest;
+// app is the version of the application recorded in the manifest
+func (s CanvasService) Backup(ctx context.Context, w io.Writer, app string) (*BackupManifest, error) {
+	s.Logger.Debug("Backup::Fetching")
+
+	var canvases, err = s.Repo.List(ctx)
+	if err != nil {
+		s.Logger.Error("Backup::Fetch::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	sort.Slice(canvases, func(i, j int) bool {
+		return canvases[i].Id < canvases[j].Id
+	})
+
+	var (
+		z        = zip.NewWriter(w)
+		manifest = BackupManifest{
+			Version:   BackupVersion,
+			App:       app,
+			CreatedAt: s.now(),
+			Canvases:  make([]string, 0, len(canvases)),
+			Checksums: make(map[string]string, 2*len(canvases)),
+		}
+	)
+
+	var write = func(path string, modified time.Time, b []byte) error {
+		var sum = sha256.Sum256(b)
+		manifest.Checksums[path] = hex.EncodeToString(sum[:])
+
+		var f, err = z.CreateHeader(&zip.FileHeader{Name: path, Method: zip.Deflate, Modified: modified})
+		if err == nil {
+			_, err = f.Write(b)
+		}
+
+		return err
+	}
+
+	for _, canvas := range canvases {
+		var c = BackupCanvas{Canvas: canvas}
+
+		if s.Frames != nil {
+			if c.Frames, err = s.Frames.Get(ctx, canvas.Id); err != nil {
+				s.Logger.Error("Backup::Frames::Failed", zap.String("id", canvas.Id), zap.Error(err))
+				return nil, err
+			}
+		}
+
+		var b []byte
+		if b, err = json.MarshalIndent(c, "", "  "); err != nil {
+			return nil, err
+		}
+
+		if err = write(backupPath(canvas.Id, ".json"), canvas.UpdatedAt, append(b, '\n')); err != nil {
+			s.Logger.Error("Backup::Write::Failed", zap.String("id", canvas.Id), zap.Error(err))
+			return nil, err
+		}
+
+		if err = write(backupPath(canvas.Id, ".txt"), canvas.UpdatedAt, []byte(strings.Join(canvas.Rows(), "\n")+"\n")); err != nil {
+			s.Logger.Error("Backup::Write::Failed", zap.String("id", canvas.Id), zap.Error(err))
+			return nil, err
+		}
+
+		manifest.Canvases = append(manifest.Canvases, canvas.Id)
+	}
+
+	var b []byte
+	if b, err = json.MarshalIndent(manifest, "", "  "); err != nil {
+		return nil, err
+	}
+
+	var f io.Writer
+	if f, err = z.CreateHeader(&zip.FileHeader{Name: BackupManifestPath, Method: zip.Deflate, Modified: manifest.CreatedAt}); err == nil {
+		if _, err = f.Write(append(b, '\n')); err == nil {
+			err = z.Close()
+		}
+	}
+
+	if err != nil {
+		s.Logger.Error("Backup::Write::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	s.Logger.Debug("Backup::Done", zap.Int("canvases", len(manifest.Canvases)))
+
+	return &manifest, nil
+}
+
+// RestoreCollision is what is done with a canvas restored from a backup when a different one has the same id
+type RestoreCollision string
+
+const (
+	// RestoreSkip keeps the existing canvas
+	RestoreSkip RestoreCollision = "skip"
+
+	// RestoreOverwrite replaces the existing canvas with the one restored
+	RestoreOverwrite RestoreCollision = "overwrite"
+
+	// RestoreRename restores the canvas with the id suffixed by -1, -2 and so on, the first that is free
+	RestoreRename RestoreCollision = "rename"
+)
+
+// RestoreArgs to restore canvases from a backup archive
+type RestoreArgs struct {
+	// OnCollision is what is done when a different canvas has the same id; RestoreSkip by default
+	OnCollision RestoreCollision `json:"on_collision"`
+}
+
+// WithDefaults applied to unset fields
+func (a RestoreArgs) WithDefaults() RestoreArgs {
+	if a.OnCollision == "" {
+		a.OnCollision = RestoreSkip
+	}
+
+	return a
+}
+
+// Validate RestoreArgs
+func (a RestoreArgs) Validate() error {
+	switch a.OnCollision {
+	case RestoreSkip, RestoreOverwrite, RestoreRename:
+		return nil
+	default:
+		return fmt.Errorf("%w: OnCollision must be skip, overwrite or rename", ErrInvalidInput)
+	}
+}
+
+// RestoreReport tells what was done with every canvas of a backup archive, by id in the archive
+type RestoreReport struct {
+	// Created are canvases that did not exist
+	Created []string `json:"created"`
+
+	// Unchanged are canvases that exist as they are in the archive, under the same id or a renamed one
+	Unchanged []string `json:"unchanged"`
+
+	// Skipped are canvases with the same id as a different one, that was kept
+	Skipped []string `json:"skipped"`
+
+	// Overwritten are canvases with the same id as a different one, that was replaced
+	Overwritten []string `json:"overwritten"`
+
+	// Renamed are canvases with the same id as a different one, restored with another id given here
+	Renamed map[string]string `json:"renamed"`
+}
+
+// RestoreBackup of canvases from a zip archive made by Backup; all checksums are verified before anything is restored,
+// and restoring the same archive again changes nothing
+func (s CanvasService) RestoreBackup(ctx context.Context, r io.ReaderAt, size int64, args RestoreArgs) (*RestoreReport, error) {
+	args = args.WithDefaults()
+
+	if err := args.Validate(); err != nil {
+		s.Logger.Debug("RestoreBackup::Validate::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	var canvases, err = readBackup(r, size)
+	if err != nil {
+		s.Logger.Debug("RestoreBackup::Read::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	var report = RestoreReport{
+		Created:     []string{},
+		Unchanged:   []string{},
+		Skipped:     []string{},
+		Overwritten: []string{},
+		Renamed:     map[string]string{},
+	}
+
+	for _, c := range canvases {
+		if err = s.restoreCanvas(ctx, c, args.OnCollision, &report); err != nil {
+			s.Logger.Error("RestoreBackup::Failed", zap.String("id", c.Id), zap.Error(err))
+			return &report, err
+		}
+	}
+
+	s.Logger.Debug("RestoreBackup::Done",
+		zap.Int("created", len(report.Created)),
+		zap.Int("unchanged", len(report.Unchanged)),
+		zap.Int("skipped", len(report.Skipped)),
+		zap.Int("overwritten", len(report.Overwritten)),
+		zap.Int("renamed", len(report.Renamed)),
+	)
+
+	return &report, nil
+}
+
+// restoreCanvas from a backup as per onCollision, recording what was done in report
+func (s CanvasService) restoreCanvas(ctx context.Context, c BackupCanvas, onCollision RestoreCollision, report *RestoreReport) error {
+	var existing, err = s.Repo.Get(ctx, c.Id)
+
+	switch {
+	case err == ErrNotFound:
+		return s.restoreAs(ctx, c, c.Id, CanvasEventCreated, func() {
+			report.Created = append(report.Created, c.Id)
+		})
+	case err != nil:
+		return err
+	case sameCanvas(*existing, c.Canvas):
+		report.Unchanged = append(report.Unchanged, c.Id)
+		return nil
+	}
+
+	switch onCollision {
+	case RestoreOverwrite:
+		return s.restoreAs(ctx, c, c.Id, CanvasEventUpdated, func() {
+			report.Overwritten = append(report.Overwritten, c.Id)
+		})
+	case RestoreRename:
+		break
+	default:
+		report.Skipped = append(report.Skipped, c.Id)
+		return nil
+	}
+
+	// renamed ids are tried in the same order every time, for a canvas restored again to be found unchanged
+	for i := 1; i <= MaxRenames; i++ {
+		var id = fmt.Sprintf("%s-%d", c.Id, i)
+
+		if existing, err = s.Repo.Get(ctx, id); err == ErrNotFound {
+			return s.restoreAs(ctx, c, id, CanvasEventCreated, func() {
+				report.Renamed[c.Id] = id
+			})
+		} else if err != nil {
+			return err
+		} else if sameCanvas(*existing, c.Canvas) {
+			report.Unchanged = append(report.Unchanged, c.Id)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: no free id to rename canvas %s", ErrInvalidInput, c.Id)
+}
+
+// restoreAs a canvas from a backup under id, creating or updating it as per event, then call done
+func (s CanvasService) restoreAs(ctx context.Context, c BackupCanvas, id string, event CanvasEventName, done func()) error {
+	var (
+		canvas = c.Canvas
+		err    error
+	)
+
+	canvas.Id = id
+
+	if event == CanvasEventCreated {
+		err = s.Repo.Create(ctx, canvas)
+	} else {
+		err = s.Repo.Update(ctx, canvas)
+	}
+
+	if err == nil && s.Frames != nil {
+		if len(c.Frames) == 0 {
+			err = s.Frames.Delete(ctx, id)
+		} else {
+			err = s.Frames.Save(ctx, id, c.Frames)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	done()
+
+	s.Logger.Debug("RestoreBackup::Restored", canvas.AsLogFields()...)
+	s.record(ctx, RevisionRestored, 0, canvas)
+	s.Broadcast(ctx, s.BroadCaster, s.Logger, CanvasEvent{
+		Name:   event,
+		Canvas: canvas,
+	})
+
+	return nil
+}
+
+// sameCanvas tells if canvases look the same, regardless of when and by whom they were changed
+func sameCanvas(a, b Canvas) bool {
+	return a.Name == b.Name && a.Content == b.Content && a.Width == b.Width && a.Height == b.Height
+}
+
+// readBackup archive, checking the manifest and checksums, into its canvases in order
+func readBackup(r io.ReaderAt, size int64) ([]BackupCanvas, error) {
+	var z, err = zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a zip archive", ErrInvalidInput)
+	}
+
+	var (
+		files = make(map[string][]byte, len(z.File))
+		left  = int64(MaxBackupBytes)
+	)
+
+	for _, f := range z.File {
+		var limit = int64(MaxBackupFileBytes)
+		if left < limit {
+			limit = left
+		}
+
+		var b []byte
+		if b, err = readZipFile(f, limit); errors.Is(err, errTooLarge) && limit < MaxBackupFileBytes {
+			return nil, fmt.Errorf("%w: backup is larger than %d bytes uncompressed", ErrInvalidInput, MaxBackupBytes)
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %s cannot be read: %s", ErrInvalidInput, f.Name, err)
+		}
+
+		files[f.Name] = b
+		left -= int64(len(b))
+	}
+
+	var manifest BackupManifest
+
+	if b, ok := files[BackupManifestPath]; !ok {
+		return nil, fmt.Errorf("%w: %s is missing", ErrInvalidInput, BackupManifestPath)
+	} else if err = json.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: %s is not valid json", ErrInvalidInput, BackupManifestPath)
+	}
+
+	if manifest.Version < 1 || manifest.Version > BackupVersion {
+		return nil, fmt.Errorf("%w: backup version %d is not supported, up to %d is", ErrInvalidInput, manifest.Version, BackupVersion)
+	}
+
+	for path, checksum := range manifest.Checksums {
+		var b, ok = files[path]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is missing", ErrInvalidInput, path)
+		}
+
+		if sum := sha256.Sum256(b); hex.EncodeToString(sum[:]) != checksum {
+			return nil, fmt.Errorf("%w: checksum of %s does not match", ErrInvalidInput, path)
+		}
+	}
+
+	var canvases = make([]BackupCanvas, len(manifest.Canvases))
+
+	for i, id := range manifest.Canvases {
+		var path = backupPath(id, ".json")
+
+		if _, ok := manifest.Checksums[path]; !ok {
+			return nil, fmt.Errorf("%w: %s has no checksum", ErrInvalidInput, path)
+		}
+
+		var c = &canvases[i]
+		if err = json.Unmarshal(files[path], c); err != nil {
+			return nil, fmt.Errorf("%w: %s is not valid json", ErrInvalidInput, path)
+		}
+
+		if c.Id != id || c.Width < 1 || c.Height < 1 || len(c.Content) != c.Width*c.Height {
+			return nil, fmt.Errorf("%w: %s is not a valid canvas", ErrInvalidInput, path)
+		}
+
+		for _, frame := range c.Frames {
+			if len(frame.Content) != len(c.Content) {
+				return nil, fmt.Errorf("%w: %s has frames of the wrong size", ErrInvalidInput, path)
+			}
+		}
+	}
+
+	return canvases, nil
+}
+
+// errTooLarge is returned by readZipFile for files larger than the limit given
+var errTooLarge = errors.New("file too large")
+
+// readZipFile fully, unless it is larger than limit once uncompressed
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%w: %d bytes at most", errTooLarge, limit)
+	}
+
+	var r, err = f.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	// the size in the header is not to be trusted, and checked by reading one more byte than allowed
+	var b bytes.Buffer
+	if _, err = b.ReadFrom(io.LimitReader(r, limit+1)); err == nil && int64(b.Len()) > limit {
+		err = fmt.Errorf("%w: %d bytes at most", errTooLarge, limit)
+	}
+
+	return b.Bytes(), err
+}
@@ -0,0 +1,286 @@
+package ascanvas_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	mb "github.com/fluxynet/ascanvas/broadcaster/mocks"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
+)
+
+func backupService(t *testing.T, canvases ...ascanvas.Canvas) ascanvas.CanvasService {
+	var (
+		brd = &mb.CanvasBroadcaster{}
+		s   = ascanvas.CanvasService{
+			Repo:        rm.New(),
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Clock:       ascanvas.StaticClock(now),
+			Frames:      rm.NewFrames(),
+		}
+	)
+
+	brd.On("Broadcast", mock.Anything, mock.Anything).Return(nil)
+
+	for i := range canvases {
+		if err := s.Repo.Create(context.Background(), canvases[i]); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	return s
+}
+
+func TestCanvasService_Backup(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		canvases = []ascanvas.Canvas{
+			{Id: "2", Name: "Two", Content: "ab\x01d", Width: 2, Height: 2, CreatedAt: now, UpdatedAt: now, CreatedBy: "carol"},
+			{Id: "1", Name: "One", Content: "1", Width: 1, Height: 1, CreatedAt: now, UpdatedAt: now},
+		}
+		frames = []ascanvas.Frame{{Content: "1", Delay: 100}, {Content: "0", Delay: 200}}
+		s      = backupService(t, canvases...)
+		b      bytes.Buffer
+	)
+
+	if err := s.Frames.Save(ctx, "1", frames); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var manifest, err = s.Backup(ctx, &b, "v1.2.3")
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	if manifest.Version != ascanvas.BackupVersion || manifest.App != "v1.2.3" || !manifest.CreatedAt.Equal(now) {
+		t.Errorf("Backup() manifest = %v", manifest)
+	}
+
+	if want := []string{"1", "2"}; !reflect.DeepEqual(manifest.Canvases, want) {
+		t.Errorf("Backup() canvases = %v, want %v", manifest.Canvases, want)
+	}
+
+	if manifest.Filename() != "ascanvas-20211101-120000.zip" {
+		t.Errorf("Filename() got = %s", manifest.Filename())
+	}
+
+	var z *zip.Reader
+	if z, err = zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len())); err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+
+	var names []string
+	for _, f := range z.File {
+		names = append(names, f.Name)
+	}
+
+	var wantNames = []string{"canvases/1.json", "canvases/1.txt", "canvases/2.json", "canvases/2.txt", "manifest.json"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("Backup() files = %v, want %v", names, wantNames)
+	}
+
+	if len(manifest.Checksums) != 4 {
+		t.Errorf("Backup() checksums = %v, want one per canvas file", manifest.Checksums)
+	}
+
+	var r io.ReadCloser
+	if r, err = z.File[3].Open(); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	var text bytes.Buffer
+	_, _ = text.ReadFrom(r)
+	_ = r.Close()
+
+	if text.String() != "ab\n?d\n" {
+		t.Errorf("Backup() text = %q, want %q", text.String(), "ab\n?d\n")
+	}
+
+	// restored elsewhere as they were, frames included
+	var restored = backupService(t)
+
+	var report *ascanvas.RestoreReport
+	if report, err = restored.RestoreBackup(ctx, bytes.NewReader(b.Bytes()), int64(b.Len()), ascanvas.RestoreArgs{}); err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(report.Created, []string{"1", "2"}) {
+		t.Errorf("RestoreBackup() created = %v, want all", report.Created)
+	}
+
+	for _, want := range canvases {
+		var got, err = restored.Repo.Get(ctx, want.Id)
+		if err != nil {
+			t.Errorf("Get(%s) error = %v", want.Id, err)
+		} else if !reflect.DeepEqual(*got, want) {
+			t.Errorf("Get(%s) got = %v, want %v", want.Id, *got, want)
+		}
+	}
+
+	var got []ascanvas.Frame
+	if got, err = restored.ListFrames(ctx, "1"); err != nil || !reflect.DeepEqual(got, frames) {
+		t.Errorf("ListFrames() got = %v, %v, want %v", got, err, frames)
+	}
+}
+
+func TestCanvasService_RestoreBackup_Collisions(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		one    = ascanvas.Canvas{Id: "1", Name: "One", Content: "1", Width: 1, Height: 1}
+		two    = ascanvas.Canvas{Id: "2", Name: "Two", Content: "2", Width: 1, Height: 1}
+		other  = ascanvas.Canvas{Id: "2", Name: "Other", Content: "x", Width: 1, Height: 1}
+		source = backupService(t, one, two)
+		b      bytes.Buffer
+	)
+
+	if _, err := source.Backup(ctx, &b, ""); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	var restore = func(s ascanvas.CanvasService, onCollision ascanvas.RestoreCollision) *ascanvas.RestoreReport {
+		t.Helper()
+
+		var report, err = s.RestoreBackup(ctx, bytes.NewReader(b.Bytes()), int64(b.Len()), ascanvas.RestoreArgs{OnCollision: onCollision})
+		if err != nil {
+			t.Fatalf("RestoreBackup(%s) error = %v", onCollision, err)
+		}
+
+		return report
+	}
+
+	var wantContent = func(s ascanvas.CanvasService, id string, want string) {
+		t.Helper()
+
+		if got, err := s.Repo.Get(ctx, id); err != nil || got.Content != want {
+			t.Errorf("Get(%s) got = %v, %v, want content %q", id, got, err, want)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		onCollision ascanvas.RestoreCollision
+		want        ascanvas.RestoreReport
+		wantAgain   ascanvas.RestoreReport
+		content     map[string]string
+	}{
+		{
+			name:        "skip",
+			onCollision: ascanvas.RestoreSkip,
+			want:        ascanvas.RestoreReport{Created: []string{"1"}, Unchanged: []string{}, Skipped: []string{"2"}, Overwritten: []string{}, Renamed: map[string]string{}},
+			wantAgain:   ascanvas.RestoreReport{Created: []string{}, Unchanged: []string{"1"}, Skipped: []string{"2"}, Overwritten: []string{}, Renamed: map[string]string{}},
+			content:     map[string]string{"1": "1", "2": "x"},
+		},
+		{
+			name:        "overwrite",
+			onCollision: ascanvas.RestoreOverwrite,
+			want:        ascanvas.RestoreReport{Created: []string{"1"}, Unchanged: []string{}, Skipped: []string{}, Overwritten: []string{"2"}, Renamed: map[string]string{}},
+			wantAgain:   ascanvas.RestoreReport{Created: []string{}, Unchanged: []string{"1", "2"}, Skipped: []string{}, Overwritten: []string{}, Renamed: map[string]string{}},
+			content:     map[string]string{"1": "1", "2": "2"},
+		},
+		{
+			name:        "rename",
+			onCollision: ascanvas.RestoreRename,
+			want:        ascanvas.RestoreReport{Created: []string{"1"}, Unchanged: []string{}, Skipped: []string{}, Overwritten: []string{}, Renamed: map[string]string{"2": "2-1"}},
+			wantAgain:   ascanvas.RestoreReport{Created: []string{}, Unchanged: []string{"1", "2"}, Skipped: []string{}, Overwritten: []string{}, Renamed: map[string]string{}},
+			content:     map[string]string{"1": "1", "2": "x", "2-1": "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s = backupService(t, other)
+
+			if got := restore(s, tt.onCollision); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("RestoreBackup() got = %v, want %v", *got, tt.want)
+			}
+
+			if got := restore(s, tt.onCollision); !reflect.DeepEqual(*got, tt.wantAgain) {
+				t.Errorf("RestoreBackup() again got = %v, want %v", *got, tt.wantAgain)
+			}
+
+			for id, content := range tt.content {
+				wantContent(s, id, content)
+			}
+		})
+	}
+}
+
+func TestCanvasService_RestoreBackup_Invalid(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		source = backupService(t, ascanvas.Canvas{Id: "1", Name: "One", Content: "1", Width: 1, Height: 1})
+		b      bytes.Buffer
+	)
+
+	if _, err := source.Backup(ctx, &b, ""); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	// archive with the files of the backup, some replaced or left out when set to nil
+	var archive = func(replaced map[string][]byte) []byte {
+		var (
+			z, _ = zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+			out  bytes.Buffer
+			w    = zip.NewWriter(&out)
+		)
+
+		for _, f := range z.File {
+			var content, ok = replaced[f.Name]
+			if !ok {
+				var r, _ = f.Open()
+				var buf bytes.Buffer
+				_, _ = buf.ReadFrom(r)
+				content = buf.Bytes()
+			} else if content == nil {
+				continue
+			}
+
+			var fw, _ = w.Create(f.Name)
+			_, _ = fw.Write(content)
+		}
+
+		_ = w.Close()
+
+		return out.Bytes()
+	}
+
+	tests := []struct {
+		name    string
+		archive []byte
+		args    ascanvas.RestoreArgs
+	}{
+		{name: "not a zip", archive: []byte("hello")},
+		{name: "no manifest", archive: archive(map[string][]byte{"manifest.json": nil})},
+		{name: "manifest not json", archive: archive(map[string][]byte{"manifest.json": []byte("{")})},
+		{name: "later version", archive: archive(map[string][]byte{"manifest.json": []byte(`{"version":2}`)})},
+		{name: "file missing", archive: archive(map[string][]byte{"canvases/1.txt": nil})},
+		{name: "checksum", archive: archive(map[string][]byte{"canvases/1.json": []byte(`{"id":"1","content":"2","width":1,"height":1}`)})},
+		{name: "collision", archive: b.Bytes(), args: ascanvas.RestoreArgs{OnCollision: "merge"}},
+		{name: "file too large", archive: archive(map[string][]byte{"canvases/1.txt": make([]byte, ascanvas.MaxBackupFileBytes+1)})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s = backupService(t)
+
+			var _, err = s.RestoreBackup(ctx, bytes.NewReader(tt.archive), int64(len(tt.archive)), tt.args)
+			if !errors.Is(err, ascanvas.ErrInvalidInput) {
+				t.Errorf("RestoreBackup() error = %v, want ErrInvalidInput", err)
+			}
+
+			if canvases, _ := s.Repo.List(ctx); len(canvases) != 0 {
+				t.Errorf("RestoreBackup() restored %v, want nothing", canvases)
+			}
+		})
+	}
+}
@@ -38,8 +38,9 @@ func (m *Memory) Observe(ctx context.Context, id string) (ascanvas.StopObserveFu
 	var stop = func() {
 		defer m.mutex.Unlock()
 		m.mutex.Lock()
-		if _, ok := m.listeners[id]; ok {
+		if l, ok := m.listeners[id][i]; ok {
 			delete(m.listeners[id], i)
+			close(l)
 		}
 	}
 
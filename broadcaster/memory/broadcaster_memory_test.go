@@ -8,6 +8,7 @@ import (
 
 	"github.com/fluxynet/ascanvas"
 	"github.com/fluxynet/ascanvas/broadcaster/memory"
+	"github.com/fluxynet/ascanvas/conformance"
 )
 
 type receiver struct {
@@ -270,3 +271,9 @@ func TestMemory(t *testing.T) {
 		})
 	}
 }
+
+func TestMemory_Conformance(t *testing.T) {
+	conformance.TestBroadcaster(t, func(t *testing.T) conformance.Broadcaster {
+		return memory.New()
+	})
+}
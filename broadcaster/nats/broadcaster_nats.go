@@ -0,0 +1,212 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	gonats "github.com/nats-io/nats.go"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// DefaultPrefix is prepended to canvas ids to form subjects, i.e. ascanvas.canvas.<id>
+const DefaultPrefix = "ascanvas.canvas"
+
+// ErrServerNotReady means the embedded server did not start accepting connections in time
+var ErrServerNotReady = errors.New("nats server not ready for connections")
+
+// Broadcaster publishes events on a subject per canvas and subscribes to them for observing
+type Broadcaster struct {
+	Conn   *gonats.Conn
+	Prefix string
+
+	listeners map[int]*listener
+	mutex     sync.Mutex
+	counter   int
+}
+
+type listener struct {
+	sub    *gonats.Subscription
+	events chan ascanvas.CanvasEvent
+	done   chan struct{}
+	mutex  sync.RWMutex
+	once   sync.Once
+	closed bool
+}
+
+// New Broadcaster using an established connection; the connection is closed along with the Broadcaster
+func New(conn *gonats.Conn, prefix string) *Broadcaster {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	return &Broadcaster{
+		Conn:      conn,
+		Prefix:    prefix,
+		listeners: make(map[int]*listener),
+	}
+}
+
+// Connect to a NATS server at url and create a Broadcaster on that connection
+func Connect(url string) (*Broadcaster, error) {
+	var conn, err = gonats.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(conn, DefaultPrefix), nil
+}
+
+// RunServer starts an in-process NATS server; a port of -1 picks a random one
+func RunServer(host string, port int) (*server.Server, error) {
+	var s, err = server.NewServer(&server.Options{
+		Host:   host,
+		Port:   port,
+		NoLog:  true,
+		NoSigs: true,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	go s.Start()
+
+	if !s.ReadyForConnections(5 * time.Second) {
+		s.Shutdown()
+		return nil, ErrServerNotReady
+	}
+
+	return s, nil
+}
+
+// Subject on which events of a canvas are published; ascanvas.ObserveALL maps to a wildcard.
+// The id is escaped to a single token, so that no id matches events of other canvases.
+func (b *Broadcaster) Subject(id string) string {
+	if id == ascanvas.ObserveALL {
+		return b.Prefix + ".*"
+	}
+
+	return b.Prefix + "." + EscapeToken(id)
+}
+
+// EscapeToken percent-encodes characters with a meaning in subjects, i.e. separators, wildcards and whitespace;
+// other characters are kept as is and an empty id becomes %
+func EscapeToken(id string) string {
+	if id == "" {
+		return "%"
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case c == '.' || c == '*' || c == '>' || c == '%' || c <= ' ' || c == 0x7f:
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+func (b *Broadcaster) Observe(ctx context.Context, id string) (ascanvas.StopObserveFunc, <-chan ascanvas.CanvasEvent, error) {
+	var l = &listener{
+		events: make(chan ascanvas.CanvasEvent),
+		done:   make(chan struct{}),
+	}
+
+	var sub, err = b.Conn.Subscribe(b.Subject(id), l.receive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l.sub = sub
+
+	// ensures the server registered the subscription before any further publishing
+	if err = b.Conn.Flush(); err != nil {
+		_ = sub.Unsubscribe()
+		return nil, nil, err
+	}
+
+	defer b.mutex.Unlock()
+	b.mutex.Lock()
+
+	b.counter += 1
+	var i = b.counter
+	b.listeners[i] = l
+
+	var stop = func() {
+		b.mutex.Lock()
+		delete(b.listeners, i)
+		b.mutex.Unlock()
+
+		l.close()
+	}
+
+	return stop, l.events, nil
+}
+
+func (b *Broadcaster) Broadcast(ctx context.Context, event ascanvas.CanvasEvent) error {
+	var payload, err = json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return b.Conn.Publish(b.Subject(event.Canvas.Id), payload)
+}
+
+// Close all observer channels and the underlying connection
+func (b *Broadcaster) Close() error {
+	b.mutex.Lock()
+	var listeners = b.listeners
+	b.listeners = make(map[int]*listener)
+	b.mutex.Unlock()
+
+	for i := range listeners {
+		listeners[i].close()
+	}
+
+	b.Conn.Close()
+
+	return nil
+}
+
+func (l *listener) receive(msg *gonats.Msg) {
+	var event ascanvas.CanvasEvent
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		return
+	}
+
+	defer l.mutex.RUnlock()
+	l.mutex.RLock()
+
+	if l.closed {
+		return
+	}
+
+	select {
+	case l.events <- event:
+	case <-l.done:
+	}
+}
+
+func (l *listener) close() {
+	l.once.Do(func() {
+		close(l.done)
+		_ = l.sub.Unsubscribe()
+
+		defer l.mutex.Unlock()
+		l.mutex.Lock()
+
+		l.closed = true
+		close(l.events)
+	})
+}
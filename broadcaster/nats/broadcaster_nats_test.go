@@ -0,0 +1,276 @@
+package nats_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/broadcaster/nats"
+	"github.com/fluxynet/ascanvas/conformance"
+)
+
+type receiver struct {
+	ObserveId string
+	Want      []ascanvas.CanvasEvent
+	Events    []ascanvas.CanvasEvent
+}
+
+func (r *receiver) receive(events <-chan ascanvas.CanvasEvent, n int) {
+	for e := range events {
+		r.Events = append(r.Events, e)
+
+		if len(r.Events) == n {
+			break
+		}
+	}
+}
+
+func TestBroadcaster(t *testing.T) {
+	var (
+		c1 = ascanvas.Canvas{Id: "1", Name: "C1", Content: "XY", Width: 1, Height: 2}
+		c2 = ascanvas.Canvas{Id: "2", Name: "C2", Content: "ABCD", Width: 2, Height: 2}
+	)
+
+	tests := []struct {
+		name      string
+		events    []ascanvas.CanvasEvent
+		receivers []receiver
+	}{
+		{
+			name: "1 listener id = 1, 1 event id 1",
+			events: []ascanvas.CanvasEvent{
+				{Name: ascanvas.CanvasEventCreated, Canvas: c1},
+			},
+			receivers: []receiver{
+				{
+					ObserveId: "1",
+					Want: []ascanvas.CanvasEvent{
+						{Name: ascanvas.CanvasEventCreated, Canvas: c1},
+					},
+				},
+			},
+		},
+		{
+			name: "1 listener id = 2, 2 events id 1, id 2",
+			events: []ascanvas.CanvasEvent{
+				{Name: ascanvas.CanvasEventDeleted, Canvas: c1},
+				{Name: ascanvas.CanvasEventCreated, Canvas: c2},
+			},
+			receivers: []receiver{
+				{
+					ObserveId: "2",
+					Want: []ascanvas.CanvasEvent{
+						{Name: ascanvas.CanvasEventCreated, Canvas: c2},
+					},
+				},
+			},
+		},
+		{
+			name: "3 listeners: all, id = 1, id = 2; 3 events id 1, id 2, id 1",
+			events: []ascanvas.CanvasEvent{
+				{Name: ascanvas.CanvasEventCreated, Canvas: c1},
+				{Name: ascanvas.CanvasEventCreated, Canvas: c2},
+				{Name: ascanvas.CanvasEventDeleted, Canvas: c1},
+			},
+			receivers: []receiver{
+				{
+					ObserveId: ascanvas.ObserveALL,
+					Want: []ascanvas.CanvasEvent{
+						{Name: ascanvas.CanvasEventCreated, Canvas: c1},
+						{Name: ascanvas.CanvasEventCreated, Canvas: c2},
+						{Name: ascanvas.CanvasEventDeleted, Canvas: c1},
+					},
+				},
+				{
+					ObserveId: "1",
+					Want: []ascanvas.CanvasEvent{
+						{Name: ascanvas.CanvasEventCreated, Canvas: c1},
+						{Name: ascanvas.CanvasEventDeleted, Canvas: c1},
+					},
+				},
+				{
+					ObserveId: "2",
+					Want: []ascanvas.CanvasEvent{
+						{Name: ascanvas.CanvasEventCreated, Canvas: c2},
+					},
+				},
+			},
+		},
+	}
+
+	srv, err := nats.RunServer("127.0.0.1", -1)
+	if err != nil {
+		t.Fatalf("RunServer() error = %v", err)
+	}
+	defer srv.Shutdown()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+
+			// observers and publisher use distinct connections, as separate processes would
+			observer, err := nats.Connect(srv.ClientURL())
+			if err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+
+			publisher, err := nats.Connect(srv.ClientURL())
+			if err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+
+			defer publisher.Close()
+
+			wg.Add(len(tt.receivers))
+
+			for i := range tt.receivers {
+				_, c, err := observer.Observe(context.Background(), tt.receivers[i].ObserveId)
+				if err != nil {
+					t.Errorf("Observe() error = %v", err)
+					return
+				}
+
+				go func(i int) {
+					defer wg.Done()
+					tt.receivers[i].receive(c, len(tt.receivers[i].Want))
+				}(i)
+			}
+
+			for i := range tt.events {
+				if err := publisher.Broadcast(context.Background(), tt.events[i]); err != nil {
+					t.Errorf("Broadcast() error = %v", err)
+					return
+				}
+			}
+
+			wg.Wait()
+
+			if err := observer.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+				return
+			}
+
+			for i := range tt.receivers {
+				if !reflect.DeepEqual(tt.receivers[i].Events, tt.receivers[i].Want) {
+					t.Errorf("receivers got = %v, want %v", tt.receivers[i].Events, tt.receivers[i].Want)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestBroadcaster_Stop(t *testing.T) {
+	srv, err := nats.RunServer("127.0.0.1", -1)
+	if err != nil {
+		t.Fatalf("RunServer() error = %v", err)
+	}
+	defer srv.Shutdown()
+
+	b, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer b.Close()
+
+	stop, c, err := b.Observe(context.Background(), ascanvas.ObserveALL)
+	if err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+
+	stop()
+
+	if _, ok := <-c; ok {
+		t.Errorf("channel not closed after stop")
+	}
+
+	// stopping again or closing the broadcaster after stop must not panic
+	stop()
+}
+
+func TestEscapeToken(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{id: "1", want: "1"},
+		{id: "6f1c-2a-b_c", want: "6f1c-2a-b_c"},
+		{id: "a.b", want: "a%2Eb"},
+		{id: "*", want: "%2A"},
+		{id: ">", want: "%3E"},
+		{id: "50%", want: "50%25"},
+		{id: "a b\tc", want: "a%20b%09c"},
+		{id: "", want: "%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := nats.EscapeToken(tt.id); got != tt.want {
+				t.Errorf("EscapeToken() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBroadcaster_SubjectIds(t *testing.T) {
+	srv, err := nats.RunServer("127.0.0.1", -1)
+	if err != nil {
+		t.Fatalf("RunServer() error = %v", err)
+	}
+	defer srv.Shutdown()
+
+	b, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer b.Close()
+
+	// ids that would be wildcards or several tokens as is
+	var ids = []string{"*", ">", "a.b", "a"}
+
+	var (
+		ctx       = context.Background()
+		observers = make([]<-chan ascanvas.CanvasEvent, len(ids))
+	)
+
+	for i, id := range ids {
+		var stop ascanvas.StopObserveFunc
+		if stop, observers[i], err = b.Observe(ctx, id); err != nil {
+			t.Fatalf("Observe(%s) error = %v", id, err)
+		}
+		defer stop()
+	}
+
+	// broadcast in reverse, so that wildcards would receive events of other canvases first
+	for i := len(ids) - 1; i >= 0; i-- {
+		var id = ids[i]
+		if err = b.Broadcast(ctx, ascanvas.CanvasEvent{Name: ascanvas.CanvasEventUpdated, Canvas: ascanvas.Canvas{Id: id}}); err != nil {
+			t.Fatalf("Broadcast(%s) error = %v", id, err)
+		}
+	}
+
+	for i, id := range ids {
+		if event := <-observers[i]; event.Canvas.Id != id {
+			t.Errorf("Observe(%s) got event of %s", id, event.Canvas.Id)
+		}
+	}
+}
+
+func TestBroadcaster_Conformance(t *testing.T) {
+	srv, err := nats.RunServer("127.0.0.1", -1)
+	if err != nil {
+		t.Fatalf("RunServer() error = %v", err)
+	}
+	defer srv.Shutdown()
+
+	conformance.TestBroadcaster(t, func(t *testing.T) conformance.Broadcaster {
+		b, err := nats.Connect(srv.ClientURL())
+		if err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+
+		return b
+	})
+}
@@ -0,0 +1,227 @@
+package sequel
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/broadcaster/memory"
+	"github.com/fluxynet/ascanvas/internal"
+	rs "github.com/fluxynet/ascanvas/repo/sequel"
+)
+
+const (
+	// DefaultPollInterval is how often the event table is checked for new events
+	DefaultPollInterval = 250 * time.Millisecond
+
+	// DefaultRetention is how long events are kept in the event table before being pruned
+	DefaultRetention = time.Hour
+
+	// DefaultGrace is how long an event missing between others is waited for
+	DefaultGrace = 30 * time.Second
+)
+
+// Broadcaster propagates events between processes sharing the same database.
+// Events are written to the canvas_event table, created by the repo/sequel migrations,
+// and every process polls that table, relaying new rows to its local observers.
+// Seqs are allocated before events are committed, so that with concurrent writers an event may show up after
+// one with a greater seq; such missing seqs are looked for again for Grace before being given up on.
+type Broadcaster struct {
+	DB        *sql.DB
+	Dialect   rs.Dialect
+	Interval  time.Duration
+	Retention time.Duration
+	Grace     time.Duration
+
+	local *memory.Memory
+
+	// floor is the seq up to which all events were relayed or given up on
+	floor int64
+
+	// seen are seqs greater than floor already relayed
+	seen map[int64]bool
+
+	// missing are seqs greater than floor not found, with when they were first missed
+	missing map[int64]time.Time
+
+	pruned time.Time
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New Broadcaster polling the database at the given interval; only events broadcast after this call are relayed
+func New(db *sql.DB, dialect rs.Dialect, interval time.Duration) (*Broadcaster, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	var b = &Broadcaster{
+		DB:        db,
+		Dialect:   dialect,
+		Interval:  interval,
+		Retention: DefaultRetention,
+		Grace:     DefaultGrace,
+		local:     memory.New(),
+		seen:      make(map[int64]bool),
+		missing:   make(map[int64]time.Time),
+		pruned:    time.Now(),
+		done:      make(chan struct{}),
+	}
+
+	var err = db.QueryRow(dialect.Rebind(`SELECT COALESCE(MAX("seq"), 0) FROM "canvas_event"`)).Scan(&b.floor)
+	if err != nil {
+		return nil, err
+	}
+
+	b.wg.Add(1)
+	go b.poll()
+
+	return b, nil
+}
+
+func (b *Broadcaster) Observe(ctx context.Context, id string) (ascanvas.StopObserveFunc, <-chan ascanvas.CanvasEvent, error) {
+	return b.local.Observe(ctx, id)
+}
+
+func (b *Broadcaster) Broadcast(ctx context.Context, event ascanvas.CanvasEvent) error {
+	var payload, err = json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.DB.ExecContext(
+		ctx,
+		b.Dialect.Rebind(`INSERT INTO "canvas_event" ("canvas_id", "payload", "created_at") VALUES (?,?,?)`),
+		event.Canvas.Id,
+		string(payload),
+		time.Now().UnixNano(),
+	)
+
+	return err
+}
+
+// Close stops polling and closes all observer channels
+func (b *Broadcaster) Close() error {
+	close(b.done)
+	b.wg.Wait()
+
+	return b.local.Close()
+}
+
+func (b *Broadcaster) poll() {
+	defer b.wg.Done()
+
+	var ticker = time.NewTicker(b.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			_ = b.relay()
+			_ = b.prune()
+		}
+	}
+}
+
+// relay new events from the database to local observers
+func (b *Broadcaster) relay() error {
+	var events, err = b.fetch()
+
+	for i := range events {
+		_ = b.local.Broadcast(context.Background(), events[i])
+	}
+
+	return err
+}
+
+// fetch events which have not yet been relayed, in the order of their seq
+func (b *Broadcaster) fetch() ([]ascanvas.CanvasEvent, error) {
+	var (
+		events []ascanvas.CanvasEvent
+		now    = time.Now()
+		next   = b.floor + 1
+
+		rows, err = b.DB.Query(
+			b.Dialect.Rebind(`SELECT "seq", "payload" FROM "canvas_event" WHERE "seq" > ? ORDER BY "seq"`),
+			b.floor,
+		)
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer internal.Closed(rows)
+	defer b.advance(now)
+
+	for rows.Next() {
+		var (
+			seq     int64
+			payload string
+			event   ascanvas.CanvasEvent
+		)
+
+		if err = rows.Scan(&seq, &payload); err != nil {
+			return events, err
+		}
+
+		for ; next < seq; next++ {
+			if _, ok := b.missing[next]; !ok && !b.seen[next] {
+				b.missing[next] = now
+			}
+		}
+
+		next = seq + 1
+		delete(b.missing, seq)
+
+		if b.seen[seq] {
+			continue
+		}
+
+		b.seen[seq] = true
+
+		if err = json.Unmarshal([]byte(payload), &event); err == nil {
+			events = append(events, event)
+		}
+	}
+
+	return events, rows.Err()
+}
+
+// advance the floor past seqs relayed, and those missing for longer than Grace
+func (b *Broadcaster) advance(now time.Time) {
+	for {
+		var next = b.floor + 1
+
+		if b.seen[next] {
+			delete(b.seen, next)
+		} else if at, ok := b.missing[next]; ok && now.Sub(at) >= b.Grace {
+			delete(b.missing, next)
+		} else {
+			return
+		}
+
+		b.floor = next
+	}
+}
+
+// prune events older than Retention
+func (b *Broadcaster) prune() error {
+	if b.Retention <= 0 || time.Since(b.pruned) < b.Retention {
+		return nil
+	}
+
+	b.pruned = time.Now()
+
+	var _, err = b.DB.Exec(
+		b.Dialect.Rebind(`DELETE FROM "canvas_event" WHERE "created_at" < ?`),
+		b.pruned.Add(-b.Retention).UnixNano(),
+	)
+
+	return err
+}
@@ -0,0 +1,206 @@
+package sequel_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+	_ "modernc.org/sqlite"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/broadcaster/sequel"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/internal"
+	rs "github.com/fluxynet/ascanvas/repo/sequel"
+)
+
+func makeDb(t *testing.T, filename string) *sql.DB {
+	var db, err = sql.Open("sqlite", "file:"+filename+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		t.Fatalf("failed to open database connection: %s", err)
+	} else if err = db.Ping(); err != nil {
+		t.Fatalf("failed to ping database: %s", err)
+	} else if _, err = rs.NewMigrator(db, "sqlite").Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate schema: %s", err)
+	}
+
+	return db
+}
+
+type instance struct {
+	DB          *sql.DB
+	Broadcaster *sequel.Broadcaster
+	Service     *ascanvas.CanvasService
+}
+
+func makeInstance(t *testing.T, filename string, id string) instance {
+	var (
+		db     = makeDb(t, filename)
+		b, err = sequel.New(db, rs.DialectSQLite, 10*time.Millisecond)
+	)
+
+	if err != nil {
+		t.Fatalf("New() error = %s", err)
+	}
+
+	return instance{
+		DB:          db,
+		Broadcaster: b,
+		Service: &ascanvas.CanvasService{
+			Repo:        &rs.Repository{DB: db},
+			BroadCaster: b,
+			Logger:      zaptest.NewLogger(t),
+			GenerateID:  ascanvas.StaticUUIDGenerator(id, nil),
+			Broadcast:   ascanvas.SyncBroadcast,
+		},
+	}
+}
+
+func (i instance) Close() {
+	internal.Closed(i.Broadcaster)
+	internal.Closed(i.DB)
+}
+
+func receive(t *testing.T, events <-chan ascanvas.CanvasEvent) ascanvas.CanvasEvent {
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no event received")
+	}
+
+	return ascanvas.CanvasEvent{}
+}
+
+func TestBroadcaster_MultipleInstances(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		filename = filepath.Join(t.TempDir(), "ascanvas.db")
+
+		a = makeInstance(t, filename, "1")
+		b = makeInstance(t, filename, "2")
+	)
+
+	defer a.Close()
+	defer b.Close()
+
+	stopA, eventsA, err := a.Service.Observe(ctx, ascanvas.ObserveALL)
+	if err != nil {
+		t.Fatalf("Observe() error = %s", err)
+	}
+	defer stopA()
+
+	stopB, eventsB, err := b.Service.Observe(ctx, "1")
+	if err != nil {
+		t.Fatalf("Observe() error = %s", err)
+	}
+	defer stopB()
+
+	created, err := a.Service.Create(ctx, ascanvas.CreateArgs{Name: "Foo", Fill: ".", Width: 2, Height: 2})
+	if err != nil {
+		t.Fatalf("Create() error = %s", err)
+	}
+
+	for name, events := range map[string]<-chan ascanvas.CanvasEvent{"a": eventsA, "b": eventsB} {
+		got := receive(t, events)
+		want := ascanvas.CanvasEvent{Name: ascanvas.CanvasEventCreated, Canvas: *created}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("instance %s: got = %v, want %v", name, got, want)
+		}
+	}
+
+	updated, err := b.Service.ApplyFloodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Fill: "x"})
+	if err != nil {
+		t.Fatalf("ApplyFloodfill() error = %s", err)
+	}
+
+	for name, events := range map[string]<-chan ascanvas.CanvasEvent{"a": eventsA, "b": eventsB} {
+		got := receive(t, events)
+		want := ascanvas.CanvasEvent{Name: ascanvas.CanvasEventUpdated, Canvas: *updated}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("instance %s: got = %v, want %v", name, got, want)
+		}
+	}
+
+	if _, err = b.Service.Create(ctx, ascanvas.CreateArgs{Name: "Bar", Width: 1, Height: 1}); err != nil {
+		t.Fatalf("Create() error = %s", err)
+	}
+
+	if got := receive(t, eventsA); got.Canvas.Id != "2" {
+		t.Errorf("instance a: got = %v, want canvas 2", got)
+	}
+
+	select {
+	case e := <-eventsB:
+		t.Errorf("instance b: unexpected event for another canvas: %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBroadcaster_LateCommits(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		db     = makeDb(t, filepath.Join(t.TempDir(), "ascanvas.db"))
+		b, err = sequel.New(db, rs.DialectSQLite, 10*time.Millisecond)
+	)
+
+	if err != nil {
+		t.Fatalf("New() error = %s", err)
+	}
+
+	defer internal.Closed(db)
+	defer internal.Closed(b)
+
+	stop, events, err := b.Observe(ctx, ascanvas.ObserveALL)
+	if err != nil {
+		t.Fatalf("Observe() error = %s", err)
+	}
+
+	defer stop()
+
+	// as committed by concurrent writers: seq 2 first, then seq 1 allocated earlier
+	for _, seq := range []int{2, 1} {
+		var _, err = db.Exec(
+			`INSERT INTO "canvas_event" ("seq", "canvas_id", "payload", "created_at") VALUES (?,?,?,?)`,
+			seq,
+			strconv.Itoa(seq),
+			`{"name":"UPDATED","canvas":{"id":"`+strconv.Itoa(seq)+`"}}`,
+			time.Now().UnixNano(),
+		)
+
+		if err != nil {
+			t.Fatalf("failed to insert event %d: %s", seq, err)
+		}
+
+		if got := receive(t, events); got.Canvas.Id != strconv.Itoa(seq) {
+			t.Errorf("got = %v, want canvas %d", got, seq)
+		}
+	}
+
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event relayed again: %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBroadcaster_Conformance(t *testing.T) {
+	conformance.TestBroadcaster(t, func(t *testing.T) conformance.Broadcaster {
+		var db = makeDb(t, filepath.Join(t.TempDir(), "ascanvas.db"))
+		t.Cleanup(func() { internal.Closed(db) })
+
+		b, err := sequel.New(db, rs.DialectSQLite, 10*time.Millisecond)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		return b
+	})
+}
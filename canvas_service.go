@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -30,6 +31,34 @@ func StaticUUIDGenerator(id string, err error) UUIDGeneratorFunc {
 	}
 }
 
+// ClockFunc denotes functions that can tell the current time
+type ClockFunc func() time.Time
+
+// SystemClock is the time of the system
+func SystemClock() time.Time {
+	return time.Now()
+}
+
+// StaticClock always tells the same time; useful for testing
+func StaticClock(t time.Time) ClockFunc {
+	return func() time.Time {
+		return t
+	}
+}
+
+type authorKey struct{}
+
+// WithAuthor returns a context carrying the author of changes made with it
+func WithAuthor(ctx context.Context, author string) context.Context {
+	return context.WithValue(ctx, authorKey{}, author)
+}
+
+// Author of changes, as set by WithAuthor
+func Author(ctx context.Context) string {
+	var author, _ = ctx.Value(authorKey{}).(string)
+	return author
+}
+
 // BroadcastFunc denotes functions used for wrapping broadcasting features
 type BroadcastFunc func(ctx context.Context, b CanvasBroadcaster, l *zap.Logger, event CanvasEvent)
 
@@ -55,6 +84,32 @@ type CanvasService struct {
 	Logger      *zap.Logger
 	GenerateID  UUIDGeneratorFunc
 	Broadcast   BroadcastFunc
+
+	// Clock timestamps changes; SystemClock when nil
+	Clock ClockFunc
+
+	// Locker is optional; when set, transformations of regions locked by someone else are rejected
+	Locker CanvasLocker
+
+	// Presences is optional; when set, sessions joined are tracked and can publish their cursor via MoveCursor
+	Presences *PresenceTracker
+
+	// Registers is optional; when set, conflict-free merging of cell writes is available via Sync
+	Registers CellRegisterRepository
+
+	// Trash is optional; when set, deleted canvases go to the trash until restored or purged
+	Trash CanvasTrash
+
+	// Frames is optional; when set, canvases can be animated with more frames
+	Frames CanvasFrameRepository
+
+	// Transactor is optional; when set, changes spanning Repo and Trash are made in one transaction,
+	// otherwise what was done is undone when a later step fails
+	Transactor Transactor
+
+	// History is optional; when set, a revision is recorded on every change of content or frames of a canvas,
+	// kept when it is deleted until it is purged, for time-lapses to be replayed
+	History CanvasHistory
 }
 
 type CreateArgs struct {
@@ -102,11 +157,6 @@ func (a CreateArgs) Validate() error {
 
 // Create a new Canvas
 func (s CanvasService) Create(ctx context.Context, args CreateArgs) (*Canvas, error) {
-	var (
-		id  string
-		err error
-	)
-
 	if args.Fill == "" {
 		args.Fill = " "
 	}
@@ -118,19 +168,26 @@ func (s CanvasService) Create(ctx context.Context, args CreateArgs) (*Canvas, er
 		return nil, err
 	}
 
-	id, err = s.GenerateID()
-	if err != nil {
-		return nil, err
-	}
-
-	var canvas = Canvas{
-		Id:      id,
+	return s.create(ctx, Canvas{
 		Name:    args.Name,
 		Content: strings.Repeat(args.Fill, args.Width*args.Height),
 		Width:   args.Width,
 		Height:  args.Height,
+	})
+}
+
+// create a canvas with a new id, as made by the author of ctx, and broadcast it
+func (s CanvasService) create(ctx context.Context, canvas Canvas) (*Canvas, error) {
+	var id, err = s.GenerateID()
+	if err != nil {
+		return nil, err
 	}
 
+	canvas.Id = id
+	s.touch(ctx, &canvas)
+	canvas.CreatedAt = canvas.UpdatedAt
+	canvas.CreatedBy = canvas.UpdatedBy
+
 	s.Logger.Debug("Create::BeforeCreate", canvas.AsLogFields()...)
 
 	err = s.Repo.Create(ctx, canvas)
@@ -139,6 +196,7 @@ func (s CanvasService) Create(ctx context.Context, args CreateArgs) (*Canvas, er
 	}
 
 	s.Logger.Debug("Created::Created", canvas.AsLogFields()...)
+	s.record(ctx, RevisionCreated, 0, canvas)
 	s.Broadcast(ctx, s.BroadCaster, s.Logger, CanvasEvent{
 		Name:   CanvasEventCreated,
 		Canvas: canvas,
@@ -192,7 +250,14 @@ func (s CanvasService) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	err = s.Repo.Delete(ctx, id)
+	// registers and frames are kept along with trashed canvases, for them to be restored as they were
+	if s.Trash != nil {
+		err = s.trash(ctx, *canvas)
+	} else if err = s.Repo.Delete(ctx, id); err == nil {
+		s.deleteRegisters(ctx, id)
+		s.deleteFrames(ctx, id)
+	}
+
 	if err != nil {
 		s.Logger.Error("Delete::Failed", zap.Error(err))
 		return err
@@ -230,6 +295,10 @@ type TransformRectangleArgs struct {
 	Height  int         `json:"height"`
 	Fill    string      `json:"fill"`
 	Outline string      `json:"outline"`
+
+	// Frame of an animated canvas to transform, the first one being the canvas itself;
+	// others cannot be transformed when there are Registers, as frames are not merged
+	Frame int `json:"frame,omitempty"`
 }
 
 func (a TransformRectangleArgs) Validate() error {
@@ -267,6 +336,10 @@ func (a TransformRectangleArgs) Validate() error {
 		errs = append(errs, "Outline must be not be longer than 1 character")
 	}
 
+	if a.Frame < 0 {
+		errs = append(errs, "Frame must not be negative")
+	}
+
 	if len(errs) == 0 {
 		return nil
 	}
@@ -276,6 +349,12 @@ func (a TransformRectangleArgs) Validate() error {
 
 // ApplyRectangle loads a Canvas and uses TransformRectangle on it
 func (s CanvasService) ApplyRectangle(ctx context.Context, id string, args TransformRectangleArgs) (*Canvas, error) {
+	if args.Frame > 0 {
+		return s.applyToFrame(ctx, "ApplyRectangle", RevisionRectangle, id, args.Frame, func(canvas *Canvas) (touchedArea, error) {
+			return rectangleArea(args), TransformRectangle(canvas, args)
+		})
+	}
+
 	s.Logger.Debug("ApplyRectangle::Fetching")
 
 	var canvas, err = s.Repo.Get(ctx, id)
@@ -290,6 +369,7 @@ func (s CanvasService) ApplyRectangle(ctx context.Context, id string, args Trans
 	}
 
 	s.Logger.Debug("ApplyRectangle::Transform")
+	var original = *canvas
 	err = TransformRectangle(canvas, args)
 
 	if err == nil {
@@ -299,8 +379,13 @@ func (s CanvasService) ApplyRectangle(ctx context.Context, id string, args Trans
 		return nil, err
 	}
 
+	if err = s.checkLocks(ctx, id, rectangleArea(args)); err != nil {
+		s.Logger.Debug("ApplyRectangle::Locked", zap.Error(err))
+		return nil, err
+	}
+
 	s.Logger.Debug("ApplyRectangle::Updating")
-	err = s.Repo.Update(ctx, *canvas)
+	err = s.save(ctx, original, canvas)
 
 	if err != nil {
 		s.Logger.Error("ApplyRectangle::Update::Failed", zap.Error(err))
@@ -308,6 +393,7 @@ func (s CanvasService) ApplyRectangle(ctx context.Context, id string, args Trans
 	}
 
 	s.Logger.Debug("ApplyRectangle::Updated", zap.String("id", canvas.Id))
+	s.record(ctx, RevisionRectangle, 0, *canvas)
 	s.Broadcast(ctx, s.BroadCaster, s.Logger, CanvasEvent{
 		Name:   CanvasEventUpdated,
 		Canvas: *canvas,
@@ -319,6 +405,10 @@ func (s CanvasService) ApplyRectangle(ctx context.Context, id string, args Trans
 type TransformFloodfillArgs struct {
 	Start Coordinates `json:"start"`
 	Fill  string      `json:"fill"`
+
+	// Frame of an animated canvas to transform, the first one being the canvas itself;
+	// others cannot be transformed when there are Registers, as frames are not merged
+	Frame int `json:"frame,omitempty"`
 }
 
 func (a TransformFloodfillArgs) Validate() error {
@@ -336,6 +426,10 @@ func (a TransformFloodfillArgs) Validate() error {
 		errs = append(errs, "Fill must contain exactly 1 character")
 	}
 
+	if a.Frame < 0 {
+		errs = append(errs, "Frame must not be negative")
+	}
+
 	if len(errs) == 0 {
 		return nil
 	}
@@ -344,6 +438,13 @@ func (a TransformFloodfillArgs) Validate() error {
 }
 
 func (s CanvasService) ApplyFloodfill(ctx context.Context, id string, args TransformFloodfillArgs) (*Canvas, error) {
+	if args.Frame > 0 {
+		return s.applyToFrame(ctx, "ApplyFloodfill", RevisionFloodfill, id, args.Frame, func(canvas *Canvas) (touchedArea, error) {
+			var reached = cellsArea(floodfillReach(*canvas, args.Start))
+			return reached, TransformFloodfill(canvas, args)
+		})
+	}
+
 	s.Logger.Debug("ApplyFloodfill::Fetching")
 
 	var canvas, err = s.Repo.Get(ctx, id)
@@ -355,6 +456,7 @@ func (s CanvasService) ApplyFloodfill(ctx context.Context, id string, args Trans
 	}
 
 	s.Logger.Debug("ApplyFloodfill::Transform")
+	var original = *canvas
 	err = TransformFloodfill(canvas, args)
 
 	if err == nil {
@@ -366,8 +468,13 @@ func (s CanvasService) ApplyFloodfill(ctx context.Context, id string, args Trans
 		return nil, err
 	}
 
+	if err = s.checkLocks(ctx, id, cellsArea(floodfillReach(original, args.Start))); err != nil {
+		s.Logger.Debug("ApplyFloodfill::Locked", zap.Error(err))
+		return nil, err
+	}
+
 	s.Logger.Debug("ApplyFloodfill::Updating")
-	err = s.Repo.Update(ctx, *canvas)
+	err = s.save(ctx, original, canvas)
 
 	if err != nil {
 		s.Logger.Error("ApplyFloodfill::Update::Failed", zap.Error(err))
@@ -375,6 +482,7 @@ func (s CanvasService) ApplyFloodfill(ctx context.Context, id string, args Trans
 	}
 
 	s.Logger.Debug("ApplyFloodfill::Updated", zap.String("id", canvas.Id))
+	s.record(ctx, RevisionFloodfill, 0, *canvas)
 	s.Broadcast(ctx, s.BroadCaster, s.Logger, CanvasEvent{
 		Name:   CanvasEventUpdated,
 		Canvas: *canvas,
@@ -382,3 +490,29 @@ func (s CanvasService) ApplyFloodfill(ctx context.Context, id string, args Trans
 
 	return canvas, err
 }
+
+// now as per the Clock, in UTC without monotonic reading so that times compare equal once stored
+func (s CanvasService) now() time.Time {
+	if s.Clock == nil {
+		return SystemClock().UTC()
+	}
+
+	return s.Clock().UTC()
+}
+
+// touch marks a canvas as updated now, by the author of the context
+func (s CanvasService) touch(ctx context.Context, canvas *Canvas) {
+	canvas.UpdatedAt = s.now()
+	canvas.UpdatedBy = Author(ctx)
+}
+
+// deleteRegisters of a canvas deleted for good, if any
+func (s CanvasService) deleteRegisters(ctx context.Context, id string) {
+	if s.Registers == nil {
+		return
+	}
+
+	if err := s.Registers.Delete(ctx, id); err != nil {
+		s.Logger.Error("Registers::Delete::Failed", zap.String("id", id), zap.Error(err))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/zap/zaptest"
 
@@ -14,10 +15,14 @@ import (
 	mr "github.com/fluxynet/ascanvas/repo/mocks"
 )
 
+// now is the time of the clock of services under test
+var now = time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+
 func TestCanvasService_Create(t *testing.T) {
 	tests := []struct {
 		name     string
 		mustCall bool
+		author   string
 		args     ascanvas.CreateArgs
 		want     *ascanvas.Canvas
 		wantErr  bool
@@ -44,11 +49,13 @@ func TestCanvasService_Create(t *testing.T) {
 				Height: 1,
 			},
 			want: &ascanvas.Canvas{
-				Id:      "1",
-				Name:    "Foo",
-				Content: "  ",
-				Width:   2,
-				Height:  1,
+				Id:        "1",
+				Name:      "Foo",
+				Content:   "  ",
+				Width:     2,
+				Height:    1,
+				CreatedAt: now,
+				UpdatedAt: now,
 			},
 		},
 		{
@@ -78,6 +85,7 @@ func TestCanvasService_Create(t *testing.T) {
 		{
 			name:     "normal create",
 			mustCall: true,
+			author:   "alice",
 			args: ascanvas.CreateArgs{
 				Name:   "Test",
 				Fill:   ".",
@@ -85,18 +93,22 @@ func TestCanvasService_Create(t *testing.T) {
 				Height: 15,
 			},
 			want: &ascanvas.Canvas{
-				Id:      "1",
-				Name:    "Test",
-				Content: strings.Repeat(".", 150),
-				Width:   10,
-				Height:  15,
+				Id:        "1",
+				Name:      "Test",
+				Content:   strings.Repeat(".", 150),
+				Width:     10,
+				Height:    15,
+				CreatedAt: now,
+				UpdatedAt: now,
+				CreatedBy: "alice",
+				UpdatedBy: "alice",
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.Background()
+			ctx := ascanvas.WithAuthor(context.Background(), tt.author)
 			repo := &mr.CanvasRepository{}
 			brd := &mb.CanvasBroadcaster{}
 			s := ascanvas.CanvasService{
@@ -105,6 +117,7 @@ func TestCanvasService_Create(t *testing.T) {
 				Logger:      zaptest.NewLogger(t),
 				GenerateID:  ascanvas.StaticUUIDGenerator("1", nil),
 				Broadcast:   ascanvas.SyncBroadcast,
+				Clock:       ascanvas.StaticClock(now),
 			}
 
 			var event ascanvas.CanvasEvent
@@ -635,11 +648,16 @@ func TestCanvasService_ApplyFloodfill(t *testing.T) {
 				BroadCaster: brd,
 				Logger:      zaptest.NewLogger(t),
 				Broadcast:   ascanvas.SyncBroadcast,
+				Clock:       ascanvas.StaticClock(now),
 			}
 
+			// updates are timestamped by the clock
+			tt.repoUpdate.Canvas.UpdatedAt = now
+
 			var event ascanvas.CanvasEvent
 
 			if tt.want != nil {
+				tt.want.UpdatedAt = now
 				event = ascanvas.CanvasEvent{
 					Name:   ascanvas.CanvasEventUpdated,
 					Canvas: *tt.want,
@@ -802,11 +820,16 @@ func TestCanvasService_ApplyRectangle(t *testing.T) {
 				BroadCaster: brd,
 				Logger:      zaptest.NewLogger(t),
 				Broadcast:   ascanvas.SyncBroadcast,
+				Clock:       ascanvas.StaticClock(now),
 			}
 
+			// updates are timestamped by the clock
+			tt.repoUpdate.Canvas.UpdatedAt = now
+
 			var event ascanvas.CanvasEvent
 
 			if tt.want != nil {
+				tt.want.UpdatedAt = now
 				event = ascanvas.CanvasEvent{
 					Name:   ascanvas.CanvasEventUpdated,
 					Canvas: *tt.want,
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+)
+
+func Backup(_ *cobra.Command, args []string) {
+	var (
+		config        = loadConfig()
+		service, stop = offlineService(config)
+		b             bytes.Buffer
+	)
+
+	var manifest, err = service.Backup(context.Background(), &b, appCommit)
+	stop()
+
+	if err != nil {
+		log.Fatalln("failed to backup canvases: ", err.Error())
+	}
+
+	var path = manifest.Filename()
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	if path == "-" {
+		_, err = os.Stdout.Write(b.Bytes())
+	} else if err = os.WriteFile(path, b.Bytes(), 0644); err == nil {
+		log.Printf("backed up %d canvases to %s\n", len(manifest.Canvases), path)
+	}
+
+	if err != nil {
+		log.Fatalln("failed to write backup: ", err.Error())
+	}
+}
+
+func Restore(c *cobra.Command, args []string) {
+	var (
+		onCollision, _ = c.Flags().GetString("on-collision")
+		rargs          = ascanvas.RestoreArgs{OnCollision: ascanvas.RestoreCollision(onCollision)}
+	)
+
+	if err := rargs.Validate(); err != nil {
+		log.Fatalln(err)
+	}
+
+	var f, err = os.Open(args[0])
+	if err != nil {
+		log.Fatalln("failed to open backup: ", err.Error())
+	}
+
+	defer internal.Closed(f)
+
+	var info os.FileInfo
+	if info, err = f.Stat(); err != nil {
+		log.Fatalln("failed to open backup: ", err.Error())
+	}
+
+	var (
+		config        = loadConfig()
+		service, stop = offlineService(config)
+	)
+
+	report, err := service.RestoreBackup(context.Background(), f, info.Size(), rargs)
+	stop()
+
+	if report != nil {
+		printRestoreReport(os.Stdout, report)
+	}
+
+	if err != nil {
+		log.Fatalln("failed to restore backup: ", err.Error())
+	}
+}
+
+// printRestoreReport as one line per canvas restored with another id, then totals
+func printRestoreReport(w io.Writer, report *ascanvas.RestoreReport) {
+	var renamed = make([]string, 0, len(report.Renamed))
+	for id := range report.Renamed {
+		renamed = append(renamed, id)
+	}
+
+	sort.Strings(renamed)
+
+	for _, id := range renamed {
+		fmt.Fprintf(w, "renamed %s to %s\n", id, report.Renamed[id])
+	}
+
+	fmt.Fprintf(
+		w,
+		"created %d, unchanged %d, skipped %d, overwritten %d, renamed %d\n",
+		len(report.Created),
+		len(report.Unchanged),
+		len(report.Skipped),
+		len(report.Overwritten),
+		len(report.Renamed),
+	)
+}
+
+// offlineService for canvases as configured for the server, without it running; stop releases its resources
+func offlineService(config Config) (*ascanvas.CanvasService, func()) {
+	// canvases kept in memory are neither those of the server nor kept after the command
+	if config.Repository == RepositoryMemory && config.Snapshot == "" {
+		log.Fatalln("canvases in memory can only be backed up or restored with a repository_snapshot")
+	}
+
+	var db *sql.DB
+
+	if needsDB(config) {
+		db = openDB(config)
+
+		if config.AutoMigrate {
+			migrateUp(db, config)
+		}
+	}
+
+	var repo, err = makeRepository(config, db)
+	if err != nil {
+		log.Fatalln("failed to start repository: ", err.Error())
+	}
+
+	broadcaster, err := makeBroadcaster(config, db)
+	if err != nil {
+		log.Fatalln("failed to start broadcaster: ", err.Error())
+	}
+
+	frames, err := makeFrames(config, db, repo)
+	if err != nil {
+		log.Fatalln("failed to start frames: ", err.Error())
+	}
+
+	var history ascanvas.CanvasHistory
+	if config.History {
+		if history, err = makeHistory(config, db, repo); err != nil {
+			log.Fatalln("failed to start history: ", err.Error())
+		}
+	}
+
+	var service = &ascanvas.CanvasService{
+		Repo:        repo,
+		BroadCaster: broadcaster,
+		Logger:      zap.NewNop(),
+		GenerateID:  ascanvas.UUIDGenerator,
+		Clock:       ascanvas.SystemClock,
+		Broadcast:   ascanvas.SyncBroadcast,
+		Frames:      frames,
+		Transactor:  makeTransactor(db, repo),
+		History:     history,
+	}
+
+	return service, func() {
+		for _, r := range []interface{}{history, frames, repo, broadcaster} {
+			if c, ok := r.(io.Closer); ok {
+				internal.Closed(c)
+			}
+		}
+
+		if db != nil {
+			internal.Closed(db)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web/client"
+)
+
+const (
+	// OutputText prints results for people: tables, or canvases drawn
+	OutputText = "text"
+
+	// OutputJSON prints results as replied by the server
+	OutputJSON = "json"
+)
+
+// withClientFlags common to all commands talking to a server
+func withClientFlags(c *cobra.Command) *cobra.Command {
+	c.Flags().String("server", client.DefaultServer, "Base url of the server")
+	c.Flags().String("author", "", "Author of changes")
+
+	return c
+}
+
+// withOutputFlag for commands printing results
+func withOutputFlag(c *cobra.Command) *cobra.Command {
+	c.Flags().StringP("output", "o", OutputText, "Output format: text or json")
+
+	return c
+}
+
+// newClient as per flags, exiting when they are not valid
+func newClient(c *cobra.Command) *client.Client {
+	var (
+		server, _ = c.Flags().GetString("server")
+		author, _ = c.Flags().GetString("author")
+		api       = client.New(server)
+	)
+
+	if output, err := c.Flags().GetString("output"); err == nil && output != OutputText && output != OutputJSON {
+		log.Fatalln("output must be text or json")
+	}
+
+	api.Author = author
+
+	return api
+}
+
+// printResult as json, or with text
+func printResult(c *cobra.Command, result interface{}, text func(w io.Writer) error) {
+	var (
+		output, _ = c.Flags().GetString("output")
+		err       error
+	)
+
+	if output == OutputJSON {
+		var enc = json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(result)
+	} else {
+		err = text(os.Stdout)
+	}
+
+	if err != nil {
+		log.Fatalln("failed to print result: ", err.Error())
+	}
+}
+
+// printCanvas as json, or drawn as text
+func printCanvas(c *cobra.Command, canvas *ascanvas.Canvas) {
+	printResult(c, canvas, func(w io.Writer) error {
+		return canvas.WriteText(w, ascanvas.TextArgs{Format: ascanvas.TextPlain})
+	})
+}
+
+// printID of a canvas as json, or alone as text for scripts to use
+func printID(c *cobra.Command, canvas *ascanvas.Canvas) {
+	printResult(c, canvas, func(w io.Writer) error {
+		var _, err = fmt.Fprintln(w, canvas.Id)
+		return err
+	})
+}
+
+func List(c *cobra.Command, _ []string) {
+	var (
+		api     = newClient(c)
+		name, _ = c.Flags().GetString("name")
+	)
+
+	var summaries, err = api.List(context.Background(), name)
+	if err != nil {
+		log.Fatalln("failed to list canvases: ", err.Error())
+	}
+
+	printResult(c, summaries, func(w io.Writer) error {
+		var tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tWIDTH\tHEIGHT")
+
+		for _, s := range summaries {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", s.Id, s.Name, s.Width, s.Height)
+		}
+
+		return tw.Flush()
+	})
+}
+
+func Get(c *cobra.Command, args []string) {
+	var (
+		api       = newClient(c)
+		format, _ = c.Flags().GetString("format")
+		targs     = ascanvas.TextArgs{Format: ascanvas.TextFormat(format)}
+	)
+
+	if err := targs.WithDefaults().Validate(); err != nil {
+		log.Fatalln(err)
+	}
+
+	var canvas, err = api.Get(context.Background(), args[0])
+	if err != nil {
+		log.Fatalln("failed to get canvas: ", err.Error())
+	}
+
+	printResult(c, canvas, func(w io.Writer) error {
+		return canvas.WriteText(w, targs)
+	})
+}
+
+func Create(c *cobra.Command, args []string) {
+	var (
+		api   = newClient(c)
+		flags = c.Flags()
+		cargs = ascanvas.CreateArgs{Name: args[0]}
+	)
+
+	cargs.Width, _ = flags.GetInt("width")
+	cargs.Height, _ = flags.GetInt("height")
+	cargs.Fill, _ = flags.GetString("fill")
+
+	var canvas, err = api.Create(context.Background(), cargs)
+	if err != nil {
+		log.Fatalln("failed to create canvas: ", err.Error())
+	}
+
+	printID(c, canvas)
+}
+
+func Delete(c *cobra.Command, args []string) {
+	if err := newClient(c).Delete(context.Background(), args[0]); err != nil {
+		log.Fatalln("failed to delete canvas: ", err.Error())
+	}
+}
+
+func Rect(c *cobra.Command, args []string) {
+	var (
+		api   = newClient(c)
+		flags = c.Flags()
+		rargs ascanvas.TransformRectangleArgs
+	)
+
+	rargs.TopLeft.X, _ = flags.GetInt("x")
+	rargs.TopLeft.Y, _ = flags.GetInt("y")
+	rargs.Width, _ = flags.GetInt("width")
+	rargs.Height, _ = flags.GetInt("height")
+	rargs.Fill, _ = flags.GetString("fill")
+	rargs.Outline, _ = flags.GetString("outline")
+	rargs.Frame, _ = flags.GetInt("frame")
+
+	var canvas, err = api.Rectangle(context.Background(), args[0], rargs)
+	if err != nil {
+		log.Fatalln("failed to draw rectangle: ", err.Error())
+	}
+
+	printCanvas(c, canvas)
+}
+
+func Fill(c *cobra.Command, args []string) {
+	var (
+		api   = newClient(c)
+		flags = c.Flags()
+		fargs ascanvas.TransformFloodfillArgs
+	)
+
+	fargs.Start.X, _ = flags.GetInt("x")
+	fargs.Start.Y, _ = flags.GetInt("y")
+	fargs.Fill, _ = flags.GetString("fill")
+	fargs.Frame, _ = flags.GetInt("frame")
+
+	var canvas, err = api.Floodfill(context.Background(), args[0], fargs)
+	if err != nil {
+		log.Fatalln("failed to floodfill: ", err.Error())
+	}
+
+	printCanvas(c, canvas)
+}
+
+func Import(c *cobra.Command, args []string) {
+	var (
+		api   = newClient(c)
+		flags = c.Flags()
+		iargs ascanvas.ImportArgs
+		text  []byte
+		err   error
+	)
+
+	iargs.Name, _ = flags.GetString("name")
+	iargs.Fill, _ = flags.GetString("fill")
+
+	if args[0] == "-" {
+		text, err = io.ReadAll(os.Stdin)
+	} else {
+		text, err = os.ReadFile(args[0])
+
+		if iargs.Name == "" {
+			iargs.Name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+		}
+	}
+
+	if err != nil {
+		log.Fatalln("failed to read text: ", err.Error())
+	}
+
+	iargs.Text = string(text)
+
+	canvas, err := api.Import(context.Background(), iargs)
+	if err != nil {
+		log.Fatalln("failed to import canvas: ", err.Error())
+	}
+
+	printID(c, canvas)
+}
+
+func Export(c *cobra.Command, args []string) {
+	var (
+		api   = newClient(c)
+		flags = c.Flags()
+		iargs ascanvas.ImageArgs
+	)
+
+	var format, _ = flags.GetString("format")
+	iargs.Format = ascanvas.ImageFormat(format)
+	iargs.CellWidth, _ = flags.GetInt("cell-width")
+	iargs.CellHeight, _ = flags.GetInt("cell-height")
+	iargs.Padding, _ = flags.GetInt("padding")
+	iargs.Foreground, _ = flags.GetString("fg")
+	iargs.Background, _ = flags.GetString("bg")
+
+	var b, err = api.Export(context.Background(), args[0], iargs)
+	if err != nil {
+		log.Fatalln("failed to export canvas: ", err.Error())
+	}
+
+	var file, _ = flags.GetString("file")
+	if file == "" || file == "-" {
+		_, err = os.Stdout.Write(b)
+	} else {
+		err = os.WriteFile(file, b, 0644)
+	}
+
+	if err != nil {
+		log.Fatalln("failed to write export: ", err.Error())
+	}
+}
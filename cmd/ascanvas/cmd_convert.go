@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+)
+
+func Convert(c *cobra.Command, args []string) {
+	var (
+		flags = c.Flags()
+		cargs ascanvas.ConvertArgs
+		targs ascanvas.TextArgs
+		err   error
+	)
+
+	cargs.Width, _ = flags.GetInt("width")
+	cargs.Aspect, _ = flags.GetFloat64("aspect")
+	cargs.Dither, _ = flags.GetBool("dither")
+	cargs.Ramp, _ = flags.GetString("ramp")
+
+	var format, _ = flags.GetString("format")
+	targs.Format = ascanvas.TextFormat(format)
+
+	if err = targs.WithDefaults().Validate(); err != nil {
+		log.Fatalln(err)
+	}
+
+	var f = os.Stdin
+	if args[0] != "-" {
+		if f, err = os.Open(args[0]); err != nil {
+			log.Fatalln("failed to open image: ", err.Error())
+		}
+
+		defer internal.Closed(f)
+	}
+
+	img, err := ascanvas.DecodeImage(f)
+	if err != nil {
+		log.Fatalln("failed to decode image: ", err.Error())
+	}
+
+	canvas, err := ascanvas.ImageToCanvas(img, cargs)
+	if err != nil {
+		log.Fatalln("failed to convert image: ", err.Error())
+	}
+
+	canvas.Name = args[0]
+
+	if err = canvas.WriteText(os.Stdout, targs); err != nil {
+		log.Fatalln("failed to print canvas: ", err.Error())
+	}
+}
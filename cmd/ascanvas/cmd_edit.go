@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web/client"
+)
+
+// key pressed: a character as is, or one of the keys below
+type key int
+
+const (
+	keyUp key = -(iota + 1)
+	keyDown
+	keyRight
+	keyLeft
+)
+
+const (
+	keyCtrlC     key = 0x03
+	keyCtrlF     key = 0x06
+	keyBackspace key = 0x08
+	keyTab       key = 0x09
+	keyEnter     key = 0x0d
+	keyCtrlQ     key = 0x11
+	keyCtrlZ     key = 0x1a
+	keyEsc       key = 0x1b
+	keyDelete    key = 0x7f
+)
+
+// editHelp is shown below the canvas being edited
+const editHelp = " arrows move  type to draw  Tab select  ^F floodfill  ^Z undo  ^Q quit"
+
+// edit made to a canvas, undone by drawing back cells changed between before and after; before is the canvas on the
+// server right before the edit, except for cells the edit did not target, so that changes by others are never undone
+type edit struct {
+	what   string
+	before ascanvas.Canvas
+	after  ascanvas.Canvas
+}
+
+// canvasAPI of the server as used to edit and follow a canvas, implemented by client.Client
+type canvasAPI interface {
+	Get(ctx context.Context, id string) (*ascanvas.Canvas, error)
+	Rectangle(ctx context.Context, id string, args ascanvas.TransformRectangleArgs) (*ascanvas.Canvas, error)
+	Floodfill(ctx context.Context, id string, args ascanvas.TransformFloodfillArgs) (*ascanvas.Canvas, error)
+	Events(ctx context.Context, id string) (*client.EventStream, error)
+}
+
+// update of the canvas from the stream of events, or only a status when canvas is nil
+type update struct {
+	canvas *ascanvas.Canvas
+	event  string
+	status string
+}
+
+// editor of a canvas of a server, in the terminal
+type editor struct {
+	ctx    context.Context
+	api    canvasAPI
+	canvas *ascanvas.Canvas
+	cursor ascanvas.Coordinates
+
+	// mark is the corner of the selection opposite to the cursor, if selecting
+	mark *ascanvas.Coordinates
+
+	// filling waits for the character to floodfill with at the cursor
+	filling bool
+
+	undos  []edit
+	status string
+}
+
+func Edit(c *cobra.Command, args []string) {
+	var (
+		api      = newClient(c)
+		ctx, end = context.WithCancel(context.Background())
+	)
+
+	defer end()
+
+	var canvas, err = api.Get(ctx, args[0])
+	if err != nil {
+		log.Fatalln("failed to get canvas: ", err.Error())
+	}
+
+	var restore func()
+	if restore, err = rawTerminal(); err != nil {
+		log.Fatalln("failed to set up terminal: ", err.Error())
+	}
+
+	// on panics too; exiting on errors below skips deferred calls, so it is restored before
+	defer restore()
+
+	// signals end editing as ^Q does, keys not raising any in raw mode
+	var signals = make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	go func() {
+		select {
+		case <-signals:
+			end()
+		case <-ctx.Done():
+		}
+	}()
+
+	err = editCanvas(ctx, &editor{ctx: ctx, api: api, canvas: canvas, status: "editing"}, os.Stdin, os.Stdout)
+
+	restore()
+
+	// clear screen, show the cursor
+	fmt.Print("\x1b[H\x1b[2J\x1b[?25h")
+
+	switch {
+	case errors.Is(err, errDeleted):
+		fmt.Printf("canvas %s was deleted\n", canvas.Id)
+	case err != nil:
+		log.Fatalln("failed to edit canvas: ", err.Error())
+	}
+}
+
+// editCanvas with keys pressed on in, drawn on out along with changes by others, until quit or the canvas is deleted
+func editCanvas(ctx context.Context, e *editor, in io.Reader, out io.Writer) error {
+	var (
+		keys    = readKeys(in)
+		updates = make(chan update)
+		done    = make(chan error, 1)
+		send    = func(u update) {
+			select {
+			case updates <- u:
+			case <-ctx.Done():
+			}
+		}
+	)
+
+	go func() {
+		done <- followAgain(ctx, e.api, e.canvas.Id,
+			func(canvas *ascanvas.Canvas, event string) {
+				send(update{canvas: canvas, event: event, status: strings.ToLower(event) + " at " + time.Now().Format("15:04:05")})
+			},
+			func(err error, delay time.Duration) {
+				send(update{status: fmt.Sprintf("disconnected: %s, reconnecting in %s", oneLine(err), delay)})
+			},
+		)
+	}()
+
+	for {
+		e.draw(out)
+
+		select {
+		case k, ok := <-keys:
+			if !ok || e.press(k) {
+				return nil
+			}
+		case u := <-updates:
+			// echoes of own edits leave the status as is
+			if u.canvas == nil || u.event != string(ascanvas.CanvasEventUpdated) || u.canvas.Content != e.canvas.Content {
+				e.status = u.status
+			}
+
+			if u.canvas != nil {
+				e.canvas = u.canvas
+			}
+		case <-ctx.Done():
+			return nil
+		case err := <-done:
+			if err == nil {
+				err = errDeleted
+			}
+
+			return err
+		}
+	}
+}
+
+// press a key, telling if the editor is to quit
+func (e *editor) press(k key) bool {
+	switch k {
+	case keyCtrlC, keyCtrlQ:
+		return true
+	case keyUp:
+		e.move(0, -1)
+	case keyDown:
+		e.move(0, 1)
+	case keyLeft:
+		e.move(-1, 0)
+	case keyRight:
+		e.move(1, 0)
+	case keyEnter:
+		e.cursor.X = 0
+		e.move(0, 1)
+	case keyBackspace, keyDelete:
+		e.move(-1, 0)
+		e.draw1(" ")
+	case keyTab:
+		if e.mark == nil {
+			var mark = e.cursor
+			e.mark, e.filling = &mark, false
+			e.status = "selecting: move to the opposite corner, then type the character to fill it with"
+		} else {
+			e.mark, e.status = nil, ""
+		}
+	case keyCtrlF:
+		e.mark, e.filling = nil, true
+		e.status = "floodfill: type the character to fill with"
+	case keyCtrlZ:
+		e.undo()
+	case keyEsc:
+		e.mark, e.filling, e.status = nil, false, ""
+	default:
+		if k < ' ' || k > '~' {
+			break
+		}
+
+		var char = string(rune(k))
+
+		switch {
+		case e.filling:
+			e.filling = false
+			// cells filled are those of the character now, other cells changed being changes by others
+			var filled = func(_ ascanvas.Coordinates, cell string) bool { return cell == char }
+
+			e.apply("floodfill with "+char, filled, func() (*ascanvas.Canvas, error) {
+				return e.api.Floodfill(e.ctx, e.canvas.Id, ascanvas.TransformFloodfillArgs{Start: e.cursor, Fill: char})
+			})
+		case e.mark != nil:
+			var rect = e.selection()
+			rect.Fill, e.mark = char, nil
+
+			e.apply("fill selection with "+char, within(rect), func() (*ascanvas.Canvas, error) {
+				return e.api.Rectangle(e.ctx, e.canvas.Id, rect)
+			})
+		default:
+			e.draw1(char)
+			e.move(1, 0)
+		}
+	}
+
+	return false
+}
+
+// move the cursor, staying within the canvas
+func (e *editor) move(dx, dy int) {
+	var to = ascanvas.Coordinates{X: e.cursor.X + dx, Y: e.cursor.Y + dy}
+	if e.canvas.Contains(to) {
+		e.cursor = to
+	}
+}
+
+// draw1 character at the cursor
+func (e *editor) draw1(char string) {
+	if p := e.cursor.Y*e.canvas.Width + e.cursor.X; p < len(e.canvas.Content) && e.canvas.Content[p:p+1] == char {
+		return
+	}
+
+	var rect = ascanvas.TransformRectangleArgs{TopLeft: e.cursor, Width: 1, Height: 1, Fill: char}
+
+	e.apply("typed "+char, within(rect), func() (*ascanvas.Canvas, error) {
+		return e.api.Rectangle(e.ctx, e.canvas.Id, rect)
+	})
+}
+
+// selection between the mark and the cursor, as a rectangle without characters
+func (e *editor) selection() ascanvas.TransformRectangleArgs {
+	var (
+		from = *e.mark
+		to   = e.cursor
+	)
+
+	if from.X > to.X {
+		from.X, to.X = to.X, from.X
+	}
+
+	if from.Y > to.Y {
+		from.Y, to.Y = to.Y, from.Y
+	}
+
+	return ascanvas.TransformRectangleArgs{TopLeft: from, Width: to.X - from.X + 1, Height: to.Y - from.Y + 1}
+}
+
+// within the rectangle of args, as targeted by apply
+func within(args ascanvas.TransformRectangleArgs) func(c ascanvas.Coordinates, cell string) bool {
+	return func(c ascanvas.Coordinates, _ string) bool {
+		return c.X >= args.TopLeft.X && c.X < args.TopLeft.X+args.Width &&
+			c.Y >= args.TopLeft.Y && c.Y < args.TopLeft.Y+args.Height
+	}
+}
+
+// apply a change made on the server, keeping it to be undone; the canvas is fetched right before, the local copy
+// possibly missing changes by others, and only cells targeted as per their character after the change are kept
+func (e *editor) apply(what string, targeted func(c ascanvas.Coordinates, cell string) bool, change func() (*ascanvas.Canvas, error)) {
+	var before, err = e.api.Get(e.ctx, e.canvas.Id)
+
+	var after *ascanvas.Canvas
+	if err == nil {
+		after, err = change()
+	}
+
+	if err != nil {
+		e.status = oneLine(err)
+		return
+	}
+
+	// cells not targeted are left as they are after, for them not to be undone
+	if before.Width == after.Width && len(before.Content) == len(after.Content) {
+		var content = []byte(before.Content)
+
+		for p := range content {
+			if !targeted(ascanvas.Coordinates{X: p % after.Width, Y: p / after.Width}, after.Content[p:p+1]) {
+				content[p] = after.Content[p]
+			}
+		}
+
+		before.Content = string(content)
+	}
+
+	e.undos = append(e.undos, edit{what: what, before: *before, after: *after})
+	e.canvas, e.status = after, what
+}
+
+// undo the last edit, drawing back the cells it changed; changes made by others since to other cells are kept.
+// An edit is undone whole or not at all: runs drawn back before one fails are drawn again, see rollback.
+func (e *editor) undo() {
+	if len(e.undos) == 0 {
+		e.status = "nothing to undo"
+		return
+	}
+
+	var (
+		last = e.undos[len(e.undos)-1]
+
+		// state of the cells of the edit on the server, as runs are drawn back
+		state = last.after
+	)
+
+	for _, rect := range ascanvas.Revert(last.before, last.after) {
+		var canvas, err = e.api.Rectangle(e.ctx, e.canvas.Id, rect)
+		if err != nil {
+			e.status = "failed to undo " + last.what + ": " + oneLine(err)
+			e.rollback(state)
+			return
+		}
+
+		_ = ascanvas.TransformRectangle(&state, rect)
+		e.canvas = canvas
+	}
+
+	e.undos = e.undos[:len(e.undos)-1]
+	e.status = "undone " + last.what
+}
+
+// rollback runs of the last edit drawn back by undo before failing, from state to as the edit left them; when this
+// fails too, the edit is kept as partly undone, for the runs left to be drawn back by the next undo
+func (e *editor) rollback(state ascanvas.Canvas) {
+	var last = &e.undos[len(e.undos)-1]
+
+	for _, rect := range ascanvas.Revert(last.after, state) {
+		var canvas, err = e.api.Rectangle(e.ctx, e.canvas.Id, rect)
+		if err != nil {
+			last.after = state
+			e.status += ", partly undone"
+			return
+		}
+
+		_ = ascanvas.TransformRectangle(&state, rect)
+		e.canvas = canvas
+	}
+}
+
+// draw the canvas over the whole terminal, the selection highlighted, with status and help lines at the bottom
+func (e *editor) draw(w io.Writer) {
+	var (
+		b    strings.Builder
+		rect ascanvas.TransformRectangleArgs
+		mode string
+	)
+
+	if e.mark != nil {
+		rect, mode = e.selection(), "SELECT  "
+	} else if e.filling {
+		mode = "FILL  "
+	}
+
+	// cursor home, clear screen
+	b.WriteString("\x1b[H\x1b[2J")
+
+	for y, row := range e.canvas.Rows() {
+		if e.mark != nil && y >= rect.TopLeft.Y && y < rect.TopLeft.Y+rect.Height {
+			var from, to = rect.TopLeft.X, rect.TopLeft.X + rect.Width
+			row = row[:from] + "\x1b[7m" + row[from:to] + "\x1b[0m" + row[to:]
+		}
+
+		b.WriteString(row)
+		b.WriteString("\r\n")
+	}
+
+	fmt.Fprintf(&b, "\x1b[7m %s  %dx%d  %s  %s%s \x1b[0m\r\n", e.canvas.Name, e.canvas.Width, e.canvas.Height, e.cursor, mode, e.status)
+	fmt.Fprintf(&b, "\x1b[2m%s\x1b[0m", editHelp)
+
+	// cursor onto its cell, 1-based
+	fmt.Fprintf(&b, "\x1b[%d;%dH", e.cursor.Y+1, e.cursor.X+1)
+
+	_, _ = io.WriteString(w, b.String())
+}
+
+// readKeys pressed on in until it ends, arrows being sent as escape sequences
+func readKeys(in io.Reader) <-chan key {
+	var keys = make(chan key)
+
+	go func() {
+		defer close(keys)
+
+		var b = make([]byte, 64)
+
+		for {
+			var n, err = in.Read(b)
+
+			for _, k := range parseKeys(b[:n]) {
+				keys <- k
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return keys
+}
+
+// parseKeys read at once; an escape alone is the escape key
+func parseKeys(b []byte) []key {
+	var keys []key
+
+	for i := 0; i < len(b); i++ {
+		if b[i] == byte(keyEsc) && i+2 < len(b) && (b[i+1] == '[' || b[i+1] == 'O') {
+			switch b[i+2] {
+			case 'A':
+				keys = append(keys, keyUp)
+			case 'B':
+				keys = append(keys, keyDown)
+			case 'C':
+				keys = append(keys, keyRight)
+			case 'D':
+				keys = append(keys, keyLeft)
+			}
+
+			i += 2
+			continue
+		}
+
+		keys = append(keys, key(b[i]))
+	}
+
+	return keys
+}
+
+// rawTerminal passes keys pressed on stdin as they are, without echoing them, until restored; restoring it again
+// does nothing
+func rawTerminal() (func(), error) {
+	var fd = int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errors.New("stdin is not a terminal")
+	}
+
+	var saved, err = term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() { _ = term.Restore(fd, saved) })
+	}, nil
+}
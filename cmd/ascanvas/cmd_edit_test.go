@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web/client"
+)
+
+// fakeAPI of a server with a single canvas, drawn on as the server would
+type fakeAPI struct {
+	canvas ascanvas.Canvas
+
+	// fails transforms by their number, counted from 1
+	fails map[int]bool
+	calls int
+}
+
+var errFake = errors.New("fake failure")
+
+func (f *fakeAPI) Get(_ context.Context, _ string) (*ascanvas.Canvas, error) {
+	var canvas = f.canvas
+	return &canvas, nil
+}
+
+func (f *fakeAPI) Rectangle(_ context.Context, _ string, args ascanvas.TransformRectangleArgs) (*ascanvas.Canvas, error) {
+	return f.transform(func(canvas *ascanvas.Canvas) error {
+		return ascanvas.TransformRectangle(canvas, args)
+	})
+}
+
+func (f *fakeAPI) Floodfill(_ context.Context, _ string, args ascanvas.TransformFloodfillArgs) (*ascanvas.Canvas, error) {
+	return f.transform(func(canvas *ascanvas.Canvas) error {
+		return ascanvas.TransformFloodfill(canvas, args)
+	})
+}
+
+func (f *fakeAPI) Events(_ context.Context, _ string) (*client.EventStream, error) {
+	return nil, ascanvas.ErrNotSupported
+}
+
+func (f *fakeAPI) transform(t func(canvas *ascanvas.Canvas) error) (*ascanvas.Canvas, error) {
+	if f.calls++; f.fails[f.calls] {
+		return nil, errFake
+	}
+
+	if err := t(&f.canvas); err != nil {
+		return nil, err
+	}
+
+	var canvas = f.canvas
+	return &canvas, nil
+}
+
+// draw a rectangle of fill on the canvas, as others would
+func draw(x, y, width int, fill string) func(canvas *ascanvas.Canvas) {
+	return func(canvas *ascanvas.Canvas) {
+		_ = ascanvas.TransformRectangle(canvas, ascanvas.TransformRectangleArgs{
+			TopLeft: ascanvas.Coordinates{X: x, Y: y},
+			Width:   width,
+			Height:  1,
+			Fill:    fill,
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		b    string
+		want []key
+	}{
+		{name: "empty", b: "", want: nil},
+		{name: "characters", b: "ab ", want: []key{'a', 'b', ' '}},
+		{name: "controls", b: "\x11\x1a\x06\t\r\x7f", want: []key{keyCtrlQ, keyCtrlZ, keyCtrlF, keyTab, keyEnter, keyDelete}},
+		{name: "arrows", b: "\x1b[A\x1b[B\x1b[C\x1b[D", want: []key{keyUp, keyDown, keyRight, keyLeft}},
+		{name: "arrows of application mode", b: "\x1bOA\x1bOD", want: []key{keyUp, keyLeft}},
+		{name: "arrows between characters", b: "a\x1b[Cb", want: []key{'a', keyRight, 'b'}},
+		{name: "escape alone", b: "\x1b", want: []key{keyEsc}},
+		{name: "escape then characters", b: "\x1bab", want: []key{keyEsc, 'a', 'b'}},
+		{name: "sequence cut short", b: "\x1b[", want: []key{keyEsc, '['}},
+		{name: "other sequence", b: "\x1b[Hx", want: []key{'x'}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKeys([]byte(tt.b)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditor_selection(t *testing.T) {
+	var want = ascanvas.TransformRectangleArgs{TopLeft: ascanvas.Coordinates{X: 1, Y: 2}, Width: 3, Height: 2}
+
+	tests := []struct {
+		name   string
+		mark   ascanvas.Coordinates
+		cursor ascanvas.Coordinates
+		want   ascanvas.TransformRectangleArgs
+	}{
+		{name: "cursor bottom right", mark: ascanvas.Coordinates{X: 1, Y: 2}, cursor: ascanvas.Coordinates{X: 3, Y: 3}, want: want},
+		{name: "cursor top left", mark: ascanvas.Coordinates{X: 3, Y: 3}, cursor: ascanvas.Coordinates{X: 1, Y: 2}, want: want},
+		{name: "cursor top right", mark: ascanvas.Coordinates{X: 1, Y: 3}, cursor: ascanvas.Coordinates{X: 3, Y: 2}, want: want},
+		{name: "cursor bottom left", mark: ascanvas.Coordinates{X: 3, Y: 2}, cursor: ascanvas.Coordinates{X: 1, Y: 3}, want: want},
+		{
+			name:   "single cell",
+			mark:   ascanvas.Coordinates{X: 2, Y: 2},
+			cursor: ascanvas.Coordinates{X: 2, Y: 2},
+			want:   ascanvas.TransformRectangleArgs{TopLeft: ascanvas.Coordinates{X: 2, Y: 2}, Width: 1, Height: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mark = tt.mark
+				e    = editor{mark: &mark, cursor: tt.cursor}
+			)
+
+			if got := e.selection(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditor_undo(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// keys pressed on a canvas of content, others drawing before the edit unbeknownst to the editor, and once it is made
+		content string
+		keys    string
+		others  func(canvas *ascanvas.Canvas)
+		since   func(canvas *ascanvas.Canvas)
+
+		// transforms failing, by their number since the edit
+		fails map[int]bool
+
+		// content after the first undo, and after another one when the first failed
+		want      string
+		wantStack int
+		wantAgain string
+	}{
+		{
+			name:    "typed",
+			content: "abc",
+			keys:    "x",
+			want:    "abc",
+		},
+		{
+			name:    "selection",
+			content: "abc",
+			keys:    "\t\x1b[C\x1b[Cx",
+			want:    "abc",
+		},
+		{
+			name:    "floodfill",
+			content: "aab",
+			keys:    "\x06x",
+			want:    "aab",
+		},
+		{
+			name:    "changes by others since kept",
+			content: "abc",
+			keys:    "x",
+			since:   draw(1, 0, 2, "o"),
+			want:    "aoo",
+		},
+		{
+			name:    "changes by others not seen yet undone as they were on the server",
+			content: "abc",
+			keys:    "\t\x1b[C\x1b[Cx",
+			others:  draw(1, 0, 1, "o"),
+			want:    "aoc",
+		},
+		{
+			name:    "changes by others not seen yet nor filled kept",
+			content: "aab",
+			keys:    "\x06x",
+			others:  draw(2, 0, 1, "o"),
+			want:    "aao",
+		},
+		{
+			name:      "failed",
+			content:   "abc",
+			keys:      "x",
+			fails:     map[int]bool{2: true},
+			want:      "xbc",
+			wantStack: 1,
+			wantAgain: "abc",
+		},
+		{
+			name:      "failed partly, rolled back",
+			content:   "abc",
+			keys:      "\t\x1b[C\x1b[Cx",
+			fails:     map[int]bool{3: true},
+			want:      "xxx",
+			wantStack: 1,
+			wantAgain: "abc",
+		},
+		{
+			name:      "failed partly, rolling back too",
+			content:   "abc",
+			keys:      "\t\x1b[C\x1b[Cx",
+			fails:     map[int]bool{4: true, 5: true},
+			want:      "abx",
+			wantStack: 1,
+			wantAgain: "abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				api = &fakeAPI{
+					canvas: ascanvas.Canvas{Id: "1", Content: tt.content, Width: len(tt.content), Height: 1},
+					fails:  map[int]bool{},
+				}
+				canvas = api.canvas
+				e      = &editor{ctx: context.Background(), api: api, canvas: &canvas}
+			)
+
+			if tt.others != nil {
+				tt.others(&api.canvas)
+			}
+
+			for _, k := range parseKeys([]byte(tt.keys)) {
+				e.press(k)
+			}
+
+			if len(e.undos) != 1 {
+				t.Fatalf("press() kept %d edits, want 1; %s", len(e.undos), e.status)
+			}
+
+			if tt.since != nil {
+				tt.since(&api.canvas)
+			}
+
+			api.fails, api.calls = tt.fails, 1
+
+			e.undo()
+
+			if api.canvas.Content != tt.want {
+				t.Errorf("undo() content = %q, want %q", api.canvas.Content, tt.want)
+			}
+
+			if len(e.undos) != tt.wantStack {
+				t.Fatalf("undo() kept %d edits, want %d; %s", len(e.undos), tt.wantStack, e.status)
+			}
+
+			if tt.wantStack == 0 {
+				return
+			}
+
+			api.fails = nil
+
+			if e.undo(); api.canvas.Content != tt.wantAgain || len(e.undos) != 0 {
+				t.Errorf("undo() again content = %q with %d edits, want %q with none", api.canvas.Content, len(e.undos), tt.wantAgain)
+			}
+		})
+	}
+}
+
+func TestEditor_undoNothing(t *testing.T) {
+	var e = &editor{ctx: context.Background(), api: &fakeAPI{}}
+
+	if e.undo(); e.status != "nothing to undo" {
+		t.Errorf("undo() status = %q, want nothing to undo", e.status)
+	}
+}
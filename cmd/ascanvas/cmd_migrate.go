@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxynet/ascanvas/internal"
+	"github.com/fluxynet/ascanvas/repo/sequel"
+)
+
+func MigrateUp(_ *cobra.Command, _ []string) {
+	var (
+		config = loadConfig()
+		db     = openDB(config)
+	)
+
+	defer internal.Closed(db)
+
+	migrateUp(db, config)
+}
+
+func MigrateDown(_ *cobra.Command, args []string) {
+	var (
+		config = loadConfig()
+		steps  = 1
+		err    error
+	)
+
+	if len(args) == 1 {
+		if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+			log.Fatalln("steps must be a positive number")
+		}
+	}
+
+	var db = openDB(config)
+	defer internal.Closed(db)
+
+	done, err := sequel.NewMigrator(db, config.DbDriver).Down(context.Background(), steps)
+
+	for _, m := range done {
+		log.Printf("reverted migration %d %s\n", m.Version, m.Name)
+	}
+
+	if err != nil {
+		log.Fatalln("failed to revert migrations: ", err.Error())
+	}
+}
+
+func MigrateStatus(_ *cobra.Command, _ []string) {
+	var (
+		config = loadConfig()
+		db     = openDB(config)
+	)
+
+	defer internal.Closed(db)
+
+	var statuses, err = sequel.NewMigrator(db, config.DbDriver).Status(context.Background())
+	if err != nil {
+		log.Fatalln("failed to get migration status: ", err.Error())
+	}
+
+	var w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+
+	for _, s := range statuses {
+		var at = "pending"
+		if s.AppliedAt != nil {
+			at = s.AppliedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, at)
+	}
+
+	_ = w.Flush()
+}
+
+// migrateUp applies pending migrations, exiting on failure
+func migrateUp(db *sql.DB, config Config) {
+	var done, err = sequel.NewMigrator(db, config.DbDriver).Up(context.Background())
+
+	for _, m := range done {
+		log.Printf("applied migration %d %s\n", m.Version, m.Name)
+	}
+
+	if err != nil {
+		log.Fatalln("failed to apply migrations: ", err.Error())
+	}
+}
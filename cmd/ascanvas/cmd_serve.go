@@ -1,11 +1,23 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/nats-io/nats-server/v2/server"
 	"github.com/spf13/cobra"
 	"github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
@@ -13,9 +25,15 @@ import (
 
 	"github.com/fluxynet/ascanvas"
 	"github.com/fluxynet/ascanvas/broadcaster/memory"
+	"github.com/fluxynet/ascanvas/broadcaster/nats"
+	bs "github.com/fluxynet/ascanvas/broadcaster/sequel"
 	"github.com/fluxynet/ascanvas/cmd"
 	docs "github.com/fluxynet/ascanvas/docs/ascanvas"
 	"github.com/fluxynet/ascanvas/internal"
+	lm "github.com/fluxynet/ascanvas/locker/memory"
+	"github.com/fluxynet/ascanvas/repo/cache"
+	"github.com/fluxynet/ascanvas/repo/filesystem"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
 	"github.com/fluxynet/ascanvas/repo/sequel"
 	"github.com/fluxynet/ascanvas/web"
 	"github.com/fluxynet/ascanvas/web/canvas"
@@ -25,57 +43,150 @@ func Serve(_ *cobra.Command, _ []string) {
 	var (
 		logger        *zap.Logger
 		db            *sql.DB
-		repo          *sequel.Repository
-		broadcaster   *memory.Memory
+		repo          ascanvas.CanvasRepository
+		broadcaster   Broadcaster
 		canvasService *ascanvas.CanvasService
 		webCanvas     canvas.WebCanvas
 		router        *chi.Mux
 
-		config = Config{
-			ListenAddr: "127.0.0.1:1337",
-			DbDriver:   "sqlite",
-			DSN:        "ascanvas.db",
-			LogLevel:   "debug",
-		}
-
-		err = cmd.LoadConfig("ascanvas.json", &config)
+		config = loadConfig()
+		err    error
 	)
 
+	logger, err = cmd.Logger(config.LogLevel, cmd.DoNotLogToFile)
+
 	if err != nil {
-		log.Printf("config file not loaded, using defaults (%s)\n", err.Error())
+		log.Fatalln("failed to start logger: ", err.Error())
 	}
 
-	logger, err = cmd.Logger(config.LogLevel, cmd.DoNotLogToFile)
+	if needsDB(config) {
+		db = openDB(config)
+		defer internal.Closed(db)
+
+		if config.AutoMigrate {
+			migrateUp(db, config)
+		}
+	}
 
+	broadcaster, err = makeBroadcaster(config, db)
 	if err != nil {
-		log.Fatalln("failed to start logger: ", err.Error())
+		log.Fatalln("failed to start broadcaster: ", err.Error())
 	}
 
-	broadcaster = memory.New()
 	defer internal.Closed(broadcaster)
 
-	db, err = sql.Open(config.DbDriver, config.DSN)
+	repo, err = makeRepository(config, db)
+	if err != nil {
+		log.Fatalln("failed to start repository: ", err.Error())
+	}
+
+	if c, ok := repo.(io.Closer); ok {
+		defer internal.Closed(c)
+	}
+
+	frames, err := makeFrames(config, db, repo)
 	if err != nil {
-		log.Fatalln("failed to open database connection: ", err.Error())
-	} else if err = db.Ping(); err != nil {
-		log.Fatalln("failed to ping database: ", err.Error())
-	} else if _, err = db.Exec(sequel.SQLiteSchemaInit); err != nil {
-		log.Fatalln("failed to initialize schema: ", err.Error())
+		log.Fatalln("failed to start frames: ", err.Error())
 	}
 
-	repo = &sequel.Repository{DB: db}
+	if c, ok := frames.(io.Closer); ok {
+		defer internal.Closed(c)
+	}
+
+	var (
+		uncached  = repo
+		repoCache *cache.Cache
+	)
+
+	if config.CacheBytes > 0 {
+		repoCache = cache.New(repo, config.CacheBytes)
+		repo = repoCache
+	}
 
 	canvasService = &ascanvas.CanvasService{
 		Repo:        repo,
 		BroadCaster: broadcaster,
 		Logger:      logger,
 		GenerateID:  ascanvas.UUIDGenerator,
+		Clock:       ascanvas.SystemClock,
 		Broadcast:   ascanvas.AsyncBroadcast,
+		Frames:      frames,
+		Transactor:  makeTransactor(db, uncached),
+	}
+
+	// locks are held in memory, and would not be enforced by other instances sharing the broadcaster
+	if singleInstance(config) {
+		canvasService.Locker = lm.New()
+	} else {
+		logger.Warn("locks disabled, as they only hold within a single instance", zap.String("broadcaster", config.Broadcaster))
+	}
+
+	if config.CRDT {
+		if canvasService.Registers, err = makeRegisters(config, db, uncached); err != nil {
+			log.Fatalln("failed to start crdt: ", err.Error())
+		}
+	}
+
+	if config.History {
+		history, err := makeHistory(config, db, uncached)
+		if err != nil {
+			log.Fatalln("failed to start history: ", err.Error())
+		}
+
+		if c, ok := history.(io.Closer); ok {
+			defer internal.Closed(c)
+		}
+
+		canvasService.History = history
+	}
+
+	if config.Trash != "" {
+		var retention, err = time.ParseDuration(config.Trash)
+		if err != nil {
+			log.Fatalln("invalid trash retention: ", err.Error())
+		}
+
+		trash, err := makeTrash(config, db, uncached)
+		if err != nil {
+			log.Fatalln("failed to start trash: ", err.Error())
+		}
+
+		if c, ok := trash.(io.Closer); ok {
+			defer internal.Closed(c)
+		}
+
+		canvasService.Trash = trash
+
+		if retention > 0 {
+			go purgeTrash(canvasService, retention, time.Minute)
+		}
+	}
+
+	go expireLocks(canvasService, time.Second)
+
+	if repoCache != nil {
+		if _, events, err := canvasService.Observe(context.Background(), ascanvas.ObserveALL); err == nil {
+			go repoCache.Track(events)
+			go logCacheStats(logger, repoCache, time.Minute)
+		} else {
+			log.Fatalln("failed to track cache invalidations: ", err.Error())
+		}
+	}
+
+	canvasService.Presences = ascanvas.NewPresenceTracker()
+	if _, events, err := canvasService.Observe(context.Background(), ascanvas.ObserveALL); err == nil {
+		go canvasService.Presences.Track(events)
+		go expirePresences(canvasService, 5*time.Second)
+	} else {
+		log.Fatalln("failed to track presence: ", err.Error())
 	}
 
 	webCanvas = canvas.WebCanvas{
-		Service: canvasService,
-		GetID:   web.ChiIDGetter,
+		Service:   canvasService,
+		GetID:     web.ChiIDGetter,
+		GetLockID: web.ChiParamGetter("lock"),
+		GetFrame:  web.ChiParamGetter("frame"),
+		Version:   appCommit,
 	}
 
 	docs.SwaggerInfo.Host = config.ListenAddr
@@ -86,10 +197,32 @@ func Serve(_ *cobra.Command, _ []string) {
 
 	router.Route("/api", func(r chi.Router) {
 		r.Get("/events", webCanvas.Observe)
+		r.Get("/search", webCanvas.Search)
+		r.Get("/trash", webCanvas.Trash)
+		r.Get("/export.zip", webCanvas.Backup)
+		r.Post("/import", webCanvas.Import)
+		r.Post("/convert", webCanvas.Convert)
 
 		r.Get("/{id}/events", webCanvas.Observe)
 		r.Patch("/{id}/rectangle", webCanvas.Rectangle)
 		r.Patch("/{id}/floodfill", webCanvas.Floodfill)
+		r.Post("/{id}/sync", webCanvas.Sync)
+		r.Post("/{id}/find", webCanvas.Find)
+		r.Post("/{id}/cursor", webCanvas.Cursor)
+		r.Get("/{id}/presence", webCanvas.Presence)
+		r.Get("/{id}/export", webCanvas.Export)
+		r.Get("/{id}/locks", webCanvas.Locks)
+		r.Post("/{id}/locks", webCanvas.Lock)
+		r.Patch("/{id}/locks/{lock}", webCanvas.RenewLock)
+		r.Delete("/{id}/locks/{lock}", webCanvas.Unlock)
+		r.Get("/{id}/timelapse", webCanvas.Timelapse)
+		r.Get("/{id}/timelapse/events", webCanvas.ReplayTimelapse)
+		r.Get("/{id}/frames", webCanvas.Frames)
+		r.Post("/{id}/frames/{frame}/duplicate", webCanvas.DuplicateFrame)
+		r.Patch("/{id}/frames/{frame}", webCanvas.FrameDelay)
+		r.Delete("/{id}/frames/{frame}", webCanvas.DeleteFrame)
+		r.Post("/{id}/restore", webCanvas.Restore)
+		r.Delete("/{id}/purge", webCanvas.Purge)
 		r.Delete("/{id}", webCanvas.Delete)
 		r.Get("/{id}", webCanvas.Get)
 
@@ -103,9 +236,219 @@ func Serve(_ *cobra.Command, _ []string) {
 		w.Write(ascanvas.IndexHTML)
 	})
 
-	log.Printf("server listening at %s\n", config.ListenAddr)
-	err = http.ListenAndServe(config.ListenAddr, router)
+	listen(config.ListenAddr, router)
+}
+
+// listen until interrupted, then shut down gracefully so that deferred cleanups run
+func listen(addr string, handler http.Handler) {
+	var (
+		server      = &http.Server{Addr: addr, Handler: handler}
+		ctx, cancel = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		errs        = make(chan error, 1)
+	)
+
+	defer cancel()
+
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	log.Printf("server listening at %s\n", addr)
+
+	select {
+	case err := <-errs:
+		log.Println(err)
+	case <-ctx.Done():
+		log.Println("shutting down")
+
+		var timeout, done = context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+
+		if err := server.Shutdown(timeout); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func makeRepository(config Config, db *sql.DB) (ascanvas.CanvasRepository, error) {
+	switch config.Repository {
+	case RepositorySequel, "":
+		return &sequel.Repository{DB: db, Dialect: sequel.DialectOf(config.DbDriver)}, nil
+	case RepositoryFilesystem:
+		return filesystem.New(config.RepoDir)
+	case RepositoryMemory:
+		if config.Snapshot != "" {
+			return rm.Load(config.Snapshot)
+		}
+
+		return rm.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown repository: %s", config.Repository)
+	}
+}
+
+// makeTrash stored along with canvases of repo: in the database, the directory or the snapshot
+func makeTrash(config Config, db *sql.DB, repo ascanvas.CanvasRepository) (ascanvas.CanvasTrash, error) {
+	switch r := repo.(type) {
+	case *sequel.Repository:
+		return &sequel.Trash{DB: db, Dialect: sequel.DialectOf(config.DbDriver)}, nil
+	case *filesystem.Repository:
+		return filesystem.NewTrash(r)
+	case *rm.Memory:
+		if r.Snapshot != "" {
+			return rm.LoadTrash(snapshotOf(r.Snapshot, "trash"))
+		}
+
+		return rm.NewTrash(), nil
+	default:
+		return nil, fmt.Errorf("%w: trash of repository %T", ascanvas.ErrNotSupported, repo)
+	}
+}
+
+// makeRegisters of cells merged along with canvases of repo, in the same transaction: only in the database
+func makeRegisters(config Config, db *sql.DB, repo ascanvas.CanvasRepository) (ascanvas.CellRegisterRepository, error) {
+	if _, ok := repo.(*sequel.Repository); !ok {
+		return nil, fmt.Errorf("%w: crdt needs the sequel repository, not %s", ascanvas.ErrNotSupported, config.Repository)
+	}
+
+	return &sequel.CellRegisters{DB: db, Dialect: sequel.DialectOf(config.DbDriver)}, nil
+}
+
+// makeTransactor spanning canvases and trash of repo, nil when it has no transactions
+func makeTransactor(db *sql.DB, repo ascanvas.CanvasRepository) ascanvas.Transactor {
+	if _, ok := repo.(*sequel.Repository); ok {
+		return sequel.Transactor{DB: db}
+	}
+
+	return nil
+}
+
+// snapshotOf something kept along with a memory snapshot, e.g. canvas.trash.json for canvas.json
+func snapshotOf(snapshot, what string) string {
+	var ext = filepath.Ext(snapshot)
+	return strings.TrimSuffix(snapshot, ext) + "." + what + ext
+}
+
+// makeFrames of animated canvases stored along with canvases of repo: in the database, the directory or the snapshot
+func makeFrames(config Config, db *sql.DB, repo ascanvas.CanvasRepository) (ascanvas.CanvasFrameRepository, error) {
+	switch r := repo.(type) {
+	case *sequel.Repository:
+		return &sequel.Frames{DB: db, Dialect: sequel.DialectOf(config.DbDriver)}, nil
+	case *filesystem.Repository:
+		return filesystem.NewFrames(r)
+	case *rm.Memory:
+		if r.Snapshot != "" {
+			return rm.LoadFrames(snapshotOf(r.Snapshot, "frames"))
+		}
+
+		return rm.NewFrames(), nil
+	default:
+		return nil, fmt.Errorf("%w: frames of repository %T", ascanvas.ErrNotSupported, repo)
+	}
+}
+
+// makeHistory of canvases stored along with canvases of repo: in the database, the directory or the snapshot
+func makeHistory(config Config, db *sql.DB, repo ascanvas.CanvasRepository) (ascanvas.CanvasHistory, error) {
+	switch r := repo.(type) {
+	case *sequel.Repository:
+		return &sequel.History{DB: db, Dialect: sequel.DialectOf(config.DbDriver)}, nil
+	case *filesystem.Repository:
+		return filesystem.NewHistory(r)
+	case *rm.Memory:
+		if r.Snapshot != "" {
+			return rm.LoadHistory(snapshotOf(r.Snapshot, "history"))
+		}
+
+		return rm.NewHistory(), nil
+	default:
+		return nil, fmt.Errorf("%w: history of repository %T", ascanvas.ErrNotSupported, repo)
+	}
+}
+
+// Broadcaster is a closable ascanvas.CanvasBroadcaster
+type Broadcaster interface {
+	ascanvas.CanvasBroadcaster
+	io.Closer
+}
+
+func makeBroadcaster(config Config, db *sql.DB) (Broadcaster, error) {
+	switch config.Broadcaster {
+	case BroadcasterMemory, "":
+		return memory.New(), nil
+	case BroadcasterSequel:
+		return bs.New(db, sequel.DialectOf(config.DbDriver), bs.DefaultPollInterval)
+	case BroadcasterNats:
+		if config.NatsURL != "" {
+			return nats.Connect(config.NatsURL)
+		}
+
+		return runEmbeddedNats()
+	default:
+		return nil, fmt.Errorf("unknown broadcaster: %s", config.Broadcaster)
+	}
+}
+
+// embeddedNats shuts down the in-process server along with the broadcaster
+type embeddedNats struct {
+	*nats.Broadcaster
+	server *server.Server
+}
+
+func (e embeddedNats) Close() error {
+	var err = e.Broadcaster.Close()
+	e.server.Shutdown()
+
+	return err
+}
+
+func runEmbeddedNats() (Broadcaster, error) {
+	var s, err = nats.RunServer("127.0.0.1", -1)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("embedded nats server listening at %s\n", s.ClientURL())
+
+	b, err := nats.Connect(s.ClientURL())
 	if err != nil {
-		log.Fatalln(err)
+		s.Shutdown()
+		return nil, err
+	}
+
+	return embeddedNats{Broadcaster: b, server: s}, nil
+}
+
+// logCacheStats periodically
+func logCacheStats(logger *zap.Logger, c *cache.Cache, interval time.Duration) {
+	for range time.Tick(interval) {
+		var s = c.Stats()
+		logger.Debug("Cache::Stats",
+			zap.Uint64("Hits", s.Hits),
+			zap.Uint64("Misses", s.Misses),
+			zap.Uint64("Evictions", s.Evictions),
+			zap.Int("Entries", s.Entries),
+			zap.Int("Bytes", s.Bytes),
+		)
+	}
+}
+
+// expireLocks periodically releases expired locks so that observers are notified
+func expireLocks(s *ascanvas.CanvasService, interval time.Duration) {
+	for range time.Tick(interval) {
+		_ = s.ExpireLocks(context.Background())
+	}
+}
+
+// expirePresences periodically of sessions not heard of for a while, e.g. connected to an instance which crashed
+func expirePresences(s *ascanvas.CanvasService, interval time.Duration) {
+	for range time.Tick(interval) {
+		s.ExpirePresences(context.Background())
+	}
+}
+
+// purgeTrash periodically of canvases deleted longer than retention ago
+func purgeTrash(s *ascanvas.CanvasService, retention, interval time.Duration) {
+	for range time.Tick(interval) {
+		_ = s.PurgeExpired(context.Background(), retention)
 	}
 }
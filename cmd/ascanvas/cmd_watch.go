@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web/client"
+)
+
+const (
+	// MinReconnectDelay is how long watch waits before reconnecting the first time
+	MinReconnectDelay = time.Second
+
+	// MaxReconnectDelay is the longest watch waits before reconnecting, doubling the delay every time until then
+	MaxReconnectDelay = 30 * time.Second
+)
+
+// errDeleted ends following a canvas
+var errDeleted = errors.New("canvas deleted")
+
+// changedFunc is told of a canvas as it is when followed, then after every change
+type changedFunc func(canvas *ascanvas.Canvas, event string)
+
+func Watch(c *cobra.Command, args []string) {
+	var (
+		api      = newClient(c)
+		ctx, end = signal.NotifyContext(context.Background(), os.Interrupt)
+	)
+
+	defer end()
+
+	// hide the cursor while drawing, and show it again on exit
+	fmt.Print("\x1b[?25l")
+
+	var err = followAgain(ctx, api, args[0],
+		func(canvas *ascanvas.Canvas, event string) {
+			drawCanvas(os.Stdout, canvas, event, time.Now())
+		},
+		func(err error, delay time.Duration) {
+			fmt.Printf("\r\x1b[2K\x1b[7m disconnected: %s, reconnecting in %s \x1b[0m", oneLine(err), delay)
+		},
+	)
+
+	fmt.Print("\x1b[?25h\n")
+
+	if err != nil {
+		log.Fatalln("failed to watch canvas: ", err.Error())
+	}
+}
+
+// followAgain a canvas, reconnecting with a growing delay whenever the stream of events ends,
+// until the canvas is deleted or ctx is done; disconnected is told of every wait before reconnecting
+func followAgain(ctx context.Context, api canvasAPI, id string, changed changedFunc, disconnected func(err error, delay time.Duration)) error {
+	var delay = MinReconnectDelay
+
+	for {
+		var connected, err = follow(ctx, api, id, changed)
+
+		switch {
+		case errors.Is(err, errDeleted) || ctx.Err() != nil:
+			return nil
+		case errors.Is(err, ascanvas.ErrNotFound):
+			return err
+		}
+
+		if connected {
+			delay = MinReconnectDelay
+		}
+
+		disconnected(err, delay)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > MaxReconnectDelay {
+			delay = MaxReconnectDelay
+		}
+	}
+}
+
+// follow a canvas, telling changed of it as it is then after every change until the stream of events ends;
+// connected tells if the stream was connected at all
+func follow(ctx context.Context, api canvasAPI, id string, changed changedFunc) (bool, error) {
+	var events, err = api.Events(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	defer func() { _ = events.Close() }()
+
+	// fetched once connected for no change to be missed
+	var canvas *ascanvas.Canvas
+	if canvas, err = api.Get(ctx, id); err != nil {
+		return true, err
+	}
+
+	changed(canvas, "connected")
+
+	for {
+		var event client.Event
+		if event, err = events.Next(); err != nil {
+			return true, err
+		}
+
+		switch event.Name {
+		case ascanvas.CanvasEventUpdated, ascanvas.CanvasEventRestored:
+			if canvas, err = event.Canvas(); err != nil {
+				return true, err
+			}
+
+			changed(canvas, string(event.Name))
+		case ascanvas.CanvasEventDeleted:
+			changed(canvas, string(event.Name))
+			return true, errDeleted
+		}
+	}
+}
+
+// drawCanvas over the whole terminal, with a status line of its name, size and the last event at the bottom
+func drawCanvas(w io.Writer, canvas *ascanvas.Canvas, event string, at time.Time) {
+	var b strings.Builder
+
+	// cursor home, clear screen
+	b.WriteString("\x1b[H\x1b[2J")
+
+	for _, row := range canvas.Rows() {
+		b.WriteString(row)
+		b.WriteString("\r\n")
+	}
+
+	fmt.Fprintf(&b, "\x1b[7m %s  %dx%d  %s at %s \x1b[0m", canvas.Name, canvas.Width, canvas.Height, event, at.Format("15:04:05"))
+
+	_, _ = io.WriteString(w, b.String())
+}
+
+// oneLine of an error, for the status line
+func oneLine(err error) string {
+	if err == nil || err == io.EOF {
+		return "stream ended"
+	}
+
+	return strings.ReplaceAll(err.Error(), "\n", " ")
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 
 	"github.com/spf13/cobra"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/cmd"
 )
 
 var (
@@ -32,6 +36,164 @@ func main() {
 	}
 	rootCmd.AddCommand(cmdServe)
 
+	var cmdMigrate = &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage database schema migrations",
+	}
+	rootCmd.AddCommand(cmdMigrate)
+
+	cmdMigrate.AddCommand(&cobra.Command{
+		Use:   "up",
+		Short: "Apply all pending migrations",
+		Args:  cobra.NoArgs,
+		Run:   MigrateUp,
+	})
+
+	cmdMigrate.AddCommand(&cobra.Command{
+		Use:   "down [steps]",
+		Short: "Revert the latest applied migrations, 1 by default",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   MigrateDown,
+	})
+
+	cmdMigrate.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "List migrations and when they were applied",
+		Args:  cobra.NoArgs,
+		Run:   MigrateStatus,
+	})
+
+	var cmdConvert = &cobra.Command{
+		Use:   "convert <image>",
+		Short: "Print a png, jpeg or gif image drawn with characters; - reads the image from stdin",
+		Args:  cobra.ExactArgs(1),
+		Run:   Convert,
+	}
+	cmdConvert.Flags().Int("width", ascanvas.DefaultConvertWidth, "Width in characters")
+	cmdConvert.Flags().Float64("aspect", ascanvas.DefaultAspect, "Width of terminal cells divided by their height")
+	cmdConvert.Flags().Bool("dither", false, "Floyd-Steinberg dithering")
+	cmdConvert.Flags().String("ramp", ascanvas.DefaultRamp, "Characters from the lightest to the darkest")
+	cmdConvert.Flags().String("format", string(ascanvas.TextPlain), "Output format: text, ansi or html")
+	rootCmd.AddCommand(cmdConvert)
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "backup [file]",
+		Short: "Write all canvases to a zip archive, named after the current time by default; - writes it to stdout",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   Backup,
+	})
+
+	var cmdRestore = &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Restore canvases from a zip archive written by backup; restoring it again changes nothing",
+		Args:  cobra.ExactArgs(1),
+		Run:   Restore,
+	}
+	cmdRestore.Flags().String("on-collision", string(ascanvas.RestoreSkip), "When a different canvas has the same id: skip, overwrite or rename")
+	rootCmd.AddCommand(cmdRestore)
+
+	var cmdList = withOutputFlag(withClientFlags(&cobra.Command{
+		Use:   "list",
+		Short: "List canvases of a server",
+		Args:  cobra.NoArgs,
+		Run:   List,
+	}))
+	cmdList.Flags().String("name", "", "Only canvases with a name containing this, ignoring case")
+	rootCmd.AddCommand(cmdList)
+
+	var cmdGet = withOutputFlag(withClientFlags(&cobra.Command{
+		Use:   "get <id>",
+		Short: "Print a canvas of a server",
+		Args:  cobra.ExactArgs(1),
+		Run:   Get,
+	}))
+	cmdGet.Flags().String("format", string(ascanvas.TextPlain), "Text format: text, ansi or html")
+	rootCmd.AddCommand(cmdGet)
+
+	var cmdCreate = withOutputFlag(withClientFlags(&cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a canvas on a server and print its id",
+		Args:  cobra.ExactArgs(1),
+		Run:   Create,
+	}))
+	cmdCreate.Flags().Int("width", 0, "Width in characters")
+	cmdCreate.Flags().Int("height", 0, "Height in characters")
+	cmdCreate.Flags().String("fill", " ", "Character the canvas is filled with")
+	rootCmd.AddCommand(cmdCreate)
+
+	rootCmd.AddCommand(withClientFlags(&cobra.Command{
+		Use:   "delete <id>",
+		Short: "Delete a canvas of a server",
+		Args:  cobra.ExactArgs(1),
+		Run:   Delete,
+	}))
+
+	var cmdRect = withOutputFlag(withClientFlags(&cobra.Command{
+		Use:   "rect <id>",
+		Short: "Draw a rectangle on a canvas of a server",
+		Args:  cobra.ExactArgs(1),
+		Run:   Rect,
+	}))
+	cmdRect.Flags().Int("x", 0, "Column of the top left corner")
+	cmdRect.Flags().Int("y", 0, "Row of the top left corner")
+	cmdRect.Flags().Int("width", 0, "Width in characters")
+	cmdRect.Flags().Int("height", 0, "Height in characters")
+	cmdRect.Flags().String("fill", "", "Character the rectangle is filled with")
+	cmdRect.Flags().String("outline", "", "Character the rectangle is outlined with")
+	cmdRect.Flags().Int("frame", 0, "Frame of an animated canvas to draw on")
+	rootCmd.AddCommand(cmdRect)
+
+	var cmdFill = withOutputFlag(withClientFlags(&cobra.Command{
+		Use:   "fill <id>",
+		Short: "Floodfill a canvas of a server",
+		Args:  cobra.ExactArgs(1),
+		Run:   Fill,
+	}))
+	cmdFill.Flags().Int("x", 0, "Column of the start")
+	cmdFill.Flags().Int("y", 0, "Row of the start")
+	cmdFill.Flags().String("fill", "", "Character the area is filled with")
+	cmdFill.Flags().Int("frame", 0, "Frame of an animated canvas to fill")
+	rootCmd.AddCommand(cmdFill)
+
+	var cmdImport = withOutputFlag(withClientFlags(&cobra.Command{
+		Use:   "import <file>",
+		Short: "Create a canvas on a server from a text file and print its id; - reads the text from stdin",
+		Args:  cobra.ExactArgs(1),
+		Run:   Import,
+	}))
+	cmdImport.Flags().String("name", "", "Name of the canvas, the name of the file by default")
+	cmdImport.Flags().String("fill", "", "Character short lines are padded with, a space by default")
+	rootCmd.AddCommand(cmdImport)
+
+	var cmdExport = withClientFlags(&cobra.Command{
+		Use:   "export <id>",
+		Short: "Download a canvas of a server as an image or asciicast",
+		Args:  cobra.ExactArgs(1),
+		Run:   Export,
+	})
+	cmdExport.Flags().String("format", string(ascanvas.ImagePNG), "Format: png, svg, gif or cast")
+	cmdExport.Flags().Int("cell-width", 0, "Width of every character in pixels")
+	cmdExport.Flags().Int("cell-height", 0, "Height of every character in pixels")
+	cmdExport.Flags().Int("padding", 0, "Padding around the canvas in pixels")
+	cmdExport.Flags().String("fg", "", "Foreground color, as #rgb or #rrggbb")
+	cmdExport.Flags().String("bg", "", "Background color, as #rgb or #rrggbb")
+	cmdExport.Flags().String("file", "", "File written, stdout by default")
+	rootCmd.AddCommand(cmdExport)
+
+	rootCmd.AddCommand(withClientFlags(&cobra.Command{
+		Use:   "watch <id>",
+		Short: "Draw a canvas of a server in the terminal, redrawn live as it changes",
+		Args:  cobra.ExactArgs(1),
+		Run:   Watch,
+	}))
+
+	rootCmd.AddCommand(withClientFlags(&cobra.Command{
+		Use:   "edit <id>",
+		Short: "Edit a canvas of a server full screen in the terminal, along with others editing it",
+		Args:  cobra.ExactArgs(1),
+		Run:   Edit,
+	}))
+
 	cmdVersion := &cobra.Command{
 		Use:   "version",
 		Short: "Check software version",
@@ -47,9 +209,84 @@ func main() {
 	}
 }
 
+const (
+	// BroadcasterMemory only notifies observers within the same process
+	BroadcasterMemory = "memory"
+
+	// BroadcasterSequel notifies observers of all processes sharing the same database
+	BroadcasterSequel = "sequel"
+
+	// BroadcasterNats notifies observers of all processes connected to the same NATS server
+	BroadcasterNats = "nats"
+)
+
+const (
+	// RepositorySequel stores canvases in the database
+	RepositorySequel = "sequel"
+
+	// RepositoryFilesystem stores canvases as text files in repository_dir
+	RepositoryFilesystem = "filesystem"
+
+	// RepositoryMemory keeps canvases in memory, saved to repository_snapshot on shutdown if set
+	RepositoryMemory = "memory"
+)
+
 type Config struct {
-	ListenAddr string `json:"listen_addr"`
-	DbDriver   string `json:"db_driver"`
-	DSN        string `json:"dsn"`
-	LogLevel   string `json:"log_level"`
+	ListenAddr  string `json:"listen_addr"`
+	DbDriver    string `json:"db_driver"`
+	DSN         string `json:"dsn"`
+	LogLevel    string `json:"log_level"`
+	Repository  string `json:"repository"`
+	RepoDir     string `json:"repository_dir"`
+	Snapshot    string `json:"repository_snapshot"`
+	CacheBytes  int    `json:"cache_bytes"`
+	Trash       string `json:"trash_retention"`
+	Broadcaster string `json:"broadcaster"`
+	NatsURL     string `json:"nats_url"`
+	CRDT        bool   `json:"crdt"`
+	History     bool   `json:"history"`
+	AutoMigrate bool   `json:"auto_migrate"`
+}
+
+// loadConfig from ascanvas.json, falling back to defaults
+func loadConfig() Config {
+	var config = Config{
+		ListenAddr:  "127.0.0.1:1337",
+		DbDriver:    "sqlite",
+		DSN:         "file:ascanvas.db?_pragma=busy_timeout(5000)",
+		LogLevel:    "debug",
+		Repository:  RepositorySequel,
+		RepoDir:     "canvas",
+		Broadcaster: BroadcasterMemory,
+		AutoMigrate: true,
+	}
+
+	if err := cmd.LoadConfig("ascanvas.json", &config); err != nil {
+		log.Printf("config file not loaded, using defaults (%s)\n", err.Error())
+	}
+
+	return config
+}
+
+// needsDB tells if any component configured is backed by the database
+func needsDB(config Config) bool {
+	return config.Repository == RepositorySequel || config.Repository == "" ||
+		config.Broadcaster == BroadcasterSequel
+}
+
+// openDB as per config, exiting on failure
+func openDB(config Config) *sql.DB {
+	var db, err = sql.Open(config.DbDriver, config.DSN)
+	if err != nil {
+		log.Fatalln("failed to open database connection: ", err.Error())
+	} else if err = db.Ping(); err != nil {
+		log.Fatalln("failed to ping database: ", err.Error())
+	}
+
+	return db
+}
+
+// singleInstance tells if the server runs alone, the broadcaster not being shared with other instances
+func singleInstance(config Config) bool {
+	return config.Broadcaster == BroadcasterMemory || config.Broadcaster == ""
 }
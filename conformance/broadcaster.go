@@ -0,0 +1,300 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// Broadcaster is a closable ascanvas.CanvasBroadcaster
+type Broadcaster interface {
+	ascanvas.CanvasBroadcaster
+	io.Closer
+}
+
+// NewBroadcaster returns a broadcaster without observers; it is called once per test and closed by it
+type NewBroadcaster func(t *testing.T) Broadcaster
+
+// TestBroadcaster checks delivery by canvas id and to ObserveALL, ordering, stopping observers and Close semantics
+func TestBroadcaster(t *testing.T, newBroadcaster NewBroadcaster) {
+	t.Run("delivery", func(t *testing.T) { broadcasterDelivery(t, newBroadcaster(t)) })
+	t.Run("no_observer", func(t *testing.T) { broadcasterNoObserver(t, newBroadcaster(t)) })
+	t.Run("stop", func(t *testing.T) { broadcasterStop(t, newBroadcaster(t)) })
+	t.Run("close", func(t *testing.T) { broadcasterClose(t, newBroadcaster(t)) })
+	t.Run("concurrent", func(t *testing.T) { broadcasterConcurrent(t, newBroadcaster(t)) })
+}
+
+// collector receives events of an observer in the background until its channel is closed
+type collector struct {
+	events []ascanvas.CanvasEvent
+	closed bool
+	mutex  sync.Mutex
+	done   chan struct{}
+}
+
+func collect(events <-chan ascanvas.CanvasEvent) *collector {
+	var c = &collector{done: make(chan struct{})}
+
+	go func() {
+		defer close(c.done)
+
+		for e := range events {
+			c.mutex.Lock()
+			c.events = append(c.events, e)
+			c.mutex.Unlock()
+		}
+
+		c.mutex.Lock()
+		c.closed = true
+		c.mutex.Unlock()
+	}()
+
+	return c
+}
+
+// wait until n events are received, or Timeout, and a little more in case unexpected events follow
+func (c *collector) wait(n int) []ascanvas.CanvasEvent {
+	var deadline = time.Now().Add(Timeout)
+
+	for time.Now().Before(deadline) {
+		c.mutex.Lock()
+		var got = len(c.events)
+		c.mutex.Unlock()
+
+		if got >= n {
+			break
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	time.Sleep(Quiet)
+
+	defer c.mutex.Unlock()
+	c.mutex.Lock()
+
+	return append([]ascanvas.CanvasEvent{}, c.events...)
+}
+
+// isClosed tells if the channel has been closed within Timeout
+func (c *collector) isClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	case <-time.After(Timeout):
+		return false
+	}
+}
+
+func mustObserve(t *testing.T, b Broadcaster, id string) (ascanvas.StopObserveFunc, *collector) {
+	t.Helper()
+
+	var stop, events, err = b.Observe(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Observe(%s) error = %v", id, err)
+	}
+
+	return stop, collect(events)
+}
+
+func mustBroadcast(t *testing.T, b Broadcaster, events ...ascanvas.CanvasEvent) {
+	t.Helper()
+
+	for i := range events {
+		if err := b.Broadcast(context.Background(), events[i]); err != nil {
+			t.Fatalf("Broadcast() error = %v", err)
+		}
+	}
+}
+
+func event(name ascanvas.CanvasEventName, i int) ascanvas.CanvasEvent {
+	return ascanvas.CanvasEvent{Name: name, Canvas: canvasN(i)}
+}
+
+func broadcasterDelivery(t *testing.T, b Broadcaster) {
+	defer b.Close()
+
+	var (
+		stopAll, all = mustObserve(t, b, ascanvas.ObserveALL)
+		stop1, one   = mustObserve(t, b, canvasN(1).Id)
+		stop2, two   = mustObserve(t, b, canvasN(2).Id)
+		stop3, three = mustObserve(t, b, canvasN(3).Id)
+
+		events = []ascanvas.CanvasEvent{
+			event(ascanvas.CanvasEventCreated, 1),
+			event(ascanvas.CanvasEventCreated, 2),
+			event(ascanvas.CanvasEventUpdated, 1),
+			event(ascanvas.CanvasEventDeleted, 1),
+		}
+	)
+
+	defer stopAll()
+	defer stop1()
+	defer stop2()
+	defer stop3()
+
+	mustBroadcast(t, b, events...)
+
+	var tests = []struct {
+		name string
+		c    *collector
+		want []ascanvas.CanvasEvent
+	}{
+		{name: "all", c: all, want: events},
+		{name: "id 1", c: one, want: []ascanvas.CanvasEvent{events[0], events[2], events[3]}},
+		{name: "id 2", c: two, want: []ascanvas.CanvasEvent{events[1]}},
+		{name: "id 3", c: three, want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := tt.c.wait(len(tt.want)); len(got) != 0 || len(tt.want) != 0 {
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("observer %s got = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func broadcasterNoObserver(t *testing.T, b Broadcaster) {
+	defer b.Close()
+
+	var done = make(chan struct{})
+
+	go func() {
+		defer close(done)
+		mustBroadcast(t, b, event(ascanvas.CanvasEventCreated, 1))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(Timeout):
+		t.Errorf("Broadcast() without observers blocked")
+	}
+}
+
+func broadcasterStop(t *testing.T, b Broadcaster) {
+	defer b.Close()
+
+	var (
+		stop, stopped = mustObserve(t, b, canvasN(1).Id)
+		stopK, kept   = mustObserve(t, b, canvasN(1).Id)
+		first         = event(ascanvas.CanvasEventCreated, 1)
+		second        = event(ascanvas.CanvasEventUpdated, 1)
+	)
+
+	defer stopK()
+
+	mustBroadcast(t, b, first)
+
+	if got := stopped.wait(1); !reflect.DeepEqual(got, []ascanvas.CanvasEvent{first}) {
+		t.Errorf("before stop got = %v, want %v", got, []ascanvas.CanvasEvent{first})
+	}
+
+	stop()
+
+	if !stopped.isClosed() {
+		t.Fatalf("channel not closed after stop")
+	}
+
+	// stopping again must not panic
+	stop()
+
+	mustBroadcast(t, b, second)
+
+	if got := kept.wait(2); !reflect.DeepEqual(got, []ascanvas.CanvasEvent{first, second}) {
+		t.Errorf("other observer got = %v, want %v", got, []ascanvas.CanvasEvent{first, second})
+	}
+
+	if got := stopped.wait(1); len(got) != 1 {
+		t.Errorf("stopped observer got = %v, want only %v", got, first)
+	}
+}
+
+func broadcasterClose(t *testing.T, b Broadcaster) {
+	var (
+		stopAll, all = mustObserve(t, b, ascanvas.ObserveALL)
+		stop1, one   = mustObserve(t, b, canvasN(1).Id)
+	)
+
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for name, c := range map[string]*collector{"all": all, "id 1": one} {
+		if !c.isClosed() {
+			t.Errorf("observer %s: channel not closed after Close", name)
+		}
+	}
+
+	// stopping observers of a closed broadcaster must not panic
+	stopAll()
+	stop1()
+}
+
+func broadcasterConcurrent(t *testing.T, b Broadcaster) {
+	defer b.Close()
+
+	const (
+		publishers = 4
+		each       = 10
+	)
+
+	var (
+		stop, all = mustObserve(t, b, ascanvas.ObserveALL)
+		wg        sync.WaitGroup
+	)
+
+	defer stop()
+
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+
+		go func(p int) {
+			defer wg.Done()
+
+			for i := 0; i < each; i++ {
+				var e = event(ascanvas.CanvasEventUpdated, p)
+				e.Canvas.Name = fmt.Sprintf("%d-%d", p, i)
+
+				if err := b.Broadcast(context.Background(), e); err != nil {
+					t.Errorf("Broadcast() error = %v", err)
+				}
+			}
+		}(p)
+	}
+
+	wg.Wait()
+
+	var (
+		got  = all.wait(publishers * each)
+		seen = make(map[string]int)
+		last = make(map[string]int)
+	)
+
+	for _, e := range got {
+		var p, i int
+		if _, err := fmt.Sscanf(e.Canvas.Name, "%d-%d", &p, &i); err != nil {
+			t.Errorf("unexpected event %v", e)
+			continue
+		}
+
+		seen[e.Canvas.Name] += 1
+
+		// events of a same publisher arrive in order
+		if prev, ok := last[e.Canvas.Id]; ok && i <= prev {
+			t.Errorf("publisher %d: event %d received after %d", p, i, prev)
+		}
+
+		last[e.Canvas.Id] = i
+	}
+
+	if len(got) != publishers*each || len(seen) != publishers*each {
+		t.Errorf("received %d events, %d distinct, want %d", len(got), len(seen), publishers*each)
+	}
+}
@@ -0,0 +1,21 @@
+// Package conformance is a test kit proving that an implementation of ascanvas.CanvasRepository
+// or ascanvas.CanvasBroadcaster behaves like the ones shipped with ascanvas.
+//
+// Call it from a test of the implementation:
+//
+//	func TestRepository(t *testing.T) {
+//		conformance.TestRepository(t, func(t *testing.T) ascanvas.CanvasRepository {
+//			return myrepo.New(...)
+//		})
+//	}
+package conformance
+
+import (
+	"time"
+)
+
+// Timeout is how long events are awaited; asynchronous broadcasters may need more on slow machines
+var Timeout = 5 * time.Second
+
+// Quiet is how long to wait to be reasonably sure that an event is not coming
+var Quiet = 100 * time.Millisecond
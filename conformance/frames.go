@@ -0,0 +1,111 @@
+package conformance
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// NewFrames returns a frame repository without any animated canvas; it is called once per test
+type NewFrames func(t *testing.T) ascanvas.CanvasFrameRepository
+
+// TestFrames checks that frames are kept in order per canvas, replaced as a whole and deleted
+func TestFrames(t *testing.T, newFrames NewFrames) {
+	t.Run("empty", func(t *testing.T) { framesEmpty(t, newFrames(t)) })
+	t.Run("save_get", func(t *testing.T) { framesSaveGet(t, newFrames(t)) })
+	t.Run("save_again", func(t *testing.T) { framesSaveAgain(t, newFrames(t)) })
+	t.Run("delete", func(t *testing.T) { framesDelete(t, newFrames(t)) })
+}
+
+func framesN(n int) []ascanvas.Frame {
+	var frames = make([]ascanvas.Frame, n)
+
+	for i := range frames {
+		frames[i] = ascanvas.Frame{
+			Content: canvasN(i).Content,
+			Delay:   ascanvas.DefaultFrameDelay * (i + 1),
+		}
+	}
+
+	return frames
+}
+
+func mustSave(t *testing.T, f ascanvas.CanvasFrameRepository, id string, frames []ascanvas.Frame) {
+	t.Helper()
+
+	if err := f.Save(context.Background(), id, frames); err != nil {
+		t.Fatalf("Save(%s) error = %v", id, err)
+	}
+}
+
+func wantFrames(t *testing.T, f ascanvas.CanvasFrameRepository, id string, want []ascanvas.Frame) {
+	t.Helper()
+
+	var got, err = f.Get(context.Background(), id)
+
+	if err != nil {
+		t.Errorf("Get(%s) error = %v", id, err)
+	} else if len(got) != 0 || len(want) != 0 {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Get(%s) got = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func framesEmpty(t *testing.T, f ascanvas.CanvasFrameRepository) {
+	wantFrames(t, f, canvasN(1).Id, nil)
+
+	if err := f.Delete(context.Background(), canvasN(1).Id); err != nil {
+		t.Errorf("Delete() of canvas without frames error = %v, want nil", err)
+	}
+}
+
+func framesSaveGet(t *testing.T, f ascanvas.CanvasFrameRepository) {
+	var (
+		a = framesN(3)
+		b = framesN(12)
+	)
+
+	mustSave(t, f, canvasN(1).Id, a)
+	mustSave(t, f, canvasN(2).Id, b)
+
+	wantFrames(t, f, canvasN(1).Id, a)
+	wantFrames(t, f, canvasN(2).Id, b)
+}
+
+func framesSaveAgain(t *testing.T, f ascanvas.CanvasFrameRepository) {
+	var (
+		frames = framesN(3)
+		again  = framesN(2)
+	)
+
+	again[1].Content = "changed"
+
+	mustSave(t, f, canvasN(1).Id, frames)
+	mustSave(t, f, canvasN(1).Id, again)
+
+	wantFrames(t, f, canvasN(1).Id, []ascanvas.Frame{frames[0], {Content: "changed", Delay: frames[1].Delay}})
+
+	// frames saved are not changed along with the slice given
+	again[0].Delay = 1
+	wantFrames(t, f, canvasN(1).Id, []ascanvas.Frame{frames[0], {Content: "changed", Delay: frames[1].Delay}})
+}
+
+func framesDelete(t *testing.T, f ascanvas.CanvasFrameRepository) {
+	var (
+		a = framesN(2)
+		b = framesN(3)
+	)
+
+	mustSave(t, f, canvasN(1).Id, a)
+	mustSave(t, f, canvasN(2).Id, b)
+
+	if err := f.Delete(context.Background(), canvasN(1).Id); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	wantFrames(t, f, canvasN(1).Id, nil)
+	wantFrames(t, f, canvasN(2).Id, b)
+}
@@ -0,0 +1,126 @@
+package conformance
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// NewHistory returns a history without any revision; it is called once per test
+type NewHistory func(t *testing.T) ascanvas.CanvasHistory
+
+// TestHistory checks that revisions are listed per canvas in the order they were appended, and deleted
+func TestHistory(t *testing.T, newHistory NewHistory) {
+	t.Run("empty", func(t *testing.T) { historyEmpty(t, newHistory(t)) })
+	t.Run("append_list", func(t *testing.T) { historyAppendList(t, newHistory(t)) })
+	t.Run("same_time", func(t *testing.T) { historySameTime(t, newHistory(t)) })
+	t.Run("delete", func(t *testing.T) { historyDelete(t, newHistory(t)) })
+}
+
+func revisionsN(n int) []ascanvas.Revision {
+	var revisions = make([]ascanvas.Revision, n)
+
+	for i := range revisions {
+		var c = canvasN(i)
+
+		revisions[i] = ascanvas.Revision{
+			Op:      ascanvas.RevisionRectangle,
+			Frame:   i % 3,
+			Content: c.Content,
+			Width:   c.Width,
+			Height:  c.Height,
+			At:      epoch.Add(time.Duration(i) * time.Second),
+			By:      "carol",
+		}
+	}
+
+	return revisions
+}
+
+func mustAppend(t *testing.T, h ascanvas.CanvasHistory, id string, revisions ...ascanvas.Revision) {
+	t.Helper()
+
+	for i := range revisions {
+		if err := h.Append(context.Background(), id, revisions[i]); err != nil {
+			t.Fatalf("Append(%s) error = %v", id, err)
+		}
+	}
+}
+
+func wantRevisions(t *testing.T, h ascanvas.CanvasHistory, id string, want []ascanvas.Revision) {
+	t.Helper()
+
+	var got, err = h.List(context.Background(), id)
+
+	if err != nil {
+		t.Errorf("List(%s) error = %v", id, err)
+	} else if len(got) != 0 || len(want) != 0 {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("List(%s) got = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func historyEmpty(t *testing.T, h ascanvas.CanvasHistory) {
+	wantRevisions(t, h, canvasN(1).Id, nil)
+
+	if err := h.Delete(context.Background(), canvasN(1).Id); err != nil {
+		t.Errorf("Delete() of canvas without history error = %v, want nil", err)
+	}
+}
+
+func historyAppendList(t *testing.T, h ascanvas.CanvasHistory) {
+	var (
+		a = revisionsN(3)
+		b = revisionsN(5)
+	)
+
+	// a deleted frame is recorded without content
+	b[4].Op, b[4].Content = ascanvas.RevisionFrames, ""
+
+	for i := range b {
+		if i < len(a) {
+			mustAppend(t, h, canvasN(1).Id, a[i])
+		}
+
+		mustAppend(t, h, canvasN(2).Id, b[i])
+	}
+
+	wantRevisions(t, h, canvasN(1).Id, a)
+	wantRevisions(t, h, canvasN(2).Id, b)
+}
+
+func historySameTime(t *testing.T, h ascanvas.CanvasHistory) {
+	var revisions = revisionsN(4)
+
+	for i := range revisions {
+		revisions[i].At = epoch
+	}
+
+	mustAppend(t, h, canvasN(1).Id, revisions...)
+	wantRevisions(t, h, canvasN(1).Id, revisions)
+}
+
+func historyDelete(t *testing.T, h ascanvas.CanvasHistory) {
+	var (
+		a = revisionsN(2)
+		b = revisionsN(3)
+	)
+
+	mustAppend(t, h, canvasN(1).Id, a...)
+	mustAppend(t, h, canvasN(2).Id, b...)
+
+	if err := h.Delete(context.Background(), canvasN(1).Id); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	wantRevisions(t, h, canvasN(1).Id, nil)
+	wantRevisions(t, h, canvasN(2).Id, b)
+
+	// the history starts over once deleted
+	mustAppend(t, h, canvasN(1).Id, a[1])
+	wantRevisions(t, h, canvasN(1).Id, a[1:])
+}
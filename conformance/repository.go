@@ -0,0 +1,243 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// NewRepository returns an empty repository; it is called once per test
+type NewRepository func(t *testing.T) ascanvas.CanvasRepository
+
+// TestRepository checks CRUD semantics, ErrNotFound on missing ids, List ordering and concurrent access;
+// canvases must be stored with their timestamps to the nanosecond and their authors
+func TestRepository(t *testing.T, newRepo NewRepository) {
+	t.Run("empty", func(t *testing.T) { repositoryEmpty(t, newRepo(t)) })
+	t.Run("create_get", func(t *testing.T) { repositoryCreateGet(t, newRepo(t)) })
+	t.Run("create_duplicate", func(t *testing.T) { repositoryCreateDuplicate(t, newRepo(t)) })
+	t.Run("get_missing", func(t *testing.T) { repositoryGetMissing(t, newRepo(t)) })
+	t.Run("update", func(t *testing.T) { repositoryUpdate(t, newRepo(t)) })
+	t.Run("update_missing", func(t *testing.T) { repositoryUpdateMissing(t, newRepo(t)) })
+	t.Run("delete", func(t *testing.T) { repositoryDelete(t, newRepo(t)) })
+	t.Run("list_order", func(t *testing.T) { repositoryListOrder(t, newRepo(t)) })
+	t.Run("concurrent", func(t *testing.T) { repositoryConcurrent(t, newRepo(t)) })
+}
+
+// epoch of the timestamps of canvases
+var epoch = time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+
+func canvasN(i int) ascanvas.Canvas {
+	var at = epoch.Add(time.Duration(i) * time.Minute)
+
+	return ascanvas.Canvas{
+		Id:        fmt.Sprintf("%03d", i),
+		Name:      fmt.Sprintf("Canvas %d", i),
+		Content:   strings.Repeat(string(rune('a'+i%26)), 6),
+		Width:     3,
+		Height:    2,
+		CreatedAt: at,
+		UpdatedAt: at.Add(time.Nanosecond),
+		CreatedBy: "alice",
+	}
+}
+
+func mustCreate(t *testing.T, r ascanvas.CanvasRepository, canvases ...ascanvas.Canvas) {
+	t.Helper()
+
+	for i := range canvases {
+		if err := r.Create(context.Background(), canvases[i]); err != nil {
+			t.Fatalf("Create(%s) error = %v", canvases[i].Id, err)
+		}
+	}
+}
+
+func wantGet(t *testing.T, r ascanvas.CanvasRepository, id string, want *ascanvas.Canvas) {
+	t.Helper()
+
+	var got, err = r.Get(context.Background(), id)
+
+	if want == nil {
+		if !errors.Is(err, ascanvas.ErrNotFound) {
+			t.Errorf("Get(%s) error = %v, want ErrNotFound", id, err)
+		}
+
+		return
+	}
+
+	if err != nil {
+		t.Errorf("Get(%s) error = %v", id, err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Errorf("Get(%s) got = %v, want %v", id, got, want)
+	}
+}
+
+func wantList(t *testing.T, r ascanvas.CanvasRepository, want []ascanvas.Canvas) {
+	t.Helper()
+
+	var got, err = r.List(context.Background())
+
+	if err != nil {
+		t.Errorf("List() error = %v", err)
+	} else if got == nil {
+		t.Errorf("List() got = nil, want non-nil slice")
+	} else if len(got) != 0 || len(want) != 0 {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("List() got = %v, want %v", got, want)
+		}
+	}
+}
+
+func repositoryEmpty(t *testing.T, r ascanvas.CanvasRepository) {
+	wantList(t, r, []ascanvas.Canvas{})
+}
+
+func repositoryCreateGet(t *testing.T, r ascanvas.CanvasRepository) {
+	var (
+		small = canvasN(1)
+		large = ascanvas.Canvas{Id: "2", Name: "Large", Content: strings.Repeat("#", 800*600), Width: 800, Height: 600}
+		odd   = ascanvas.Canvas{Id: "3", Name: "Odd", Content: strings.Repeat(".", 255), Width: 15, Height: 17}
+	)
+
+	mustCreate(t, r, small, large, odd)
+
+	wantGet(t, r, small.Id, &small)
+	wantGet(t, r, large.Id, &large)
+	wantGet(t, r, odd.Id, &odd)
+}
+
+func repositoryCreateDuplicate(t *testing.T, r ascanvas.CanvasRepository) {
+	var (
+		c         = canvasN(1)
+		duplicate = ascanvas.Canvas{Id: c.Id, Name: "Duplicate", Content: "x", Width: 1, Height: 1}
+	)
+
+	mustCreate(t, r, c)
+
+	if err := r.Create(context.Background(), duplicate); err == nil {
+		t.Errorf("Create() of duplicate id error = nil, want error")
+	}
+
+	wantGet(t, r, c.Id, &c)
+}
+
+func repositoryGetMissing(t *testing.T, r ascanvas.CanvasRepository) {
+	mustCreate(t, r, canvasN(1))
+
+	wantGet(t, r, "404", nil)
+}
+
+func repositoryUpdate(t *testing.T, r ascanvas.CanvasRepository) {
+	var (
+		c     = canvasN(1)
+		other = canvasN(2)
+	)
+
+	mustCreate(t, r, c, other)
+
+	c.Name = "Renamed"
+	c.Content = strings.Repeat("x", 12)
+	c.Width = 4
+	c.Height = 3
+	c.UpdatedAt = c.UpdatedAt.Add(time.Hour)
+	c.UpdatedBy = "bob"
+
+	if err := r.Update(context.Background(), c); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	wantGet(t, r, c.Id, &c)
+	wantGet(t, r, other.Id, &other)
+}
+
+func repositoryUpdateMissing(t *testing.T, r ascanvas.CanvasRepository) {
+	if err := r.Update(context.Background(), canvasN(1)); err != nil {
+		t.Errorf("Update() of missing canvas error = %v, want nil", err)
+	}
+
+	// updating does not create
+	wantGet(t, r, canvasN(1).Id, nil)
+}
+
+func repositoryDelete(t *testing.T, r ascanvas.CanvasRepository) {
+	var (
+		ctx  = context.Background()
+		c    = canvasN(1)
+		kept = canvasN(2)
+	)
+
+	mustCreate(t, r, c, kept)
+
+	if err := r.Delete(ctx, c.Id); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	wantGet(t, r, c.Id, nil)
+	wantGet(t, r, kept.Id, &kept)
+
+	if err := r.Delete(ctx, c.Id); err != nil {
+		t.Errorf("Delete() of missing canvas error = %v, want nil", err)
+	}
+
+	// the id can be used again
+	mustCreate(t, r, c)
+	wantGet(t, r, c.Id, &c)
+}
+
+func repositoryListOrder(t *testing.T, r ascanvas.CanvasRepository) {
+	var want = []ascanvas.Canvas{canvasN(1), canvasN(2), canvasN(3), canvasN(4)}
+
+	// created out of order, listed by id
+	mustCreate(t, r, want[2], want[0], want[3], want[1])
+
+	wantList(t, r, want)
+}
+
+func repositoryConcurrent(t *testing.T, r ascanvas.CanvasRepository) {
+	const n = 20
+
+	var (
+		ctx  = context.Background()
+		wg   sync.WaitGroup
+		want = make([]ascanvas.Canvas, n)
+	)
+
+	for i := 0; i < n; i++ {
+		want[i] = canvasN(i)
+		want[i].Name = "Updated"
+
+		wg.Add(1)
+
+		go func(c ascanvas.Canvas) {
+			defer wg.Done()
+
+			if err := r.Create(ctx, c); err != nil {
+				t.Errorf("Create(%s) error = %v", c.Id, err)
+				return
+			}
+
+			if _, err := r.List(ctx); err != nil {
+				t.Errorf("List() error = %v", err)
+			}
+
+			c.Name = "Updated"
+			if err := r.Update(ctx, c); err != nil {
+				t.Errorf("Update(%s) error = %v", c.Id, err)
+			}
+
+			if _, err := r.Get(ctx, c.Id); err != nil {
+				t.Errorf("Get(%s) error = %v", c.Id, err)
+			}
+		}(canvasN(i))
+	}
+
+	wg.Wait()
+
+	wantList(t, r, want)
+}
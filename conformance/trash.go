@@ -0,0 +1,161 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// NewTrash returns an empty trash; it is called once per test
+type NewTrash func(t *testing.T) ascanvas.CanvasTrash
+
+// TestTrash checks that deleted canvases are kept as put, listed most recently deleted first, removed and expired
+func TestTrash(t *testing.T, newTrash NewTrash) {
+	t.Run("empty", func(t *testing.T) { trashEmpty(t, newTrash(t)) })
+	t.Run("put_get", func(t *testing.T) { trashPutGet(t, newTrash(t)) })
+	t.Run("put_again", func(t *testing.T) { trashPutAgain(t, newTrash(t)) })
+	t.Run("list_order", func(t *testing.T) { trashListOrder(t, newTrash(t)) })
+	t.Run("remove", func(t *testing.T) { trashRemove(t, newTrash(t)) })
+	t.Run("expire", func(t *testing.T) { trashExpire(t, newTrash(t)) })
+}
+
+func deletedN(i int, deletedAt time.Time) ascanvas.DeletedCanvas {
+	return ascanvas.DeletedCanvas{
+		Canvas:    canvasN(i),
+		DeletedAt: deletedAt,
+		DeletedBy: "carol",
+	}
+}
+
+func mustPut(t *testing.T, tr ascanvas.CanvasTrash, canvases ...ascanvas.DeletedCanvas) {
+	t.Helper()
+
+	for i := range canvases {
+		if err := tr.Put(context.Background(), canvases[i]); err != nil {
+			t.Fatalf("Put(%s) error = %v", canvases[i].Id, err)
+		}
+	}
+}
+
+func wantTrashed(t *testing.T, tr ascanvas.CanvasTrash, id string, want *ascanvas.DeletedCanvas) {
+	t.Helper()
+
+	var got, err = tr.Get(context.Background(), id)
+
+	if want == nil {
+		if !errors.Is(err, ascanvas.ErrNotFound) {
+			t.Errorf("Get(%s) error = %v, want ErrNotFound", id, err)
+		}
+
+		return
+	}
+
+	if err != nil {
+		t.Errorf("Get(%s) error = %v", id, err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Errorf("Get(%s) got = %v, want %v", id, got, want)
+	}
+}
+
+func wantTrashList(t *testing.T, tr ascanvas.CanvasTrash, want []ascanvas.DeletedCanvas) {
+	t.Helper()
+
+	var got, err = tr.List(context.Background())
+
+	if err != nil {
+		t.Errorf("List() error = %v", err)
+	} else if got == nil {
+		t.Errorf("List() got = nil, want non-nil slice")
+	} else if len(got) != 0 || len(want) != 0 {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("List() got = %v, want %v", got, want)
+		}
+	}
+}
+
+func trashEmpty(t *testing.T, tr ascanvas.CanvasTrash) {
+	wantTrashList(t, tr, []ascanvas.DeletedCanvas{})
+	wantTrashed(t, tr, canvasN(1).Id, nil)
+
+	if err := tr.Remove(context.Background(), canvasN(1).Id); err != nil {
+		t.Errorf("Remove() of missing canvas error = %v, want nil", err)
+	}
+}
+
+func trashPutGet(t *testing.T, tr ascanvas.CanvasTrash) {
+	var c = deletedN(1, epoch.Add(time.Hour+time.Nanosecond))
+
+	mustPut(t, tr, c)
+	wantTrashed(t, tr, c.Id, &c)
+}
+
+func trashPutAgain(t *testing.T, tr ascanvas.CanvasTrash) {
+	var (
+		c     = deletedN(1, epoch.Add(time.Hour))
+		again = deletedN(1, epoch.Add(2*time.Hour))
+	)
+
+	again.Name = "Deleted again"
+
+	mustPut(t, tr, c, again)
+	wantTrashed(t, tr, c.Id, &again)
+	wantTrashList(t, tr, []ascanvas.DeletedCanvas{again})
+}
+
+func trashListOrder(t *testing.T, tr ascanvas.CanvasTrash) {
+	var (
+		a = deletedN(1, epoch.Add(time.Hour))
+		b = deletedN(2, epoch.Add(3*time.Hour))
+		c = deletedN(3, epoch.Add(2*time.Hour))
+		d = deletedN(4, epoch.Add(3*time.Hour))
+	)
+
+	mustPut(t, tr, d, a, c, b)
+	wantTrashList(t, tr, []ascanvas.DeletedCanvas{b, d, c, a})
+}
+
+func trashRemove(t *testing.T, tr ascanvas.CanvasTrash) {
+	var (
+		a = deletedN(1, epoch.Add(time.Hour))
+		b = deletedN(2, epoch.Add(time.Hour))
+	)
+
+	mustPut(t, tr, a, b)
+
+	if err := tr.Remove(context.Background(), a.Id); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	wantTrashed(t, tr, a.Id, nil)
+	wantTrashList(t, tr, []ascanvas.DeletedCanvas{b})
+}
+
+func trashExpire(t *testing.T, tr ascanvas.CanvasTrash) {
+	var (
+		a = deletedN(1, epoch.Add(2*time.Hour))
+		b = deletedN(2, epoch.Add(time.Hour))
+		c = deletedN(3, epoch.Add(3*time.Hour))
+	)
+
+	mustPut(t, tr, a, b, c)
+
+	// canvases deleted exactly at the time given are kept
+	var expired, err = tr.Expire(context.Background(), c.DeletedAt)
+	if err != nil {
+		t.Fatalf("Expire() error = %v", err)
+	}
+
+	if want := []ascanvas.DeletedCanvas{b, a}; !reflect.DeepEqual(expired, want) {
+		t.Errorf("Expire() got = %v, want %v", expired, want)
+	}
+
+	wantTrashList(t, tr, []ascanvas.DeletedCanvas{c})
+
+	if expired, err = tr.Expire(context.Background(), c.DeletedAt); err != nil || len(expired) != 0 {
+		t.Errorf("Expire() again got = %v, %v, want nothing", expired, err)
+	}
+}
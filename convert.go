@@ -0,0 +1,271 @@
+package ascanvas
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strings"
+
+	// decoders of the formats accepted by DecodeImage
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultRamp of characters from the lightest to the darkest
+	DefaultRamp = " .:-=+*#%@"
+
+	// DefaultConvertWidth is the width of converted canvases, unless images are narrower
+	DefaultConvertWidth = 80
+
+	// DefaultAspect of terminal cells, as their width divided by their height
+	DefaultAspect = 0.5
+
+	// MaxDecodePixels is the largest image that can be converted
+	MaxDecodePixels = 1 << 26
+)
+
+// ConvertArgs to create a canvas from an image; zero values are replaced by defaults
+type ConvertArgs struct {
+	Name string `json:"name"`
+
+	// Width of the canvas in characters; its height follows the proportions of the image and Aspect
+	Width int `json:"width"`
+
+	// Aspect of cells the canvas is displayed with, as their width divided by their height; 0.5 for terminals
+	Aspect float64 `json:"aspect"`
+
+	// Dither with Floyd-Steinberg error diffusion, for smoother gradients
+	Dither bool `json:"dither"`
+
+	// Ramp of characters drawing luminance from the lightest to the darkest
+	Ramp string `json:"ramp"`
+}
+
+// AsLogFields is a helper for logging
+func (a ConvertArgs) AsLogFields() []zap.Field {
+	return []zap.Field{
+		zap.String("Name", a.Name),
+		zap.Int("Width", a.Width),
+		zap.Float64("Aspect", a.Aspect),
+		zap.Bool("Dither", a.Dither),
+		zap.String("Ramp", a.Ramp),
+	}
+}
+
+// WithDefaults for zero values
+func (a ConvertArgs) WithDefaults() ConvertArgs {
+	if a.Width == 0 {
+		a.Width = DefaultConvertWidth
+	}
+
+	if a.Aspect == 0 {
+		a.Aspect = DefaultAspect
+	}
+
+	if a.Ramp == "" {
+		a.Ramp = DefaultRamp
+	}
+
+	return a
+}
+
+// Validate args, once defaults are applied
+func (a ConvertArgs) Validate() error {
+	var errs []string
+
+	if a.Width < 1 || a.Width > MaxImportWidth {
+		errs = append(errs, fmt.Sprintf("width must be between 1 and %d", MaxImportWidth))
+	}
+
+	if !(a.Aspect >= 0.1 && a.Aspect <= 10) {
+		errs = append(errs, "aspect must be between 0.1 and 10")
+	}
+
+	if len(a.Ramp) < 2 {
+		errs = append(errs, "ramp must have at least 2 characters")
+	}
+
+	for i := 0; i < len(a.Ramp); i++ {
+		if printable(a.Ramp[i]) != a.Ramp[i] {
+			errs = append(errs, "ramp must be printable ascii")
+			break
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidInput, strings.Join(errs, ", "))
+}
+
+// DecodeImage in png, jpeg or gif format; only the first frame of animated gifs is decoded
+func DecodeImage(r io.Reader) (image.Image, error) {
+	var b, err = io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// dimensions are checked before decoding, as headers can claim huge images in small files
+	config, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a png, jpeg or gif image", ErrInvalidInput)
+	}
+
+	if config.Width*config.Height > MaxDecodePixels {
+		return nil, fmt.Errorf("%w: image of %dx%d pixels is too large", ErrInvalidInput, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidInput, err.Error())
+	}
+
+	return img, nil
+}
+
+// ImageToCanvas draws an image with characters of a ramp by luminance, as per args once defaults are applied;
+// transparent pixels are drawn as white, on which canvases are shown by default
+func ImageToCanvas(img image.Image, args ConvertArgs) (Canvas, error) {
+	var canvas Canvas
+
+	args = args.WithDefaults()
+	if err := args.Validate(); err != nil {
+		return canvas, err
+	}
+
+	var bounds = img.Bounds()
+	if bounds.Empty() {
+		return canvas, fmt.Errorf("%w: image is empty", ErrInvalidInput)
+	}
+
+	// every cell covers cw x ch pixels, at least one, of which the average luminance is taken
+	canvas.Width = args.Width
+	if bounds.Dx() < canvas.Width {
+		canvas.Width = bounds.Dx()
+	}
+
+	var (
+		cw = float64(bounds.Dx()) / float64(canvas.Width)
+		ch = cw / args.Aspect
+	)
+
+	canvas.Height = int(math.Round(float64(bounds.Dy()) / ch))
+	if canvas.Height < 1 {
+		canvas.Height = 1
+	} else if canvas.Height > MaxImportHeight {
+		return canvas, fmt.Errorf("%w: image is too tall, the canvas would have more than %d rows", ErrInvalidInput, MaxImportHeight)
+	}
+
+	ch = float64(bounds.Dy()) / float64(canvas.Height)
+
+	var lum = make([]float64, canvas.Width*canvas.Height)
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			lum[y*canvas.Width+x] = luminance(img, image.Rect(
+				bounds.Min.X+int(float64(x)*cw),
+				bounds.Min.Y+int(float64(y)*ch),
+				bounds.Min.X+int(math.Max(float64(x+1)*cw, float64(x)*cw+1)),
+				bounds.Min.Y+int(math.Max(float64(y+1)*ch, float64(y)*ch+1)),
+			))
+		}
+	}
+
+	var (
+		b      = make([]byte, len(lum))
+		levels = float64(len(args.Ramp) - 1)
+	)
+
+	for p := range lum {
+		// luminance 1 is the lightest, drawn with the first character of the ramp
+		var level = math.Round((1 - clamp(lum[p])) * levels)
+		b[p] = args.Ramp[int(level)]
+
+		if !args.Dither {
+			continue
+		}
+
+		var (
+			e    = lum[p] - (1 - level/levels)
+			x, y = p % canvas.Width, p / canvas.Width
+		)
+
+		diffuse(lum, canvas.Width, x+1, y, e*7/16)
+		diffuse(lum, canvas.Width, x-1, y+1, e*3/16)
+		diffuse(lum, canvas.Width, x, y+1, e*5/16)
+		diffuse(lum, canvas.Width, x+1, y+1, e*1/16)
+	}
+
+	canvas.Name = args.Name
+	canvas.Content = string(b)
+
+	return canvas, nil
+}
+
+// luminance of the pixels of r in img, from 0 for black to 1 for white
+func luminance(img image.Image, r image.Rectangle) float64 {
+	var sum float64
+
+	r = r.Intersect(img.Bounds())
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			var cr, cg, cb, ca = img.At(x, y).RGBA()
+
+			// colors are premultiplied by alpha, what is transparent shows white
+			var l = (0.2126*float64(cr) + 0.7152*float64(cg) + 0.0722*float64(cb) + float64(0xffff-ca)) / 0xffff
+			sum += l
+		}
+	}
+
+	if n := r.Dx() * r.Dy(); n != 0 {
+		return sum / float64(n)
+	}
+
+	return 1
+}
+
+func diffuse(lum []float64, width, x, y int, e float64) {
+	if x >= 0 && x < width && y*width+x < len(lum) {
+		lum[y*width+x] += e
+	}
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// Convert an image into a new Canvas, as per ImageToCanvas
+func (s CanvasService) Convert(ctx context.Context, r io.Reader, args ConvertArgs) (*Canvas, error) {
+	s.Logger.Debug("Convert::Validating", args.AsLogFields()...)
+
+	if args.Name == "" {
+		return nil, fmt.Errorf("%w: name cannot be empty", ErrInvalidInput)
+	}
+
+	if err := args.WithDefaults().Validate(); err != nil {
+		s.Logger.Debug("Convert::Validate::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	var img, err = DecodeImage(r)
+	if err != nil {
+		s.Logger.Debug("Convert::Decode::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	canvas, err := ImageToCanvas(img, args)
+	if err != nil {
+		s.Logger.Debug("Convert::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	return s.create(ctx, canvas)
+}
@@ -0,0 +1,240 @@
+package ascanvas_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	mb "github.com/fluxynet/ascanvas/broadcaster/mocks"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
+)
+
+// grays is an image one pixel high of gray levels
+func grays(levels ...uint8) image.Image {
+	var img = image.NewGray(image.Rect(0, 0, len(levels), 1))
+
+	for x, l := range levels {
+		img.SetGray(x, 0, color.Gray{Y: l})
+	}
+
+	return img
+}
+
+// stripes is an image of w x h pixels, black on the left half and white on the right
+func stripes(w, h int) image.Image {
+	var img = image.NewGray(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x >= w/2 {
+				img.SetGray(x, y, color.Gray{Y: 0xff})
+			}
+		}
+	}
+
+	return img
+}
+
+func TestImageToCanvas(t *testing.T) {
+	var transparent = image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	transparent.SetNRGBA(1, 0, color.NRGBA{A: 0xff})
+
+	tests := []struct {
+		name    string
+		img     image.Image
+		args    ascanvas.ConvertArgs
+		want    ascanvas.Canvas
+		wantErr error
+	}{
+		{
+			name: "ramp",
+			img:  grays(0xff, 0xaa, 0x55, 0x00),
+			args: ascanvas.ConvertArgs{Name: "Ramp", Aspect: 1, Ramp: " .:#"},
+			want: ascanvas.Canvas{Name: "Ramp", Content: " .:#", Width: 4, Height: 1},
+		},
+		{
+			name: "default ramp",
+			img:  grays(0xff, 0x00),
+			args: ascanvas.ConvertArgs{Aspect: 1},
+			want: ascanvas.Canvas{Content: " @", Width: 2, Height: 1},
+		},
+		{
+			name: "transparent is white",
+			img:  transparent,
+			args: ascanvas.ConvertArgs{Aspect: 1, Ramp: " #"},
+			want: ascanvas.Canvas{Content: " #", Width: 2, Height: 1},
+		},
+		{
+			name: "cells averaged",
+			img:  stripes(8, 8),
+			args: ascanvas.ConvertArgs{Width: 2, Aspect: 1, Ramp: " #"},
+			want: ascanvas.Canvas{Content: "# # ", Width: 2, Height: 2},
+		},
+		{
+			name: "aspect of terminal cells",
+			img:  stripes(8, 8),
+			args: ascanvas.ConvertArgs{Width: 4, Ramp: " #"},
+			want: ascanvas.Canvas{Content: "##  ##  ", Width: 4, Height: 2},
+		},
+		{
+			name: "no wider than the image",
+			img:  stripes(2, 2),
+			args: ascanvas.ConvertArgs{Width: 80, Aspect: 1, Ramp: " #"},
+			want: ascanvas.Canvas{Content: "# # ", Width: 2, Height: 2},
+		},
+		{
+			name: "without dithering",
+			img:  grays(0x80, 0x80, 0x80, 0x80),
+			args: ascanvas.ConvertArgs{Aspect: 1, Ramp: " #"},
+			want: ascanvas.Canvas{Content: "    ", Width: 4, Height: 1},
+		},
+		{
+			name: "dithering",
+			img:  grays(0x80, 0x80, 0x80, 0x80),
+			args: ascanvas.ConvertArgs{Aspect: 1, Ramp: " #", Dither: true},
+			want: ascanvas.Canvas{Content: " # #", Width: 4, Height: 1},
+		},
+		{
+			name:    "ramp too short",
+			img:     grays(0),
+			args:    ascanvas.ConvertArgs{Ramp: "#"},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "ramp not printable",
+			img:     grays(0),
+			args:    ascanvas.ConvertArgs{Ramp: " \t#"},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "aspect",
+			img:     grays(0),
+			args:    ascanvas.ConvertArgs{Aspect: -1},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "width",
+			img:     grays(0),
+			args:    ascanvas.ConvertArgs{Width: ascanvas.MaxImportWidth + 1},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "too tall",
+			img:     image.NewGray(image.Rect(0, 0, 1, ascanvas.MaxImportHeight+1)),
+			args:    ascanvas.ConvertArgs{Aspect: 1},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "empty",
+			img:     image.NewGray(image.Rectangle{}),
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, err = ascanvas.ImageToCanvas(tt.img, tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ImageToCanvas() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ImageToCanvas() got = %q, want %q", got.Content, tt.want.Content)
+			}
+		})
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	var (
+		img               = stripes(4, 2)
+		bpng, bjpeg, bgif bytes.Buffer
+		encoders          = []error{
+			png.Encode(&bpng, img),
+			jpeg.Encode(&bjpeg, img, &jpeg.Options{Quality: 100}),
+			gif.Encode(&bgif, img, nil),
+		}
+	)
+
+	for _, err := range encoders {
+		if err != nil {
+			t.Fatalf("encoding error = %v", err)
+		}
+	}
+
+	for _, b := range []bytes.Buffer{bpng, bjpeg, bgif} {
+		var got, err = ascanvas.DecodeImage(&b)
+		if err != nil {
+			t.Errorf("DecodeImage() error = %v", err)
+		} else if got.Bounds() != img.Bounds() {
+			t.Errorf("DecodeImage() bounds = %v, want %v", got.Bounds(), img.Bounds())
+		}
+	}
+
+	if _, err := ascanvas.DecodeImage(strings.NewReader("GIF89a")); !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("DecodeImage() of truncated image error = %v, want ErrInvalidInput", err)
+	}
+
+	if _, err := ascanvas.DecodeImage(strings.NewReader("hello")); !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("DecodeImage() of text error = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestCanvasService_Convert(t *testing.T) {
+	var (
+		ctx = context.Background()
+		brd = &mb.CanvasBroadcaster{}
+		s   = ascanvas.CanvasService{
+			Repo:        rm.New(),
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			GenerateID:  ascanvas.StaticUUIDGenerator("1", nil),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Clock:       ascanvas.StaticClock(now),
+		}
+		want = ascanvas.Canvas{
+			Id:        "1",
+			Name:      "Stripes",
+			Content:   "# # ",
+			Width:     2,
+			Height:    2,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		b bytes.Buffer
+	)
+
+	if err := png.Encode(&b, stripes(8, 8)); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	brd.On("Broadcast", mock.Anything, ascanvas.CanvasEvent{Name: ascanvas.CanvasEventCreated, Canvas: want}).Return(nil)
+
+	var args = ascanvas.ConvertArgs{Name: "Stripes", Width: 2, Aspect: 1, Ramp: " #"}
+
+	var got, err = s.Convert(ctx, bytes.NewReader(b.Bytes()), args)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	} else if !reflect.DeepEqual(*got, want) {
+		t.Errorf("Convert() got = %v, want %v", *got, want)
+	}
+
+	brd.AssertExpectations(t)
+
+	args.Name = ""
+	if _, err = s.Convert(ctx, bytes.NewReader(b.Bytes()), args); !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("Convert() without name error = %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,297 @@
+package ascanvas
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// SiteServer is the site of writes made by the server itself, i.e. transformations
+const SiteServer = "server"
+
+// CellWrite is a timestamped write of a single cell.
+// Concurrent writes of the same cell are resolved by keeping the highest Lamport timestamp,
+// ties being broken by the highest Site, so that all replicas converge regardless of arrival order.
+type CellWrite struct {
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	Value   string `json:"value"`
+	Lamport uint64 `json:"lamport"`
+	Site    string `json:"site"`
+
+	// Seq is assigned by the server, in the order writes are accepted
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+func (w CellWrite) Coordinates() Coordinates {
+	return Coordinates{X: w.X, Y: w.Y}
+}
+
+// Wins tells if w takes precedence over o
+func (w CellWrite) Wins(o CellWrite) bool {
+	if w.Lamport != o.Lamport {
+		return w.Lamport > o.Lamport
+	}
+
+	return w.Site > o.Site
+}
+
+// CellRegisterRepository persists the winning write of each cell
+type CellRegisterRepository interface {
+	// Since returns registers of a canvas with Seq greater than seq, ordered by Seq
+	Since(ctx context.Context, id string, seq uint64) ([]CellWrite, error)
+
+	// Merge writes into the registers of a canvas, merges of the same canvas waiting for each other.
+	// A write replaces the register of its cell only if it wins over it, then gets the next Seq; writes with a zero
+	// Lamport timestamp get one greater than all those known, so that they win. apply is told of the writes which won
+	// with a context in which the registers merged are read, before the merge is committed; it is undone if apply fails
+	Merge(ctx context.Context, id string, writes []CellWrite, apply func(ctx context.Context, applied []CellWrite) error) error
+
+	// Delete all registers of a canvas
+	Delete(ctx context.Context, id string) error
+}
+
+// CellRegisters is the state of all registers of a canvas
+type CellRegisters struct {
+	Cells   map[Coordinates]CellWrite
+	Seq     uint64
+	Lamport uint64
+}
+
+func NewCellRegisters(writes []CellWrite) CellRegisters {
+	var r = CellRegisters{
+		Cells: make(map[Coordinates]CellWrite, len(writes)),
+	}
+
+	for i := range writes {
+		r.set(writes[i])
+	}
+
+	return r
+}
+
+func (r *CellRegisters) set(w CellWrite) {
+	r.Cells[w.Coordinates()] = w
+
+	if w.Seq > r.Seq {
+		r.Seq = w.Seq
+	}
+
+	if w.Lamport > r.Lamport {
+		r.Lamport = w.Lamport
+	}
+}
+
+// Draw the value of every register onto a canvas, skipping cells out of its bounds
+func (r CellRegisters) Draw(canvas *Canvas) {
+	var content = []byte(canvas.Content)
+
+	for c, w := range r.Cells {
+		if p := c.Y*canvas.Width + c.X; canvas.Contains(c) && p < len(content) {
+			content[p] = w.Value[0]
+		}
+	}
+
+	canvas.Content = string(content)
+}
+
+// serverWrites of the cells changed between before and after, without Lamport timestamp
+func serverWrites(before, after Canvas) []CellWrite {
+	var writes []CellWrite
+
+	for p := 0; p < len(after.Content) && p < len(before.Content); p++ {
+		if before.Content[p] != after.Content[p] {
+			writes = append(writes, CellWrite{
+				X:     p % after.Width,
+				Y:     p / after.Width,
+				Value: after.Content[p : p+1],
+				Site:  SiteServer,
+			})
+		}
+	}
+
+	return writes
+}
+
+type SyncArgs struct {
+	// Site identifies the client; must be unique among clients
+	Site string `json:"site"`
+
+	// Since is the Seq returned by the previous sync; 0 for everything
+	Since uint64 `json:"since"`
+
+	Writes []CellWrite `json:"writes"`
+}
+
+func (a SyncArgs) Validate(canvas Canvas) error {
+	var errs []string
+
+	if a.Site == "" || a.Site == SiteServer {
+		errs = append(errs, "site cannot be empty or "+SiteServer)
+	}
+
+	for i, w := range a.Writes {
+		if w.Site != a.Site {
+			errs = append(errs, fmt.Sprintf("writes[%d]: site must be %s", i, a.Site))
+		}
+
+		if w.Lamport == 0 {
+			errs = append(errs, fmt.Sprintf("writes[%d]: lamport must be greater than zero", i))
+		}
+
+		if len(w.Value) != 1 {
+			errs = append(errs, fmt.Sprintf("writes[%d]: value must be exactly one character", i))
+		}
+
+		if !canvas.Contains(w.Coordinates()) {
+			errs = append(errs, fmt.Sprintf("writes[%d]: %s is out of bounds", i, w.Coordinates()))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidInput, strings.Join(errs, ", "))
+}
+
+// cells written, whether the writes win or not
+func (a SyncArgs) cells() []Coordinates {
+	var cells = make([]Coordinates, len(a.Writes))
+	for i := range a.Writes {
+		cells[i] = a.Writes[i].Coordinates()
+	}
+
+	return cells
+}
+
+type SyncResult struct {
+	// Seq to pass as Since on the next sync
+	Seq uint64 `json:"seq"`
+
+	// Lamport is the highest timestamp known to the server; clients should move their clock past it
+	Lamport uint64 `json:"lamport"`
+
+	// Writes the client is missing, ordered by Seq
+	Writes []CellWrite `json:"writes"`
+
+	Canvas Canvas `json:"canvas"`
+}
+
+// Sync merges timestamped cell writes of a client and returns the writes it is missing
+func (s CanvasService) Sync(ctx context.Context, id string, args SyncArgs) (*SyncResult, error) {
+	if s.Registers == nil {
+		return nil, ErrNotSupported
+	}
+
+	s.Logger.Debug("Sync::Fetching", zap.String("id", id), zap.String("site", args.Site))
+
+	var canvas, err = s.Repo.Get(ctx, id)
+	if err == ErrNotFound {
+		s.Logger.Debug("Sync:NotFound", zap.String("id", id))
+		return nil, err
+	} else if err != nil {
+		s.Logger.Error("Sync::Fetch::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	if err = args.Validate(*canvas); err != nil {
+		s.Logger.Debug("Sync::Validate::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	if err = s.checkLocks(ctx, id, cellsArea(args.cells())); err != nil {
+		s.Logger.Debug("Sync::Locked", zap.Error(err))
+		return nil, err
+	}
+
+	registers, applied, err := s.mergeWrites(ctx, canvas, args.Writes)
+	if err != nil {
+		s.Logger.Error("Sync::Merge::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	if len(applied) != 0 {
+		s.Logger.Debug("Sync::Updated", zap.String("id", id), zap.Int("applied", len(applied)))
+		s.record(ctx, RevisionSync, 0, *canvas)
+		s.Broadcast(ctx, s.BroadCaster, s.Logger, CanvasEvent{
+			Name:   CanvasEventUpdated,
+			Canvas: *canvas,
+		})
+	}
+
+	var result = SyncResult{
+		Seq:     registers.Seq,
+		Lamport: registers.Lamport,
+		Writes:  []CellWrite{},
+		Canvas:  *canvas,
+	}
+
+	for _, w := range registers.Cells {
+		if w.Seq > args.Since {
+			result.Writes = append(result.Writes, w)
+		}
+	}
+
+	sort.Slice(result.Writes, func(i, j int) bool {
+		return result.Writes[i].Seq < result.Writes[j].Seq
+	})
+
+	return &result, nil
+}
+
+// mergeWrites into the registers of a canvas, then sets it to the canvas stored with all registers drawn onto it,
+// updated if any write won. Merging and updating are one transaction when the repository shares it with the registers,
+// so that content follows registers even when merging concurrently
+func (s CanvasService) mergeWrites(ctx context.Context, canvas *Canvas, writes []CellWrite) (CellRegisters, []CellWrite, error) {
+	var (
+		registers CellRegisters
+		applied   []CellWrite
+	)
+
+	var err = s.Registers.Merge(ctx, canvas.Id, writes, func(ctx context.Context, won []CellWrite) error {
+		var all, err = s.Registers.Since(ctx, canvas.Id, 0)
+		if err != nil {
+			return err
+		}
+
+		stored, err := s.Repo.Get(ctx, canvas.Id)
+		if err != nil {
+			return err
+		}
+
+		registers, applied = NewCellRegisters(all), won
+		registers.Draw(stored)
+
+		if len(applied) == 0 {
+			*canvas = *stored
+			return nil
+		}
+
+		s.touch(ctx, stored)
+		if err = s.Repo.Update(ctx, *stored); err != nil {
+			return err
+		}
+
+		*canvas = *stored
+
+		return nil
+	})
+
+	return registers, applied, err
+}
+
+// save a transformed canvas, along with writes of the server for the cells it changed if there are registers
+func (s CanvasService) save(ctx context.Context, before Canvas, after *Canvas) error {
+	if s.Registers == nil {
+		s.touch(ctx, after)
+		return s.Repo.Update(ctx, *after)
+	}
+
+	var _, _, err = s.mergeWrites(ctx, after, serverWrites(before, *after))
+
+	return err
+}
@@ -0,0 +1,301 @@
+package ascanvas_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+	_ "modernc.org/sqlite"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/broadcaster/memory"
+	"github.com/fluxynet/ascanvas/internal"
+	"github.com/fluxynet/ascanvas/repo/sequel"
+)
+
+func makeSyncService(t *testing.T, dsn string) (*ascanvas.CanvasService, *sql.DB) {
+	var db, err = sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database connection: %s", err)
+	} else if _, err = sequel.NewMigrator(db, "sqlite").Up(context.Background()); err != nil {
+		t.Fatalf("failed to initialize schema: %s", err)
+	}
+
+	return &ascanvas.CanvasService{
+		Repo:        &sequel.Repository{DB: db},
+		BroadCaster: memory.New(),
+		Logger:      zaptest.NewLogger(t),
+		GenerateID:  ascanvas.StaticUUIDGenerator("1", nil),
+		Broadcast:   ascanvas.SyncBroadcast,
+		Registers:   &sequel.CellRegisters{DB: db},
+		History:     &sequel.History{DB: db},
+		Clock:       ascanvas.StaticClock(now),
+	}, db
+}
+
+// replica is a client editing offline and syncing from time to time
+type replica struct {
+	Site    string
+	Lamport uint64
+	Seq     uint64
+	Canvas  ascanvas.Canvas
+	Pending []ascanvas.CellWrite
+}
+
+func (r *replica) write(x, y int, value string) {
+	r.Lamport += 1
+
+	var w = ascanvas.CellWrite{X: x, Y: y, Value: value, Lamport: r.Lamport, Site: r.Site}
+	r.Pending = append(r.Pending, w)
+}
+
+func (r *replica) sync(t *testing.T, s *ascanvas.CanvasService) *ascanvas.SyncResult {
+	var result, err = s.Sync(context.Background(), r.Canvas.Id, ascanvas.SyncArgs{
+		Site:   r.Site,
+		Since:  r.Seq,
+		Writes: r.Pending,
+	})
+
+	if err != nil {
+		t.Fatalf("Sync() site %s error = %v", r.Site, err)
+	}
+
+	r.Pending = nil
+	r.Seq = result.Seq
+	r.Canvas = result.Canvas
+
+	if result.Lamport > r.Lamport {
+		r.Lamport = result.Lamport
+	}
+
+	return result
+}
+
+func TestCanvasService_Sync(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		s, db  = makeSyncService(t, ":memory:")
+		canvas *ascanvas.Canvas
+		err    error
+	)
+
+	defer internal.Closed(db)
+
+	if _, err = (ascanvas.CanvasService{}).Sync(ctx, "1", ascanvas.SyncArgs{}); !errors.Is(err, ascanvas.ErrNotSupported) {
+		t.Errorf("Sync() without registers error = %v, want ErrNotSupported", err)
+	}
+
+	if _, err = s.Sync(ctx, "404", ascanvas.SyncArgs{Site: "A"}); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Sync() of unknown canvas error = %v, want ErrNotFound", err)
+	}
+
+	if canvas, err = s.Create(ctx, ascanvas.CreateArgs{Name: "Foo", Fill: ".", Width: 3, Height: 2}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var (
+		a = &replica{Site: "A", Canvas: *canvas}
+		b = &replica{Site: "B", Canvas: *canvas}
+	)
+
+	if _, err = s.Sync(ctx, "1", ascanvas.SyncArgs{Site: "A", Writes: []ascanvas.CellWrite{
+		{X: 3, Y: 0, Value: "x", Lamport: 1, Site: "A"},
+	}}); !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("Sync() out of bounds error = %v, want ErrInvalidInput", err)
+	}
+
+	// both edit offline, with a conflict on 0,0
+	a.write(0, 0, "a")
+	a.write(1, 0, "a")
+	b.write(0, 0, "b")
+	b.write(2, 1, "b")
+
+	b.sync(t, s)
+	a.sync(t, s)
+
+	// the server transforms while b is offline again
+	if _, err = s.ApplyRectangle(ctx, "1", ascanvas.TransformRectangleArgs{
+		TopLeft: ascanvas.Coordinates{X: 0, Y: 1},
+		Width:   1,
+		Height:  1,
+		Fill:    "s",
+	}); err != nil {
+		t.Fatalf("ApplyRectangle() error = %v", err)
+	}
+
+	b.write(1, 1, "b")
+	result := b.sync(t, s)
+	a.sync(t, s)
+
+	var want = internal.CanvasFromText("1", "Foo", `
+ba.
+sbb`)
+	want.CreatedAt, want.UpdatedAt = now, now
+
+	for _, r := range []*replica{a, b} {
+		if !reflect.DeepEqual(r.Canvas, *want) {
+			t.Errorf("replica %s: got = %s, want %s", r.Site, r.Canvas, want)
+		}
+	}
+
+	// syncing without any write winning leaves no revision
+	var ops []ascanvas.RevisionOp
+	if revisions, err := s.History.List(ctx, "1"); err != nil {
+		t.Fatalf("List() error = %v", err)
+	} else {
+		for _, r := range revisions {
+			ops = append(ops, r.Op)
+		}
+	}
+
+	var wantOps = []ascanvas.RevisionOp{
+		ascanvas.RevisionCreated,
+		ascanvas.RevisionSync,
+		ascanvas.RevisionSync,
+		ascanvas.RevisionRectangle,
+		ascanvas.RevisionSync,
+	}
+
+	if !reflect.DeepEqual(ops, wantOps) {
+		t.Errorf("revisions got = %v, want %v", ops, wantOps)
+	}
+
+	// b already has its first writes; 0,0 is not resent since the write of a lost the conflict
+	var missing = make(map[string]string)
+	for _, w := range result.Writes {
+		missing[w.Coordinates().String()] = w.Value
+	}
+
+	if !reflect.DeepEqual(missing, map[string]string{"1,0": "a", "0,1": "s", "1,1": "b"}) {
+		t.Errorf("Sync() missing writes = %v", missing)
+	}
+
+	if got, _ := s.Get(ctx, "1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() got = %s, want %s", got, want)
+	}
+}
+
+func TestCanvasService_Sync_Concurrent(t *testing.T) {
+	const (
+		sites  = 8
+		rounds = 10
+	)
+
+	var (
+		ctx   = context.Background()
+		s, db = makeSyncService(t, "file:"+filepath.Join(t.TempDir(), "ascanvas.db")+"?_pragma=busy_timeout(10000)")
+	)
+
+	defer internal.Closed(db)
+
+	var canvas, err = s.Create(ctx, ascanvas.CreateArgs{Name: "Foo", Fill: ".", Width: 4, Height: 3})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   []error
+		sent   = make(map[ascanvas.Coordinates]ascanvas.CellWrite)
+		random = rand.New(rand.NewSource(1))
+		writes = make([][][]ascanvas.CellWrite, sites)
+	)
+
+	// writes of every site for every round, conflicting on the same few cells
+	for i := range writes {
+		writes[i] = make([][]ascanvas.CellWrite, rounds)
+
+		for j := range writes[i] {
+			for k := 0; k < 3; k++ {
+				var w = ascanvas.CellWrite{
+					X:       random.Intn(canvas.Width),
+					Y:       random.Intn(canvas.Height),
+					Value:   string(rune('a' + i)),
+					Lamport: uint64(j*3 + k + 1 + random.Intn(3)),
+					Site:    fmt.Sprintf("site-%d", i),
+				}
+
+				if current, ok := sent[w.Coordinates()]; !ok || w.Wins(current) {
+					sent[w.Coordinates()] = w
+				}
+
+				writes[i][j] = append(writes[i][j], w)
+			}
+		}
+	}
+
+	for i := range writes {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := range writes[i] {
+				var _, err = s.Sync(ctx, canvas.Id, ascanvas.SyncArgs{Site: fmt.Sprintf("site-%d", i), Writes: writes[i][j]})
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if len(errs) != 0 {
+		t.Fatalf("Sync() errors = %v", errs)
+	}
+
+	result, err := s.Sync(ctx, canvas.Id, ascanvas.SyncArgs{Site: "check"})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	var (
+		seqs = make(map[uint64]bool)
+		want = []byte(canvas.Content)
+	)
+
+	for _, w := range result.Writes {
+		if seqs[w.Seq] {
+			t.Errorf("Sync() seq %d allocated twice", w.Seq)
+		}
+
+		seqs[w.Seq] = true
+
+		if w.Seq > result.Seq {
+			t.Errorf("Sync() seq %d greater than %d", w.Seq, result.Seq)
+		}
+
+		var expected = sent[w.Coordinates()]
+		if w.Value != expected.Value || w.Lamport != expected.Lamport || w.Site != expected.Site {
+			t.Errorf("Sync() register %s = %v, want %v", w.Coordinates(), w, expected)
+		}
+	}
+
+	if len(result.Writes) != len(sent) {
+		t.Errorf("Sync() got %d registers, want %d", len(result.Writes), len(sent))
+	}
+
+	for c, w := range sent {
+		want[c.Y*canvas.Width+c.X] = w.Value[0]
+	}
+
+	stored, err := s.Repo.Get(ctx, canvas.Id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if stored.Content != string(want) || result.Canvas.Content != string(want) {
+		t.Errorf("content got = %q, stored %q, want %q", result.Canvas.Content, stored.Content, want)
+	}
+}
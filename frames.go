@@ -0,0 +1,286 @@
+package ascanvas
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// CanvasEventFrames is when frames of an animated canvas are changed, other than by changing the canvas itself
+const CanvasEventFrames CanvasEventName = "FRAMES"
+
+const (
+	// DefaultFrameDelay in milliseconds
+	DefaultFrameDelay = 100
+
+	// MinFrameDelay in milliseconds, the shortest that animated gifs can show
+	MinFrameDelay = 20
+
+	// MaxFrameDelay in milliseconds
+	MaxFrameDelay = 60000
+
+	// MaxFrames of an animated canvas
+	MaxFrames = 256
+)
+
+// Frame of an animated canvas; all frames share the width and height of the canvas
+type Frame struct {
+	Content string `json:"content"`
+
+	// Delay before the next frame is shown, in milliseconds
+	Delay int `json:"delay"`
+}
+
+// FrameDelay of a frame as told by FRAMES events, without its content which is listed by ListFrames
+type FrameDelay struct {
+	Index int `json:"index"`
+
+	// Delay before the next frame is shown, in milliseconds
+	Delay int `json:"delay"`
+}
+
+// frameDelays of frames in order
+func frameDelays(frames []Frame) []FrameDelay {
+	var delays = make([]FrameDelay, len(frames))
+
+	for i := range frames {
+		delays[i] = FrameDelay{Index: i, Delay: frames[i].Delay}
+	}
+
+	return delays
+}
+
+// CanvasFrameRepository keeps frames of animated canvases
+type CanvasFrameRepository interface {
+	// Get frames of a canvas in order; none when the canvas is not animated
+	Get(ctx context.Context, id string) ([]Frame, error)
+
+	// Save frames of a canvas in order, replacing existing ones
+	Save(ctx context.Context, id string, frames []Frame) error
+
+	// Delete all frames of a canvas
+	Delete(ctx context.Context, id string) error
+}
+
+// ListFrames of a canvas in order; the first one is the canvas itself, the only one unless it is animated
+func (s CanvasService) ListFrames(ctx context.Context, id string) ([]Frame, error) {
+	s.Logger.Debug("ListFrames::Fetching", zap.String("id", id))
+
+	var canvas, frames, err = s.frames(ctx, id)
+	if err != nil {
+		s.Logger.Debug("ListFrames::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	s.Logger.Debug("ListFrames::Fetched", zap.String("id", canvas.Id), zap.Int("count", len(frames)))
+
+	return frames, nil
+}
+
+// DuplicateFrame of a canvas, inserting the copy right after it
+func (s CanvasService) DuplicateFrame(ctx context.Context, id string, index int) ([]Frame, error) {
+	var revision Canvas
+
+	var frames, err = s.changeFrames(ctx, "DuplicateFrame", id, index, func(canvas *Canvas, frames []Frame) ([]Frame, error) {
+		if len(frames) >= MaxFrames {
+			return nil, fmt.Errorf("%w: canvas cannot have more than %d frames", ErrInvalidInput, MaxFrames)
+		}
+
+		frames = append(frames, Frame{})
+		copy(frames[index+1:], frames[index:])
+
+		revision = *canvas
+		revision.Content = frames[index+1].Content
+
+		return frames, nil
+	})
+
+	if err == nil {
+		s.record(ctx, RevisionFrames, index+1, revision)
+	}
+
+	return frames, err
+}
+
+// FrameDelayArgs to change how long a frame is shown
+type FrameDelayArgs struct {
+	// Delay before the next frame is shown, in milliseconds
+	Delay int `json:"delay"`
+}
+
+// Validate FrameDelayArgs
+func (a FrameDelayArgs) Validate() error {
+	if a.Delay < MinFrameDelay || a.Delay > MaxFrameDelay {
+		return fmt.Errorf("%w: delay must be between %d and %d", ErrInvalidInput, MinFrameDelay, MaxFrameDelay)
+	}
+
+	return nil
+}
+
+// SetFrameDelay before the next frame of a canvas is shown; no revision is recorded, the content being the same
+func (s CanvasService) SetFrameDelay(ctx context.Context, id string, index int, args FrameDelayArgs) ([]Frame, error) {
+	return s.changeFrames(ctx, "SetFrameDelay", id, index, func(canvas *Canvas, frames []Frame) ([]Frame, error) {
+		if err := args.Validate(); err != nil {
+			return nil, err
+		}
+
+		frames[index].Delay = args.Delay
+
+		return frames, nil
+	})
+}
+
+// DeleteFrame of a canvas, other than the first one which is the canvas itself
+func (s CanvasService) DeleteFrame(ctx context.Context, id string, index int) ([]Frame, error) {
+	var revision Canvas
+
+	var frames, err = s.changeFrames(ctx, "DeleteFrame", id, index, func(canvas *Canvas, frames []Frame) ([]Frame, error) {
+		if index == 0 {
+			return nil, fmt.Errorf("%w: the first frame is the canvas itself and cannot be deleted", ErrInvalidInput)
+		}
+
+		// a deleted frame is recorded without content
+		revision = *canvas
+		revision.Content = ""
+
+		return append(frames[:index], frames[index+1:]...), nil
+	})
+
+	if err == nil {
+		s.record(ctx, RevisionFrames, index, revision)
+	}
+
+	return frames, err
+}
+
+// frames of a canvas, the first one being the canvas itself
+func (s CanvasService) frames(ctx context.Context, id string) (*Canvas, []Frame, error) {
+	var canvas, err = s.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var frames []Frame
+	if s.Frames != nil {
+		if frames, err = s.Frames.Get(ctx, id); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(frames) == 0 {
+		frames = []Frame{{Delay: DefaultFrameDelay}}
+	}
+
+	frames[0].Content = canvas.Content
+
+	return canvas, frames, nil
+}
+
+// changeFrames of a canvas with change, given the frame at index exists, then broadcast them;
+// the canvas is updated as well when change touched it
+func (s CanvasService) changeFrames(ctx context.Context, op string, id string, index int, change func(canvas *Canvas, frames []Frame) ([]Frame, error)) ([]Frame, error) {
+	if s.Frames == nil {
+		return nil, ErrNotSupported
+	}
+
+	s.Logger.Debug(op+"::Fetching", zap.String("id", id), zap.Int("frame", index))
+
+	var canvas, frames, err = s.frames(ctx, id)
+	if err != nil {
+		s.Logger.Debug(op+"::Fetch::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	if index < 0 || index >= len(frames) {
+		return nil, fmt.Errorf("%w: frame %d", ErrNotFound, index)
+	}
+
+	var before, previous = *canvas, append([]Frame{}, frames...)
+
+	if frames, err = change(canvas, frames); err != nil {
+		s.Logger.Debug(op+"::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		if err := s.Frames.Save(ctx, id, frames); err != nil {
+			return err
+		}
+
+		if canvas.UpdatedAt.Equal(before.UpdatedAt) && canvas.UpdatedBy == before.UpdatedBy {
+			return nil
+		}
+
+		if err := s.Repo.Update(ctx, *canvas); err != nil {
+			s.undo(ctx, op, func() error { return s.Frames.Save(ctx, id, previous) })
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		s.Logger.Error(op+"::Save::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	s.Logger.Debug(op+"::Saved", zap.String("id", id), zap.Int("count", len(frames)))
+	s.Broadcast(ctx, s.BroadCaster, s.Logger, CanvasEvent{
+		Name:   CanvasEventFrames,
+		Canvas: *canvas,
+		Frames: frameDelays(frames),
+	})
+
+	return frames, nil
+}
+
+// applyToFrame a transformation of a frame other than the first, which is the canvas itself, touching the canvas;
+// the canvas is returned as seen on that frame. Frames are not merged as cells are, so this is not supported
+// along with Registers.
+func (s CanvasService) applyToFrame(ctx context.Context, op string, revision RevisionOp, id string, index int, transform func(canvas *Canvas) (touchedArea, error)) (*Canvas, error) {
+	if s.Registers != nil {
+		return nil, fmt.Errorf("%w: frames other than the first cannot be transformed when cell writes are merged", ErrNotSupported)
+	}
+
+	var transformed Canvas
+
+	var _, err = s.changeFrames(ctx, op, id, index, func(canvas *Canvas, frames []Frame) ([]Frame, error) {
+		transformed = *canvas
+		transformed.Content = frames[index].Content
+
+		var touched, err = transform(&transformed)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = s.checkLocks(ctx, id, touched); err != nil {
+			return nil, err
+		}
+
+		s.touch(ctx, canvas)
+		frames[index].Content = transformed.Content
+		transformed.UpdatedAt, transformed.UpdatedBy = canvas.UpdatedAt, canvas.UpdatedBy
+
+		return frames, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, revision, index, transformed)
+
+	return &transformed, nil
+}
+
+// deleteFrames of a canvas deleted for good, if any
+func (s CanvasService) deleteFrames(ctx context.Context, id string) {
+	if s.Frames == nil {
+		return
+	}
+
+	if err := s.Frames.Delete(ctx, id); err != nil {
+		s.Logger.Error("Frames::Delete::Failed", zap.String("id", id), zap.Error(err))
+	}
+}
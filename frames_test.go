@@ -0,0 +1,239 @@
+package ascanvas_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	mb "github.com/fluxynet/ascanvas/broadcaster/mocks"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
+	"github.com/fluxynet/ascanvas/repo/sequel"
+)
+
+func TestCanvasService_Frames(t *testing.T) {
+	var (
+		ctx = ascanvas.WithAuthor(context.Background(), "carol")
+		brd = &mb.CanvasBroadcaster{}
+		s   = ascanvas.CanvasService{
+			Repo:        rm.New(),
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Clock:       ascanvas.StaticClock(now),
+			Frames:      rm.NewFrames(),
+		}
+		canvas = ascanvas.Canvas{Id: "1", Name: "Blink", Content: "....", Width: 2, Height: 2}
+	)
+
+	brd.On("Broadcast", mock.Anything, mock.Anything).Return(nil)
+
+	if err := s.Repo.Create(ctx, canvas); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var wantFrames = func(name string, got []ascanvas.Frame, err error, want ...ascanvas.Frame) {
+		t.Helper()
+
+		if err != nil {
+			t.Fatalf("%s() error = %v", name, err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s() got = %v, want %v", name, got, want)
+		}
+	}
+
+	var got, err = s.ListFrames(ctx, "1")
+	wantFrames("ListFrames", got, err, ascanvas.Frame{Content: "....", Delay: 100})
+
+	got, err = s.DuplicateFrame(ctx, "1", 0)
+	wantFrames("DuplicateFrame", got, err, ascanvas.Frame{Content: "....", Delay: 100}, ascanvas.Frame{Content: "....", Delay: 100})
+
+	got, err = s.SetFrameDelay(ctx, "1", 1, ascanvas.FrameDelayArgs{Delay: 500})
+	wantFrames("SetFrameDelay", got, err, ascanvas.Frame{Content: "....", Delay: 100}, ascanvas.Frame{Content: "....", Delay: 500})
+
+	var drawn *ascanvas.Canvas
+	drawn, err = s.ApplyRectangle(ctx, "1", ascanvas.TransformRectangleArgs{Width: 1, Height: 1, Fill: "#", Frame: 1})
+	if err != nil {
+		t.Fatalf("ApplyRectangle() error = %v", err)
+	} else if drawn.Content != "#..." {
+		t.Errorf("ApplyRectangle() of frame got = %q, want %q", drawn.Content, "#...")
+	} else if !drawn.UpdatedAt.Equal(now) || drawn.UpdatedBy != "carol" {
+		t.Errorf("ApplyRectangle() of frame got updated at %s by %q, want %s by carol", drawn.UpdatedAt, drawn.UpdatedBy, now)
+	}
+
+	// drawing on a frame touches the canvas, leaving its content as is
+	if stored, err := s.Repo.Get(ctx, "1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	} else if stored.Content != "...." || !stored.UpdatedAt.Equal(now) || stored.UpdatedBy != "carol" {
+		t.Errorf("Get() after drawing on a frame got = %v, want %q touched by carol", stored, "....")
+	}
+
+	drawn, err = s.ApplyFloodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Start: ascanvas.Coordinates{X: 1}, Fill: "o", Frame: 1})
+	if err != nil {
+		t.Fatalf("ApplyFloodfill() error = %v", err)
+	} else if drawn.Content != "#ooo" {
+		t.Errorf("ApplyFloodfill() of frame got = %q, want %q", drawn.Content, "#ooo")
+	}
+
+	// changes to the canvas itself show on the first frame only
+	if _, err = s.ApplyRectangle(ctx, "1", ascanvas.TransformRectangleArgs{TopLeft: ascanvas.Coordinates{X: 1, Y: 1}, Width: 1, Height: 1, Fill: "x"}); err != nil {
+		t.Fatalf("ApplyRectangle() error = %v", err)
+	}
+
+	got, err = s.DuplicateFrame(ctx, "1", 1)
+	wantFrames(
+		"DuplicateFrame",
+		got,
+		err,
+		ascanvas.Frame{Content: "...x", Delay: 100},
+		ascanvas.Frame{Content: "#ooo", Delay: 500},
+		ascanvas.Frame{Content: "#ooo", Delay: 500},
+	)
+
+	got, err = s.DeleteFrame(ctx, "1", 1)
+	wantFrames("DeleteFrame", got, err, ascanvas.Frame{Content: "...x", Delay: 100}, ascanvas.Frame{Content: "#ooo", Delay: 500})
+
+	var want = []ascanvas.CanvasEventName{
+		ascanvas.CanvasEventFrames,
+		ascanvas.CanvasEventFrames,
+		ascanvas.CanvasEventFrames,
+		ascanvas.CanvasEventFrames,
+		ascanvas.CanvasEventUpdated,
+		ascanvas.CanvasEventFrames,
+		ascanvas.CanvasEventFrames,
+	}
+
+	if names := broadcast(brd); !reflect.DeepEqual(names, want) {
+		t.Errorf("broadcast got = %v, want %v", names, want)
+	}
+
+	// events tell delays of frames, which are listed for their content
+	var delays = []ascanvas.FrameDelay{{Index: 0, Delay: 100}, {Index: 1, Delay: 500}}
+	if last := brd.Calls[len(brd.Calls)-1].Arguments.Get(1).(ascanvas.CanvasEvent); !reflect.DeepEqual(last.Frames, delays) {
+		t.Errorf("frames of last event got = %v, want %v", last.Frames, delays)
+	}
+
+	if err = s.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if got, err = s.Frames.Get(ctx, "1"); err != nil || len(got) != 0 {
+		t.Errorf("frames of deleted canvas got = %v, %v, want none", got, err)
+	}
+}
+
+func TestCanvasService_Frames_Invalid(t *testing.T) {
+	var (
+		ctx = context.Background()
+		s   = ascanvas.CanvasService{
+			Repo:      rm.New(),
+			Logger:    zaptest.NewLogger(t),
+			Broadcast: ascanvas.SyncBroadcast,
+			Frames:    rm.NewFrames(),
+		}
+	)
+
+	if err := s.Repo.Create(ctx, ascanvas.Canvas{Id: "1", Content: ".", Width: 1, Height: 1}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		change  func() error
+		wantErr error
+	}{
+		{
+			name: "missing canvas",
+			change: func() error {
+				_, err := s.DuplicateFrame(ctx, "2", 0)
+				return err
+			},
+			wantErr: ascanvas.ErrNotFound,
+		},
+		{
+			name: "missing frame",
+			change: func() error {
+				_, err := s.DuplicateFrame(ctx, "1", 1)
+				return err
+			},
+			wantErr: ascanvas.ErrNotFound,
+		},
+		{
+			name: "negative frame",
+			change: func() error {
+				_, err := s.SetFrameDelay(ctx, "1", -1, ascanvas.FrameDelayArgs{Delay: 100})
+				return err
+			},
+			wantErr: ascanvas.ErrNotFound,
+		},
+		{
+			name: "delay too short",
+			change: func() error {
+				_, err := s.SetFrameDelay(ctx, "1", 0, ascanvas.FrameDelayArgs{Delay: 10})
+				return err
+			},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name: "delay too long",
+			change: func() error {
+				_, err := s.SetFrameDelay(ctx, "1", 0, ascanvas.FrameDelayArgs{Delay: 60001})
+				return err
+			},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name: "delete first frame",
+			change: func() error {
+				_, err := s.DeleteFrame(ctx, "1", 0)
+				return err
+			},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name: "transform missing frame",
+			change: func() error {
+				_, err := s.ApplyFloodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Fill: "x", Frame: 1})
+				return err
+			},
+			wantErr: ascanvas.ErrNotFound,
+		},
+		{
+			name: "transform negative frame",
+			change: func() error {
+				_, err := s.ApplyFloodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Fill: "x", Frame: -1})
+				return err
+			},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	s.Registers = &sequel.CellRegisters{}
+
+	if _, err := s.ApplyRectangle(ctx, "1", ascanvas.TransformRectangleArgs{Width: 1, Height: 1, Fill: "x", Frame: 1}); !errors.Is(err, ascanvas.ErrNotSupported) {
+		t.Errorf("ApplyRectangle() of a frame with registers error = %v, want ErrNotSupported", err)
+	}
+
+	s.Registers = nil
+	s.Frames = nil
+
+	if _, err := s.DuplicateFrame(ctx, "1", 0); !errors.Is(err, ascanvas.ErrNotSupported) {
+		t.Errorf("DuplicateFrame() without frames error = %v, want ErrNotSupported", err)
+	}
+
+	if got, err := s.ListFrames(ctx, "1"); err != nil || len(got) != 1 {
+		t.Errorf("ListFrames() without frames got = %v, %v, want the canvas only", got, err)
+	}
+}
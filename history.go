@@ -0,0 +1,230 @@
+package ascanvas
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RevisionOp is the change of a canvas which made a Revision
+type RevisionOp string
+
+const (
+	RevisionCreated   RevisionOp = "create"
+	RevisionRectangle RevisionOp = "rectangle"
+	RevisionFloodfill RevisionOp = "floodfill"
+	RevisionSync      RevisionOp = "sync"
+	RevisionRestored  RevisionOp = "restore"
+
+	// RevisionFrames is a change of frames other than drawing on them: duplicating or deleting one
+	RevisionFrames RevisionOp = "frames"
+)
+
+// Revision of a frame of a canvas, as recorded in its history after it was changed
+type Revision struct {
+	Op RevisionOp `json:"op"`
+
+	// Frame changed, the first one being the canvas itself; its content is empty when it was deleted
+	Frame   int    `json:"frame"`
+	Content string `json:"content"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+
+	At time.Time `json:"at"`
+	By string    `json:"by,omitempty"`
+}
+
+// CanvasHistory is an append-only log of revisions of canvases, kept after they are deleted until they are purged
+type CanvasHistory interface {
+	// Append a revision of a canvas
+	Append(ctx context.Context, id string, revision Revision) error
+
+	// List revisions of a canvas in the order they were appended; none when it has no history
+	List(ctx context.Context, id string) ([]Revision, error)
+
+	// Delete the history of a canvas
+	Delete(ctx context.Context, id string) error
+}
+
+const (
+	// DefaultTimelapseSpeed replays a minute of edits per second
+	DefaultTimelapseSpeed = 60
+
+	// MaxTimelapseSpeed replays a day of edits per second
+	MaxTimelapseSpeed = 86400
+
+	// MaxTimelapseDelay in milliseconds between revisions of a time-lapse, for long pauses not to be replayed
+	MaxTimelapseDelay = 2000
+)
+
+// TimelapseArgs to replay the history of a canvas
+type TimelapseArgs struct {
+	// Speed of the replay relative to the time edits took; DefaultTimelapseSpeed when 0
+	Speed float64 `json:"speed"`
+}
+
+// WithDefaults for zero values
+func (a TimelapseArgs) WithDefaults() TimelapseArgs {
+	if a.Speed == 0 {
+		a.Speed = DefaultTimelapseSpeed
+	}
+
+	return a
+}
+
+// Validate TimelapseArgs once defaults are applied
+func (a TimelapseArgs) Validate() error {
+	if a.Speed <= 0 || a.Speed > MaxTimelapseSpeed || math.IsNaN(a.Speed) {
+		return fmt.Errorf("%w: speed must be greater than 0 and at most %d", ErrInvalidInput, MaxTimelapseSpeed)
+	}
+
+	return nil
+}
+
+// Timelapse of a canvas, deleted or not, as frames replaying every revision of its first frame, the canvas itself,
+// at the speed of args; a revision is shown until the next one, for as long as it took to be made at that speed but
+// between MinFrameDelay and MaxTimelapseDelay. Revisions made before the canvas got its current size are left out.
+func (s CanvasService) Timelapse(ctx context.Context, id string, args TimelapseArgs) (*Canvas, []Frame, error) {
+	if s.History == nil {
+		return nil, nil, ErrNotSupported
+	}
+
+	args = args.WithDefaults()
+	if err := args.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	s.Logger.Debug("Timelapse::Fetching", zap.String("id", id))
+
+	var revisions, err = s.History.List(ctx, id)
+	if err != nil {
+		s.Logger.Error("Timelapse::Fetch::Failed", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var canvas = s.lastSeen(ctx, id)
+
+	// revisions of other frames would flicker between the contents of different frames
+	var shown []Revision
+	for _, r := range revisions {
+		if r.Frame != 0 {
+			continue
+		} else if r.Width != canvas.Width || r.Height != canvas.Height {
+			shown = shown[:0]
+			canvas.Width, canvas.Height = r.Width, r.Height
+		}
+
+		shown = append(shown, r)
+	}
+
+	if len(shown) == 0 {
+		s.Logger.Debug("Timelapse::NotFound", zap.String("id", id))
+		return nil, nil, fmt.Errorf("%w: no history of canvas %s", ErrNotFound, id)
+	}
+
+	var frames = make([]Frame, len(shown))
+
+	for i, r := range shown {
+		var delay = MaxTimelapseDelay
+		if i+1 < len(shown) {
+			delay = int(float64(shown[i+1].At.Sub(r.At).Milliseconds()) / args.Speed)
+		}
+
+		if delay < MinFrameDelay {
+			delay = MinFrameDelay
+		} else if delay > MaxTimelapseDelay {
+			delay = MaxTimelapseDelay
+		}
+
+		frames[i] = Frame{Content: r.Content, Delay: delay}
+	}
+
+	canvas.Content = frames[len(frames)-1].Content
+
+	s.Logger.Debug("Timelapse::Fetched", zap.String("id", id), zap.Int("revisions", len(frames)))
+
+	return &canvas, frames, nil
+}
+
+// ExportTimelapseCast of a canvas, as per Timelapse and WriteCast
+func (s CanvasService) ExportTimelapseCast(ctx context.Context, id string, args TimelapseArgs) ([]byte, error) {
+	var canvas, frames, err = s.Timelapse(ctx, id, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	if err = canvas.WriteCast(&b, frames); err != nil {
+		s.Logger.Debug("ExportTimelapseCast::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	return []byte(b.String()), nil
+}
+
+// ExportTimelapseGIF of a canvas, as per Timelapse and WriteGIF
+func (s CanvasService) ExportTimelapseGIF(ctx context.Context, id string, args TimelapseArgs, image ImageArgs) ([]byte, error) {
+	var canvas, frames, err = s.Timelapse(ctx, id, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	if err = canvas.WriteGIF(&b, frames, image); err != nil {
+		s.Logger.Debug("ExportTimelapseGIF::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	return []byte(b.String()), nil
+}
+
+// lastSeen canvas with an id, from the repository or the trash; only its id is known once purged
+func (s CanvasService) lastSeen(ctx context.Context, id string) Canvas {
+	if canvas, err := s.Repo.Get(ctx, id); err == nil {
+		return *canvas
+	}
+
+	if s.Trash != nil {
+		if deleted, err := s.Trash.Get(ctx, id); err == nil {
+			return deleted.Canvas
+		}
+	}
+
+	return Canvas{Id: id}
+}
+
+// record a revision of a frame of a canvas in its history, if any
+func (s CanvasService) record(ctx context.Context, op RevisionOp, frame int, canvas Canvas) {
+	if s.History == nil {
+		return
+	}
+
+	var revision = Revision{
+		Op:      op,
+		Frame:   frame,
+		Content: canvas.Content,
+		Width:   canvas.Width,
+		Height:  canvas.Height,
+		At:      s.now(),
+		By:      Author(ctx),
+	}
+
+	if err := s.History.Append(ctx, canvas.Id, revision); err != nil {
+		s.Logger.Error("History::Append::Failed", zap.String("id", canvas.Id), zap.Error(err))
+	}
+}
+
+// deleteHistory of a canvas purged for good, if any
+func (s CanvasService) deleteHistory(ctx context.Context, id string) {
+	if s.History == nil {
+		return
+	}
+
+	if err := s.History.Delete(ctx, id); err != nil {
+		s.Logger.Error("History::Delete::Failed", zap.String("id", id), zap.Error(err))
+	}
+}
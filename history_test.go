@@ -0,0 +1,221 @@
+package ascanvas_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	mb "github.com/fluxynet/ascanvas/broadcaster/mocks"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
+)
+
+func TestCanvasService_History(t *testing.T) {
+	var (
+		ctx   = ascanvas.WithAuthor(context.Background(), "carol")
+		clock = now
+		brd   = &mb.CanvasBroadcaster{}
+		s     = ascanvas.CanvasService{
+			Repo:        rm.New(),
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			GenerateID:  ascanvas.StaticUUIDGenerator("1", nil),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Frames:      rm.NewFrames(),
+			Trash:       rm.NewTrash(),
+			History:     rm.NewHistory(),
+			Clock: func() time.Time {
+				return clock
+			},
+		}
+		later = func(d time.Duration) {
+			clock = clock.Add(d)
+		}
+	)
+
+	brd.On("Broadcast", mock.Anything, mock.Anything).Return(nil)
+
+	if _, err := s.Create(ctx, ascanvas.CreateArgs{Name: "Blink", Width: 2, Height: 2, Fill: "."}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	later(10 * time.Second)
+	if _, err := s.ApplyRectangle(ctx, "1", ascanvas.TransformRectangleArgs{Width: 1, Height: 1, Fill: "#"}); err != nil {
+		t.Fatalf("ApplyRectangle() error = %v", err)
+	}
+
+	later(time.Second)
+	if _, err := s.ApplyFloodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Start: ascanvas.Coordinates{X: 1}, Fill: "o"}); err != nil {
+		t.Fatalf("ApplyFloodfill() error = %v", err)
+	}
+
+	later(time.Hour)
+	if _, err := s.DuplicateFrame(ctx, "1", 0); err != nil {
+		t.Fatalf("DuplicateFrame() error = %v", err)
+	}
+
+	later(time.Millisecond)
+	if _, err := s.ApplyRectangle(ctx, "1", ascanvas.TransformRectangleArgs{Width: 1, Height: 1, Fill: "x", Frame: 1}); err != nil {
+		t.Fatalf("ApplyRectangle() of frame error = %v", err)
+	}
+
+	later(time.Second)
+	if _, err := s.SetFrameDelay(ctx, "1", 1, ascanvas.FrameDelayArgs{Delay: 500}); err != nil {
+		t.Fatalf("SetFrameDelay() error = %v", err)
+	}
+
+	later(time.Second)
+	if _, err := s.DeleteFrame(ctx, "1", 1); err != nil {
+		t.Fatalf("DeleteFrame() error = %v", err)
+	}
+
+	var revisions, err = s.History.List(ctx, "1")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	var want = []ascanvas.Revision{
+		{Op: ascanvas.RevisionCreated, Content: "....", Width: 2, Height: 2, At: now, By: "carol"},
+		{Op: ascanvas.RevisionRectangle, Content: "#...", Width: 2, Height: 2, At: now.Add(10 * time.Second), By: "carol"},
+		{Op: ascanvas.RevisionFloodfill, Content: "#ooo", Width: 2, Height: 2, At: now.Add(11 * time.Second), By: "carol"},
+		{Op: ascanvas.RevisionFrames, Frame: 1, Content: "#ooo", Width: 2, Height: 2, At: now.Add(time.Hour + 11*time.Second), By: "carol"},
+		{Op: ascanvas.RevisionRectangle, Frame: 1, Content: "xooo", Width: 2, Height: 2, At: now.Add(time.Hour + 11*time.Second + time.Millisecond), By: "carol"},
+		{Op: ascanvas.RevisionFrames, Frame: 1, Width: 2, Height: 2, At: now.Add(time.Hour + 13*time.Second + time.Millisecond), By: "carol"},
+	}
+
+	if !reflect.DeepEqual(revisions, want) {
+		t.Errorf("revisions got = %v, want %v", revisions, want)
+	}
+
+	// deleted canvases can be replayed, until they are purged
+	if err = s.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	canvas, frames, err := s.Timelapse(ctx, "1", ascanvas.TimelapseArgs{Speed: 10})
+	if err != nil {
+		t.Fatalf("Timelapse() error = %v", err)
+	}
+
+	var wantFrames = []ascanvas.Frame{
+		{Content: "....", Delay: 1000},
+		{Content: "#...", Delay: 100},
+		{Content: "#ooo", Delay: ascanvas.MaxTimelapseDelay},
+	}
+
+	if !reflect.DeepEqual(frames, wantFrames) {
+		t.Errorf("Timelapse() frames got = %v, want %v", frames, wantFrames)
+	}
+
+	if canvas.Name != "Blink" || canvas.Width != 2 || canvas.Height != 2 {
+		t.Errorf("Timelapse() canvas got = %v, want Blink of 2x2", canvas)
+	}
+
+	cast, err := s.ExportTimelapseCast(ctx, "1", ascanvas.TimelapseArgs{})
+	if err != nil {
+		t.Fatalf("ExportTimelapseCast() error = %v", err)
+	}
+
+	// a header, a line per revision and the end of the recording
+	if lines := strings.Count(string(cast), "\n"); lines != 1+len(wantFrames)+1 {
+		t.Errorf("ExportTimelapseCast() got %d lines, want %d", lines, 1+len(wantFrames)+1)
+	}
+
+	if _, err = s.ExportTimelapseGIF(ctx, "1", ascanvas.TimelapseArgs{}, ascanvas.ImageArgs{}); err != nil {
+		t.Errorf("ExportTimelapseGIF() error = %v", err)
+	}
+
+	if err = s.Purge(ctx, "1"); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+
+	if _, _, err = s.Timelapse(ctx, "1", ascanvas.TimelapseArgs{}); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Timelapse() of purged canvas error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCanvasService_History_WithoutTrash(t *testing.T) {
+	var (
+		ctx = context.Background()
+		brd = &mb.CanvasBroadcaster{}
+		s   = ascanvas.CanvasService{
+			Repo:        rm.New(),
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			GenerateID:  ascanvas.StaticUUIDGenerator("1", nil),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Clock:       ascanvas.StaticClock(now),
+			History:     rm.NewHistory(),
+		}
+	)
+
+	brd.On("Broadcast", mock.Anything, mock.Anything).Return(nil)
+
+	if _, err := s.Import(ctx, ascanvas.ImportArgs{Name: "Cat", Text: "=^.^="}); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if err := s.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	// only the history of the canvas is left, from which its size is known
+	var canvas, frames, err = s.Timelapse(ctx, "1", ascanvas.TimelapseArgs{})
+	if err != nil {
+		t.Fatalf("Timelapse() error = %v", err)
+	}
+
+	var want = ascanvas.Canvas{Id: "1", Content: "=^.^=", Width: 5, Height: 1}
+	if !reflect.DeepEqual(*canvas, want) || len(frames) != 1 {
+		t.Errorf("Timelapse() got = %v, %v, want %v with one frame", canvas, frames, want)
+	}
+
+	if err = s.Purge(ctx, "1"); err != nil {
+		t.Fatalf("Purge() of history error = %v", err)
+	}
+
+	if err = s.Purge(ctx, "1"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Purge() again error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCanvasService_Timelapse_Invalid(t *testing.T) {
+	var (
+		ctx = context.Background()
+		s   = ascanvas.CanvasService{
+			Repo:    rm.New(),
+			Logger:  zaptest.NewLogger(t),
+			History: rm.NewHistory(),
+		}
+	)
+
+	tests := []struct {
+		name    string
+		args    ascanvas.TimelapseArgs
+		wantErr error
+	}{
+		{name: "no history", args: ascanvas.TimelapseArgs{}, wantErr: ascanvas.ErrNotFound},
+		{name: "negative speed", args: ascanvas.TimelapseArgs{Speed: -1}, wantErr: ascanvas.ErrInvalidInput},
+		{name: "speed too high", args: ascanvas.TimelapseArgs{Speed: ascanvas.MaxTimelapseSpeed + 1}, wantErr: ascanvas.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := s.Timelapse(ctx, "1", tt.args); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Timelapse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	s.History = nil
+
+	if _, _, err := s.Timelapse(ctx, "1", ascanvas.TimelapseArgs{}); !errors.Is(err, ascanvas.ErrNotSupported) {
+		t.Errorf("Timelapse() without history error = %v, want ErrNotSupported", err)
+	}
+}
@@ -0,0 +1,331 @@
+package ascanvas
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ImageFormat of canvases exported as images
+type ImageFormat string
+
+const (
+	// ImagePNG is the default format
+	ImagePNG ImageFormat = "png"
+
+	// ImageSVG draws the same pixels as ImagePNG, as vector shapes
+	ImageSVG ImageFormat = "svg"
+
+	// ImageGIF draws the same pixels as ImagePNG, for every frame of animated canvases
+	ImageGIF ImageFormat = "gif"
+)
+
+// ContentType of images in the format
+func (f ImageFormat) ContentType() string {
+	switch f {
+	case ImageSVG:
+		return "image/svg+xml"
+	case ImageGIF:
+		return "image/gif"
+	default:
+		return "image/png"
+	}
+}
+
+const (
+	DefaultCellWidth  = 12
+	DefaultCellHeight = 18
+	DefaultForeground = "#000000"
+	DefaultBackground = "#ffffff"
+
+	// MaxCellSize is the largest width or height of cells in pixels
+	MaxCellSize = 64
+
+	// MaxPadding around images in pixels
+	MaxPadding = 256
+
+	// MaxImagePixels is the largest image that can be exported, padding included
+	MaxImagePixels = 1 << 24
+)
+
+// ImageArgs to export a canvas as an image; zero values are replaced by defaults
+type ImageArgs struct {
+	Format ImageFormat `json:"format"`
+
+	// CellWidth and CellHeight of every character in pixels; glyphs are scaled by whole pixels to fit cells
+	CellWidth  int `json:"cell_width"`
+	CellHeight int `json:"cell_height"`
+
+	// Padding around the canvas in pixels
+	Padding int `json:"padding"`
+
+	// Foreground and Background colors, as #rgb or #rrggbb
+	Foreground string `json:"foreground"`
+	Background string `json:"background"`
+}
+
+// WithDefaults for zero values
+func (a ImageArgs) WithDefaults() ImageArgs {
+	if a.Format == "" {
+		a.Format = ImagePNG
+	}
+
+	if a.CellWidth == 0 {
+		a.CellWidth = DefaultCellWidth
+	}
+
+	if a.CellHeight == 0 {
+		a.CellHeight = DefaultCellHeight
+	}
+
+	if a.Foreground == "" {
+		a.Foreground = DefaultForeground
+	}
+
+	if a.Background == "" {
+		a.Background = DefaultBackground
+	}
+
+	return a
+}
+
+// Validate args, once defaults are applied
+func (a ImageArgs) Validate() error {
+	var errs []string
+
+	if a.Format != ImagePNG && a.Format != ImageSVG && a.Format != ImageGIF {
+		errs = append(errs, "Format must be png, svg or gif")
+	}
+
+	if a.CellWidth < fontWidth || a.CellWidth > MaxCellSize {
+		errs = append(errs, fmt.Sprintf("CellWidth must be between %d and %d", fontWidth, MaxCellSize))
+	}
+
+	if a.CellHeight < fontHeight || a.CellHeight > MaxCellSize {
+		errs = append(errs, fmt.Sprintf("CellHeight must be between %d and %d", fontHeight, MaxCellSize))
+	}
+
+	if a.Padding < 0 || a.Padding > MaxPadding {
+		errs = append(errs, fmt.Sprintf("Padding must be between 0 and %d", MaxPadding))
+	}
+
+	if _, err := parseColor(a.Foreground); err != nil {
+		errs = append(errs, "Foreground must be a color as #rgb or #rrggbb")
+	}
+
+	if _, err := parseColor(a.Background); err != nil {
+		errs = append(errs, "Background must be a color as #rgb or #rrggbb")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidInput, strings.Join(errs, ", "))
+}
+
+// parseColor as #rgb or #rrggbb
+func parseColor(s string) (color.RGBA, error) {
+	var c = color.RGBA{A: 0xff}
+
+	if len(s) == 4 && s[0] == '#' {
+		s = "#" + strings.Repeat(s[1:2], 2) + strings.Repeat(s[2:3], 2) + strings.Repeat(s[3:4], 2)
+	}
+
+	if len(s) != 7 || s[0] != '#' {
+		return c, fmt.Errorf("%w: invalid color %q", ErrInvalidInput, s)
+	}
+
+	var v, err = strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return c, fmt.Errorf("%w: invalid color %q", ErrInvalidInput, s)
+	}
+
+	c.R, c.G, c.B = uint8(v>>16), uint8(v>>8), uint8(v)
+
+	return c, nil
+}
+
+// rect of pixels
+type rect struct {
+	X, Y, W, H int
+}
+
+// bounds of the image of a canvas, padding included
+func (c Canvas) bounds(args ImageArgs) (int, int) {
+	return c.Width*args.CellWidth + 2*args.Padding, c.Height*args.CellHeight + 2*args.Padding
+}
+
+// glyphRects of the canvas, each a run of foreground pixels on a row of a glyph
+func (c Canvas) glyphRects(args ImageArgs) []rect {
+	var (
+		rects []rect
+		scale = args.CellWidth / (fontWidth + 1)
+	)
+
+	if s := args.CellHeight / (fontHeight + 2); s < scale {
+		scale = s
+	}
+
+	if scale < 1 {
+		scale = 1
+	}
+
+	// glyphs are centered in cells
+	var (
+		dx = (args.CellWidth - fontWidth*scale) / 2
+		dy = (args.CellHeight - fontHeight*scale) / 2
+	)
+
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			var p = y*c.Width + x
+			if p >= len(c.Content) {
+				return rects
+			}
+
+			var (
+				g    = glyph(c.Content[p])
+				left = args.Padding + x*args.CellWidth + dx
+				top  = args.Padding + y*args.CellHeight + dy
+			)
+
+			for row := 0; row < fontHeight; row++ {
+				for col := 0; col < fontWidth; col++ {
+					if g[col]&(1<<row) == 0 {
+						continue
+					}
+
+					var run = 1
+					for col+run < fontWidth && g[col+run]&(1<<row) != 0 {
+						run++
+					}
+
+					rects = append(rects, rect{X: left + col*scale, Y: top + row*scale, W: run * scale, H: scale})
+					col += run
+				}
+			}
+		}
+	}
+
+	return rects
+}
+
+// WriteImage of the canvas drawn with an embedded font, as per args once defaults are applied
+func (c Canvas) WriteImage(w io.Writer, args ImageArgs) error {
+	args = args.WithDefaults()
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	if width, height := c.bounds(args); width*height > MaxImagePixels {
+		return fmt.Errorf("%w: image of %dx%d pixels is too large, use smaller cells", ErrInvalidInput, width, height)
+	}
+
+	switch args.Format {
+	case ImageSVG:
+		return c.writeSVG(w, args)
+	case ImageGIF:
+		return c.WriteGIF(w, []Frame{{Content: c.Content, Delay: DefaultFrameDelay}}, args)
+	default:
+		return c.writePNG(w, args)
+	}
+}
+
+func (c Canvas) writePNG(w io.Writer, args ImageArgs) error {
+	return png.Encode(w, c.paletted(args))
+}
+
+// paletted image of the canvas, with the background as first color of its palette and the foreground as second
+func (c Canvas) paletted(args ImageArgs) *image.Paletted {
+	var (
+		fg, _         = parseColor(args.Foreground)
+		bg, _         = parseColor(args.Background)
+		width, height = c.bounds(args)
+		img           = image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{bg, fg})
+	)
+
+	for _, r := range c.glyphRects(args) {
+		for y := r.Y; y < r.Y+r.H; y++ {
+			for x := r.X; x < r.X+r.W; x++ {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	return img
+}
+
+func (c Canvas) writeSVG(w io.Writer, args ImageArgs) error {
+	var (
+		fg, _         = parseColor(args.Foreground)
+		bg, _         = parseColor(args.Background)
+		width, height = c.bounds(args)
+		b             = bufio.NewWriter(w)
+	)
+
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n", width, height, width, height)
+
+	b.WriteString("<title>")
+	_ = xml.EscapeText(b, []byte(c.Name))
+	b.WriteString("</title>\n")
+
+	fmt.Fprintf(b, `<rect width="%d" height="%d" fill="#%02x%02x%02x"/>`+"\n", width, height, bg.R, bg.G, bg.B)
+
+	if rects := c.glyphRects(args); len(rects) != 0 {
+		fmt.Fprintf(b, `<path fill="#%02x%02x%02x" d="`, fg.R, fg.G, fg.B)
+
+		for _, r := range rects {
+			fmt.Fprintf(b, "M%d %dh%dv%dh-%dz", r.X, r.Y, r.W, r.H, r.W)
+		}
+
+		b.WriteString("\"/>\n")
+	}
+
+	b.WriteString("</svg>\n")
+
+	return b.Flush()
+}
+
+// ExportImage of a canvas, as per args once defaults are applied; gif images show all frames of animated canvases
+func (s CanvasService) ExportImage(ctx context.Context, id string, args ImageArgs) ([]byte, error) {
+	args = args.WithDefaults()
+	s.Logger.Debug("ExportImage::Validating", zap.String("id", id), zap.Any("args", args))
+
+	if err := args.Validate(); err != nil {
+		s.Logger.Debug("ExportImage::Invalid", zap.Error(err))
+		return nil, err
+	}
+
+	var canvas, frames, err = s.frames(ctx, id)
+	if err != nil {
+		s.Logger.Debug("ExportImage::GetFailed", zap.Error(err))
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if args.Format == ImageGIF {
+		err = canvas.WriteGIF(&b, frames, args)
+	} else {
+		err = canvas.WriteImage(&b, args)
+	}
+
+	if err != nil {
+		s.Logger.Debug("ExportImage::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	s.Logger.Debug("ExportImage::Exported", zap.Int("bytes", b.Len()))
+
+	return b.Bytes(), nil
+}
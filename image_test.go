@@ -0,0 +1,221 @@
+package ascanvas_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// golden compares got with the content of a file in testdata, or overwrites it with -update
+func golden(t *testing.T, name string, got []byte) []byte {
+	t.Helper()
+
+	var path = filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("writing %s error = %v", path, err)
+		}
+	}
+
+	var want, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s error = %v", path, err)
+	}
+
+	return want
+}
+
+var imageCanvas = ascanvas.Canvas{
+	Id:      "1",
+	Name:    "Hello <world> & co",
+	Content: "+---+|Hi!|+---+",
+	Width:   5,
+	Height:  3,
+}
+
+func TestCanvas_WriteImage(t *testing.T) {
+	tests := []struct {
+		name   string
+		canvas ascanvas.Canvas
+		args   ascanvas.ImageArgs
+		golden string
+	}{
+		{
+			name:   "png defaults",
+			canvas: imageCanvas,
+			golden: "image_default.png",
+		},
+		{
+			name:   "png custom",
+			canvas: imageCanvas,
+			args: ascanvas.ImageArgs{
+				Format:     ascanvas.ImagePNG,
+				CellWidth:  6,
+				CellHeight: 9,
+				Padding:    4,
+				Foreground: "#0f0",
+				Background: "#202020",
+			},
+			golden: "image_custom.png",
+		},
+		{
+			name:   "svg defaults",
+			canvas: imageCanvas,
+			args:   ascanvas.ImageArgs{Format: ascanvas.ImageSVG},
+			golden: "image_default.svg",
+		},
+		{
+			name:   "svg custom",
+			canvas: imageCanvas,
+			args: ascanvas.ImageArgs{
+				Format:     ascanvas.ImageSVG,
+				CellWidth:  6,
+				CellHeight: 9,
+				Padding:    4,
+				Foreground: "#0f0",
+				Background: "#202020",
+			},
+			golden: "image_custom.svg",
+		},
+		{
+			name:   "svg short content",
+			canvas: ascanvas.Canvas{Name: "Short", Content: "ab\x01", Width: 2, Height: 2},
+			args:   ascanvas.ImageArgs{Format: ascanvas.ImageSVG, CellWidth: 6, CellHeight: 9},
+			golden: "image_short.svg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := tt.canvas.WriteImage(&b, tt.args); err != nil {
+				t.Fatalf("WriteImage() error = %v", err)
+			}
+
+			var want = golden(t, tt.golden, b.Bytes())
+
+			if filepath.Ext(tt.golden) == ".svg" {
+				if !bytes.Equal(b.Bytes(), want) {
+					t.Errorf("WriteImage() got = %s, want %s", b.Bytes(), want)
+				}
+
+				return
+			}
+
+			// png encoding may change between go versions, pixels may not
+			if !samePixels(t, b.Bytes(), want) {
+				t.Errorf("WriteImage() pixels differ from %s", tt.golden)
+			}
+		})
+	}
+}
+
+func samePixels(t *testing.T, got, want []byte) bool {
+	t.Helper()
+
+	var g, err = png.Decode(bytes.NewReader(got))
+	if err != nil {
+		t.Fatalf("decoding png error = %v", err)
+	}
+
+	var w image.Image
+	if w, err = png.Decode(bytes.NewReader(want)); err != nil {
+		t.Fatalf("decoding golden png error = %v", err)
+	}
+
+	if g.Bounds() != w.Bounds() {
+		return false
+	}
+
+	for y := g.Bounds().Min.Y; y < g.Bounds().Max.Y; y++ {
+		for x := g.Bounds().Min.X; x < g.Bounds().Max.X; x++ {
+			var r1, g1, b1, a1 = g.At(x, y).RGBA()
+			var r2, g2, b2, a2 = w.At(x, y).RGBA()
+
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func TestCanvas_WriteImage_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		canvas ascanvas.Canvas
+		args   ascanvas.ImageArgs
+	}{
+		{name: "format", canvas: imageCanvas, args: ascanvas.ImageArgs{Format: "bmp"}},
+		{name: "cell too narrow", canvas: imageCanvas, args: ascanvas.ImageArgs{CellWidth: 4}},
+		{name: "cell too short", canvas: imageCanvas, args: ascanvas.ImageArgs{CellHeight: 6}},
+		{name: "cell too large", canvas: imageCanvas, args: ascanvas.ImageArgs{CellWidth: 65}},
+		{name: "negative padding", canvas: imageCanvas, args: ascanvas.ImageArgs{Padding: -1}},
+		{name: "foreground", canvas: imageCanvas, args: ascanvas.ImageArgs{Foreground: "red"}},
+		{name: "background", canvas: imageCanvas, args: ascanvas.ImageArgs{Background: "#12345g"}},
+		{
+			name:   "too large",
+			canvas: ascanvas.Canvas{Width: 1000, Height: 1000},
+			args:   ascanvas.ImageArgs{CellWidth: 64, CellHeight: 64},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := tt.canvas.WriteImage(&b, tt.args); !errors.Is(err, ascanvas.ErrInvalidInput) {
+				t.Errorf("WriteImage() error = %v, want ErrInvalidInput", err)
+			}
+
+			if b.Len() != 0 {
+				t.Errorf("WriteImage() wrote %d bytes, want none", b.Len())
+			}
+		})
+	}
+}
+
+func TestCanvasService_ExportImage(t *testing.T) {
+	var (
+		ctx = context.Background()
+		s   = ascanvas.CanvasService{
+			Repo:   rm.New(),
+			Logger: zaptest.NewLogger(t),
+		}
+	)
+
+	if err := s.Repo.Create(ctx, imageCanvas); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var got, err = s.ExportImage(ctx, imageCanvas.Id, ascanvas.ImageArgs{Format: ascanvas.ImageSVG})
+	if err != nil {
+		t.Fatalf("ExportImage() error = %v", err)
+	}
+
+	if want := golden(t, "image_default.svg", got); !bytes.Equal(got, want) {
+		t.Errorf("ExportImage() got = %s, want %s", got, want)
+	}
+
+	if _, err = s.ExportImage(ctx, "missing", ascanvas.ImageArgs{}); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("ExportImage() of missing canvas error = %v, want ErrNotFound", err)
+	}
+
+	if _, err = s.ExportImage(ctx, imageCanvas.Id, ascanvas.ImageArgs{Format: "bmp"}); !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("ExportImage() with invalid args error = %v, want ErrInvalidInput", err)
+	}
+}
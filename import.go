@@ -0,0 +1,142 @@
+package ascanvas
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxImportWidth is the longest line of imported text, once tabs are expanded
+	MaxImportWidth = 1024
+
+	// MaxImportHeight is the largest number of lines of imported text
+	MaxImportHeight = 1024
+
+	// TabWidth is the distance between tab stops when tabs of imported text are expanded into spaces
+	TabWidth = 8
+)
+
+// ImportArgs to create a canvas from text, one row per line
+type ImportArgs struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+
+	// Fill pads lines shorter than the longest one; a space by default
+	Fill string `json:"fill"`
+}
+
+// AsLogFields is a helper for logging
+func (a ImportArgs) AsLogFields() []zap.Field {
+	return []zap.Field{
+		zap.String("Name", a.Name),
+		zap.Int("Text", len(a.Text)),
+		zap.String("Fill", a.Fill),
+	}
+}
+
+// Validate args, except for the text that is checked by ParseText
+func (a ImportArgs) Validate() error {
+	var errs []string
+
+	if a.Name == "" {
+		errs = append(errs, "name cannot be empty")
+	}
+
+	if len(a.Fill) != 1 || printable(a.Fill[0]) != a.Fill[0] {
+		errs = append(errs, "fill must be exactly one printable character")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidInput, strings.Join(errs, ", "))
+}
+
+// ParseText into the content of a canvas, one row per line; the final line break is optional, tabs are expanded
+// into spaces and lines shorter than the longest one are padded with fill
+func ParseText(text string, fill byte) (Canvas, error) {
+	var canvas Canvas
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+
+	if text == "" {
+		return canvas, fmt.Errorf("%w: text cannot be empty", ErrInvalidInput)
+	}
+
+	var lines = strings.Split(text, "\n")
+	if len(lines) > MaxImportHeight {
+		return canvas, fmt.Errorf("%w: text cannot have more than %d lines", ErrInvalidInput, MaxImportHeight)
+	}
+
+	for i := range lines {
+		var b strings.Builder
+
+		for j := 0; j < len(lines[i]); j++ {
+			var c = lines[i][j]
+
+			switch {
+			case c == '\t':
+				b.WriteString(strings.Repeat(" ", TabWidth-b.Len()%TabWidth))
+			case printable(c) != c:
+				return canvas, fmt.Errorf("%w: line %d has characters that are not printable ascii", ErrInvalidInput, i+1)
+			default:
+				b.WriteByte(c)
+			}
+
+			if b.Len() > MaxImportWidth {
+				return canvas, fmt.Errorf("%w: line %d is longer than %d characters", ErrInvalidInput, i+1, MaxImportWidth)
+			}
+		}
+
+		lines[i] = b.String()
+		if len(lines[i]) > canvas.Width {
+			canvas.Width = len(lines[i])
+		}
+	}
+
+	if canvas.Width == 0 {
+		return canvas, fmt.Errorf("%w: text cannot be blank lines only", ErrInvalidInput)
+	}
+
+	var b strings.Builder
+	b.Grow(canvas.Width * len(lines))
+
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteString(strings.Repeat(string(fill), canvas.Width-len(line)))
+	}
+
+	canvas.Content = b.String()
+	canvas.Height = len(lines)
+
+	return canvas, nil
+}
+
+// Import a new Canvas from text, as parsed by ParseText
+func (s CanvasService) Import(ctx context.Context, args ImportArgs) (*Canvas, error) {
+	if args.Fill == "" {
+		args.Fill = " "
+	}
+
+	s.Logger.Debug("Import::Validating", args.AsLogFields()...)
+
+	if err := args.Validate(); err != nil {
+		s.Logger.Debug("Import::Validate::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	var canvas, err = ParseText(args.Text, args.Fill[0])
+	if err != nil {
+		s.Logger.Debug("Import::Parse::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	canvas.Name = args.Name
+
+	return s.create(ctx, canvas)
+}
@@ -0,0 +1,165 @@
+package ascanvas_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	mb "github.com/fluxynet/ascanvas/broadcaster/mocks"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
+)
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		fill    byte
+		want    ascanvas.Canvas
+		wantErr error
+	}{
+		{
+			name: "rectangle",
+			text: "ab\ncd\n",
+			fill: ' ',
+			want: ascanvas.Canvas{Content: "abcd", Width: 2, Height: 2},
+		},
+		{
+			name: "without final line break",
+			text: "ab\ncd",
+			fill: ' ',
+			want: ascanvas.Canvas{Content: "abcd", Width: 2, Height: 2},
+		},
+		{
+			name: "crlf",
+			text: "ab\r\ncd\r\n",
+			fill: ' ',
+			want: ascanvas.Canvas{Content: "abcd", Width: 2, Height: 2},
+		},
+		{
+			name: "ragged",
+			text: "a\n\nabc\nab\n",
+			fill: '.',
+			want: ascanvas.Canvas{Content: "a.....abcab.", Width: 3, Height: 4},
+		},
+		{
+			name: "blank lines are kept",
+			text: "\n x\n\n",
+			fill: ' ',
+			want: ascanvas.Canvas{Content: "   x  ", Width: 2, Height: 3},
+		},
+		{
+			name: "tabs",
+			text: "\tx\nab\tc\n",
+			fill: '-',
+			want: ascanvas.Canvas{Content: "        xab      c", Width: 9, Height: 2},
+		},
+		{
+			name:    "empty",
+			text:    "\n",
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "blank",
+			text:    "\n\n\n",
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "not printable",
+			text:    "ab\n\x1b[2J\n",
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "not ascii",
+			text:    "café",
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "too wide",
+			text:    strings.Repeat("x", ascanvas.MaxImportWidth+1),
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "too wide with tabs",
+			text:    strings.Repeat("\t", ascanvas.MaxImportWidth/ascanvas.TabWidth+1),
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "too high",
+			text:    strings.Repeat("x\n", ascanvas.MaxImportHeight+1),
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, err = ascanvas.ParseText(tt.text, tt.fill)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseText() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanvasService_Import(t *testing.T) {
+	var (
+		ctx = ascanvas.WithAuthor(context.Background(), "alice")
+		brd = &mb.CanvasBroadcaster{}
+		s   = ascanvas.CanvasService{
+			Repo:        rm.New(),
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			GenerateID:  ascanvas.StaticUUIDGenerator("1", nil),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Clock:       ascanvas.StaticClock(now),
+		}
+		want = ascanvas.Canvas{
+			Id:        "1",
+			Name:      "Arrow",
+			Content:   "-->>**",
+			Width:     3,
+			Height:    2,
+			CreatedAt: now,
+			UpdatedAt: now,
+			CreatedBy: "alice",
+			UpdatedBy: "alice",
+		}
+	)
+
+	brd.On("Broadcast", mock.Anything, ascanvas.CanvasEvent{Name: ascanvas.CanvasEventCreated, Canvas: want}).Return(nil)
+
+	var got, err = s.Import(ctx, ascanvas.ImportArgs{Name: "Arrow", Text: "-->\n>\n", Fill: "*"})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	} else if !reflect.DeepEqual(*got, want) {
+		t.Errorf("Import() got = %v, want %v", *got, want)
+	}
+
+	if stored, err := s.Repo.Get(ctx, "1"); err != nil || !reflect.DeepEqual(*stored, want) {
+		t.Errorf("Get() got = %v, %v, want %v", stored, err, want)
+	}
+
+	brd.AssertExpectations(t)
+
+	var invalid = []ascanvas.ImportArgs{
+		{Text: "x"},
+		{Name: "Fill", Text: "x", Fill: "ab"},
+		{Name: "Fill", Text: "x", Fill: "\t"},
+		{Name: "Text"},
+	}
+
+	for _, args := range invalid {
+		if _, err = s.Import(ctx, args); !errors.Is(err, ascanvas.ErrInvalidInput) {
+			t.Errorf("Import(%v) error = %v, want ErrInvalidInput", args, err)
+		}
+	}
+}
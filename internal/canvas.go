@@ -7,21 +7,16 @@ import (
 	"github.com/fluxynet/ascanvas"
 )
 
+// CanvasFromText of a fixture as parsed by ascanvas.ParseText, once the line breaks it starts or ends with are trimmed;
+// it panics on text ParseText rejects
 func CanvasFromText(id, name string, s string) *ascanvas.Canvas {
-	var canvas = ascanvas.Canvas{
-		Id:   id,
-		Name: name,
+	var canvas, err = ascanvas.ParseText(strings.Trim(s, "\n"), ' ')
+	if err != nil {
+		panic(err)
 	}
 
-	s = strings.Trim(s, "\n")
-	l := strings.Split(s, "\n")
-
-	canvas.Height = len(l)
-	if canvas.Height > 0 {
-		canvas.Width = len(l[0])
-	}
-
-	canvas.Content = strings.Join(l, "")
+	canvas.Id = id
+	canvas.Name = name
 
 	return &canvas
 }
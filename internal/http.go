@@ -47,6 +47,10 @@ func (h HttpTest) Assert(t *testing.T, handler http.HandlerFunc) bool {
 		return false
 	}
 
+	for k := range h.Request.Header {
+		r.Header[k] = h.Request.Header[k]
+	}
+
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, r)
@@ -0,0 +1,284 @@
+package ascanvas
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ListSort is the order of canvases listed
+type ListSort string
+
+const (
+	// SortID is the default order
+	SortID ListSort = "id"
+
+	// SortName orders by name, then id
+	SortName ListSort = "name"
+
+	// SortCreated orders by time of creation, then id
+	SortCreated ListSort = "created"
+
+	// SortUpdated orders by time of last update, then id
+	SortUpdated ListSort = "updated"
+)
+
+// MaxListLimit is the largest page of canvases that can be requested
+const MaxListLimit = 500
+
+// ListArgs to list a page of canvases
+type ListArgs struct {
+	// Cursor is the Next of the previous page; empty for the first page
+	Cursor string `json:"cursor"`
+
+	// Limit is the size of a page; all canvases are listed when 0
+	Limit int `json:"limit"`
+
+	// Name filters canvases whose name contains it, ignoring case
+	Name string `json:"name"`
+
+	// Prefix filters canvases whose name starts with it, ignoring case
+	Prefix string `json:"prefix"`
+
+	Sort ListSort `json:"sort"`
+	Desc bool     `json:"desc"`
+
+	// Summary leaves out the Content of canvases
+	Summary bool `json:"summary"`
+}
+
+func (a ListArgs) Validate() error {
+	var errs []string
+
+	if a.Limit < 0 {
+		errs = append(errs, "Limit cannot be negative")
+	} else if a.Limit > MaxListLimit {
+		errs = append(errs, fmt.Sprintf("Limit cannot exceed %d", MaxListLimit))
+	}
+
+	switch a.Sort {
+	case "", SortID, SortName, SortCreated, SortUpdated:
+	default:
+		errs = append(errs, "Sort must be one of id, name, created, updated")
+	}
+
+	if a.Cursor != "" {
+		if c, err := DecodeListCursor(a.Cursor); err != nil {
+			errs = append(errs, "Cursor is not valid")
+		} else if c.Sort != a.sort() || c.Desc != a.Desc {
+			errs = append(errs, "Cursor does not match Sort")
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidInput, strings.Join(errs, ", "))
+}
+
+// sort is Sort with its default
+func (a ListArgs) sort() ListSort {
+	if a.Sort == "" {
+		return SortID
+	}
+
+	return a.Sort
+}
+
+// Matches tells if a canvas passes the filters
+func (a ListArgs) Matches(c Canvas) bool {
+	var name = strings.ToLower(c.Name)
+
+	return strings.Contains(name, strings.ToLower(a.Name)) && strings.HasPrefix(name, strings.ToLower(a.Prefix))
+}
+
+// ListCursor is the position after the last canvas of a page, carried opaquely by ListArgs.Cursor
+type ListCursor struct {
+	Sort ListSort `json:"s"`
+	Desc bool     `json:"d,omitempty"`
+	Id   string   `json:"i"`
+	Name string   `json:"n,omitempty"`
+	At   int64    `json:"a,omitempty"`
+}
+
+// Encode the cursor for ListArgs.Cursor
+func (c ListCursor) Encode() string {
+	var b, _ = json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeListCursor from ListArgs.Cursor
+func DecodeListCursor(s string) (ListCursor, error) {
+	var c ListCursor
+
+	var b, err = base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+
+	if err != nil {
+		return c, fmt.Errorf("%w: invalid cursor", ErrInvalidInput)
+	}
+
+	return c, nil
+}
+
+// CanvasPage is a page of canvases listed
+type CanvasPage struct {
+	Canvases []Canvas `json:"canvases"`
+
+	// Next is the cursor of the following page; empty on the last one
+	Next string `json:"next,omitempty"`
+}
+
+// CanvasPager is implemented by repositories able to list pages of canvases by themselves;
+// other repositories are paged with Paginate
+type CanvasPager interface {
+	ListPage(ctx context.Context, args ListArgs) (*CanvasPage, error)
+}
+
+// CanvasSummary is a Canvas without its Content
+type CanvasSummary struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// Summary of the Canvas
+func (c Canvas) Summary() CanvasSummary {
+	return CanvasSummary{
+		Id:     c.Id,
+		Name:   c.Name,
+		Width:  c.Width,
+		Height: c.Height,
+	}
+}
+
+// Paginate canvases in memory, as per args
+func Paginate(canvases []Canvas, args ListArgs) (*CanvasPage, error) {
+	var (
+		s        = args.sort()
+		filtered = make([]Canvas, 0, len(canvases))
+		less     func(a, b Canvas) bool
+	)
+
+	switch s {
+	case SortID:
+		less = func(a, b Canvas) bool {
+			return a.Id < b.Id
+		}
+	case SortName:
+		less = func(a, b Canvas) bool {
+			return a.Name < b.Name || (a.Name == b.Name && a.Id < b.Id)
+		}
+	case SortCreated:
+		less = func(a, b Canvas) bool {
+			var x, y = UnixNano(a.CreatedAt), UnixNano(b.CreatedAt)
+			return x < y || (x == y && a.Id < b.Id)
+		}
+	case SortUpdated:
+		less = func(a, b Canvas) bool {
+			var x, y = UnixNano(a.UpdatedAt), UnixNano(b.UpdatedAt)
+			return x < y || (x == y && a.Id < b.Id)
+		}
+	default:
+		return nil, fmt.Errorf("%w: sorting by %s", ErrInvalidInput, s)
+	}
+
+	if args.Desc {
+		var asc = less
+		less = func(a, b Canvas) bool {
+			return asc(b, a)
+		}
+	}
+
+	var after *Canvas
+	if args.Cursor != "" {
+		var c, err = DecodeListCursor(args.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		after = &Canvas{Id: c.Id, Name: c.Name, CreatedAt: FromUnixNano(c.At), UpdatedAt: FromUnixNano(c.At)}
+	}
+
+	for i := range canvases {
+		if args.Matches(canvases[i]) && (after == nil || less(*after, canvases[i])) {
+			filtered = append(filtered, canvases[i])
+		}
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return less(filtered[i], filtered[j])
+	})
+
+	var page = &CanvasPage{Canvases: filtered}
+
+	if args.Limit > 0 && len(filtered) > args.Limit {
+		page.Canvases = filtered[:args.Limit]
+		page.Next = CursorAfter(page.Canvases[args.Limit-1], args)
+	}
+
+	if args.Summary {
+		for i := range page.Canvases {
+			page.Canvases[i].Content = ""
+		}
+	}
+
+	return page, nil
+}
+
+// CursorAfter a canvas, for the page following it; the position is taken from the canvas as per the sort of args
+func CursorAfter(c Canvas, args ListArgs) string {
+	var cursor = ListCursor{Sort: args.sort(), Desc: args.Desc, Id: c.Id}
+
+	switch cursor.Sort {
+	case SortName:
+		cursor.Name = c.Name
+	case SortCreated:
+		cursor.At = UnixNano(c.CreatedAt)
+	case SortUpdated:
+		cursor.At = UnixNano(c.UpdatedAt)
+	}
+
+	return cursor.Encode()
+}
+
+// ListPage of canvases, filtered and sorted
+func (s CanvasService) ListPage(ctx context.Context, args ListArgs) (*CanvasPage, error) {
+	s.Logger.Debug("ListPage::Validating", zap.Any("args", args))
+
+	if err := args.Validate(); err != nil {
+		s.Logger.Debug("ListPage::Invalid", zap.Error(err))
+		return nil, err
+	}
+
+	var (
+		page *CanvasPage
+		err  error
+	)
+
+	if pager, ok := s.Repo.(CanvasPager); ok {
+		page, err = pager.ListPage(ctx, args)
+	} else {
+		var canvases []Canvas
+		if canvases, err = s.Repo.List(ctx); err == nil {
+			page, err = Paginate(canvases, args)
+		}
+	}
+
+	if err == nil {
+		s.Logger.Debug("ListPage::Fetched", zap.Int("count", len(page.Canvases)), zap.String("next", page.Next))
+	} else {
+		s.Logger.Error("ListPage::Failed", zap.Error(err))
+	}
+
+	return page, err
+}
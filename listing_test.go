@@ -0,0 +1,196 @@
+package ascanvas_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// minutes after an arbitrary time
+func minutes(m int) time.Time {
+	return time.Date(2021, 11, 1, 12, m, 0, 0, time.UTC)
+}
+
+var listed = []ascanvas.Canvas{
+	{Id: "1", Name: "Banana", Content: "b", Width: 1, Height: 1, CreatedAt: minutes(3), UpdatedAt: minutes(10)},
+	{Id: "2", Name: "apple", Content: "a", Width: 1, Height: 1, CreatedAt: minutes(1), UpdatedAt: minutes(10)},
+	{Id: "3", Name: "Cherry pie", Content: "c", Width: 1, Height: 1, CreatedAt: minutes(5), UpdatedAt: minutes(6)},
+	{Id: "4", Name: "Apple pie", Content: "p", Width: 1, Height: 1, CreatedAt: minutes(2), UpdatedAt: minutes(10)},
+	{Id: "5", Name: "Banana", Content: "B", Width: 1, Height: 1, CreatedAt: minutes(4), UpdatedAt: minutes(10)},
+}
+
+// ids of all pages of canvases, following cursors
+func ids(t *testing.T, args ascanvas.ListArgs) [][]string {
+	var pages [][]string
+
+	for i := 0; i < 10; i++ {
+		if err := args.Validate(); err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+
+		var page, err = ascanvas.Paginate(listed, args)
+		if err != nil {
+			t.Fatalf("Paginate() error = %v", err)
+		}
+
+		var p = []string{}
+		for _, c := range page.Canvases {
+			p = append(p, c.Id)
+		}
+
+		pages = append(pages, p)
+
+		if page.Next == "" {
+			return pages
+		}
+
+		args.Cursor = page.Next
+	}
+
+	t.Fatalf("too many pages")
+	return nil
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name string
+		args ascanvas.ListArgs
+		want [][]string
+	}{
+		{
+			name: "all",
+			args: ascanvas.ListArgs{},
+			want: [][]string{{"1", "2", "3", "4", "5"}},
+		},
+		{
+			name: "pages by id",
+			args: ascanvas.ListArgs{Limit: 2},
+			want: [][]string{{"1", "2"}, {"3", "4"}, {"5"}},
+		},
+		{
+			name: "exact pages",
+			args: ascanvas.ListArgs{Limit: 5},
+			want: [][]string{{"1", "2", "3", "4", "5"}},
+		},
+		{
+			name: "pages by name, ties by id",
+			args: ascanvas.ListArgs{Limit: 2, Sort: ascanvas.SortName},
+			want: [][]string{{"4", "1"}, {"5", "3"}, {"2"}},
+		},
+		{
+			name: "pages by name desc",
+			args: ascanvas.ListArgs{Limit: 3, Sort: ascanvas.SortName, Desc: true},
+			want: [][]string{{"2", "3", "5"}, {"1", "4"}},
+		},
+		{
+			name: "pages by creation",
+			args: ascanvas.ListArgs{Limit: 2, Sort: ascanvas.SortCreated},
+			want: [][]string{{"2", "4"}, {"1", "5"}, {"3"}},
+		},
+		{
+			name: "pages by update desc, ties by id",
+			args: ascanvas.ListArgs{Limit: 3, Sort: ascanvas.SortUpdated, Desc: true},
+			want: [][]string{{"5", "4", "2"}, {"1", "3"}},
+		},
+		{
+			name: "name contains, ignoring case",
+			args: ascanvas.ListArgs{Name: "PIE"},
+			want: [][]string{{"3", "4"}},
+		},
+		{
+			name: "name prefix, ignoring case",
+			args: ascanvas.ListArgs{Prefix: "apple", Limit: 1},
+			want: [][]string{{"2"}, {"4"}},
+		},
+		{
+			name: "no match",
+			args: ascanvas.ListArgs{Prefix: "pie"},
+			want: [][]string{{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(t, tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pages got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate_Summary(t *testing.T) {
+	var page, err = ascanvas.Paginate(listed, ascanvas.ListArgs{Summary: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("Paginate() error = %v", err)
+	}
+
+	var want = []ascanvas.Canvas{{Id: "1", Name: "Banana", Width: 1, Height: 1, CreatedAt: minutes(3), UpdatedAt: minutes(10)}}
+	if !reflect.DeepEqual(page.Canvases, want) {
+		t.Errorf("Paginate() got = %v, want %v", page.Canvases, want)
+	}
+
+	if listed[0].Content != "b" {
+		t.Errorf("Paginate() modified the canvases given")
+	}
+}
+
+func TestCursorAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		args ascanvas.ListArgs
+		want ascanvas.ListCursor
+	}{
+		{name: "by id", args: ascanvas.ListArgs{}, want: ascanvas.ListCursor{Sort: ascanvas.SortID, Id: "3"}},
+		{name: "by name", args: ascanvas.ListArgs{Sort: ascanvas.SortName, Desc: true}, want: ascanvas.ListCursor{Sort: ascanvas.SortName, Desc: true, Id: "3", Name: "Cherry pie"}},
+		{name: "by creation", args: ascanvas.ListArgs{Sort: ascanvas.SortCreated}, want: ascanvas.ListCursor{Sort: ascanvas.SortCreated, Id: "3", At: ascanvas.UnixNano(minutes(5))}},
+		{name: "by update", args: ascanvas.ListArgs{Sort: ascanvas.SortUpdated}, want: ascanvas.ListCursor{Sort: ascanvas.SortUpdated, Id: "3", At: ascanvas.UnixNano(minutes(6))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, err = ascanvas.DecodeListCursor(ascanvas.CursorAfter(listed[2], tt.args))
+			if err != nil {
+				t.Fatalf("DecodeListCursor() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CursorAfter() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListArgs_Validate(t *testing.T) {
+	var byName = ascanvas.CursorAfter(listed[0], ascanvas.ListArgs{Sort: ascanvas.SortName})
+
+	tests := []struct {
+		name    string
+		args    ascanvas.ListArgs
+		wantErr bool
+	}{
+		{name: "defaults", args: ascanvas.ListArgs{}},
+		{name: "max limit", args: ascanvas.ListArgs{Limit: ascanvas.MaxListLimit}},
+		{name: "negative limit", args: ascanvas.ListArgs{Limit: -1}, wantErr: true},
+		{name: "limit too large", args: ascanvas.ListArgs{Limit: ascanvas.MaxListLimit + 1}, wantErr: true},
+		{name: "unknown sort", args: ascanvas.ListArgs{Sort: "content"}, wantErr: true},
+		{name: "garbage cursor", args: ascanvas.ListArgs{Cursor: "!!"}, wantErr: true},
+		{name: "cursor of sort", args: ascanvas.ListArgs{Cursor: byName, Sort: ascanvas.SortName}},
+		{name: "cursor of another sort", args: ascanvas.ListArgs{Cursor: byName}, wantErr: true},
+		{name: "cursor of another order", args: ascanvas.ListArgs{Cursor: byName, Sort: ascanvas.SortName, Desc: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err = tt.args.Validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil && !errors.Is(err, ascanvas.ErrInvalidInput) {
+				t.Errorf("Validate() error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package ascanvas
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// CanvasEventLocked is emitted when a region of a Canvas has been locked
+	CanvasEventLocked CanvasEventName = "LOCKED"
+
+	// CanvasEventUnlocked is emitted when a lock has been released or has expired
+	CanvasEventUnlocked CanvasEventName = "UNLOCKED"
+)
+
+const (
+	// DefaultLockTTL is used when no ttl is given when acquiring a Lock
+	DefaultLockTTL = time.Minute
+
+	// MaxLockTTL is the longest a Lock can be held without being renewed
+	MaxLockTTL = time.Hour
+)
+
+// Lock on a rectangular region of a Canvas; Token is only known to whoever acquired it
+type Lock struct {
+	Id        string    `json:"id"`
+	CanvasId  string    `json:"canvas_id"`
+	Owner     string    `json:"owner"`
+	Region    Selection `json:"region"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Token     string    `json:"token,omitempty"`
+}
+
+// AsLogFields is a helper for logging
+func (l Lock) AsLogFields() []zap.Field {
+	return []zap.Field{
+		zap.String("Id", l.Id),
+		zap.String("CanvasId", l.CanvasId),
+		zap.String("Owner", l.Owner),
+		zap.String("Region", l.Region.String()),
+		zap.Time("ExpiresAt", l.ExpiresAt),
+	}
+}
+
+// Public copy of the Lock, without its Token
+func (l Lock) Public() Lock {
+	l.Token = ""
+	return l
+}
+
+func (s Selection) String() string {
+	return s.TopLeft.String() + "+" + Coordinates{X: s.Width, Y: s.Height}.String()
+}
+
+// Contains tells if coordinates are within the Selection
+func (s Selection) Contains(c Coordinates) bool {
+	return c.X >= s.TopLeft.X && c.X < s.TopLeft.X+s.Width && c.Y >= s.TopLeft.Y && c.Y < s.TopLeft.Y+s.Height
+}
+
+// Intersects tells if two selections share at least one cell
+func (s Selection) Intersects(o Selection) bool {
+	return s.TopLeft.X < o.TopLeft.X+o.Width && o.TopLeft.X < s.TopLeft.X+s.Width &&
+		s.TopLeft.Y < o.TopLeft.Y+o.Height && o.TopLeft.Y < s.TopLeft.Y+s.Height
+}
+
+// CanvasLocker keeps track of region locks; expired locks are ignored until removed by Expire
+type CanvasLocker interface {
+	// Acquire a lock; ErrLocked when its region intersects another active lock
+	Acquire(ctx context.Context, lock Lock, ttl time.Duration) (*Lock, error)
+
+	// Renew extends an active lock; ErrLocked when the token does not match
+	Renew(ctx context.Context, canvasID, lockID, token string, ttl time.Duration) (*Lock, error)
+
+	// Release removes an active lock; ErrLocked when the token does not match
+	Release(ctx context.Context, canvasID, lockID, token string) (*Lock, error)
+
+	// List active locks of a canvas
+	List(ctx context.Context, canvasID string) ([]Lock, error)
+
+	// Expire removes and returns locks which have expired
+	Expire(ctx context.Context) ([]Lock, error)
+}
+
+type lockTokensKey struct{}
+
+// WithLockTokens returns a context carrying lock tokens held by the caller
+func WithLockTokens(ctx context.Context, tokens ...string) context.Context {
+	return context.WithValue(ctx, lockTokensKey{}, append(LockTokens(ctx), tokens...))
+}
+
+// LockTokens held by the caller, as set by WithLockTokens
+func LockTokens(ctx context.Context) []string {
+	var tokens, _ = ctx.Value(lockTokensKey{}).([]string)
+	return tokens
+}
+
+type LockArgs struct {
+	Owner  string    `json:"owner"`
+	Region Selection `json:"region"`
+	TTL    int       `json:"ttl"` // seconds
+}
+
+func (a LockArgs) Validate() error {
+	var errs []string
+
+	if strings.TrimSpace(a.Owner) == "" {
+		errs = append(errs, "owner cannot be empty")
+	}
+
+	if a.Region.TopLeft.X < 0 || a.Region.TopLeft.Y < 0 {
+		errs = append(errs, "region must not be negative")
+	}
+
+	if a.Region.Width < 1 || a.Region.Height < 1 {
+		errs = append(errs, "region width and height must be greater than zero")
+	}
+
+	if a.TTL < 0 || time.Duration(a.TTL)*time.Second > MaxLockTTL {
+		errs = append(errs, fmt.Sprintf("ttl must be between 0 and %d seconds", int(MaxLockTTL.Seconds())))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidInput, strings.Join(errs, ", "))
+}
+
+func (a LockArgs) ttl() time.Duration {
+	if a.TTL == 0 {
+		return DefaultLockTTL
+	}
+
+	return time.Duration(a.TTL) * time.Second
+}
+
+type RenewLockArgs struct {
+	TTL int `json:"ttl"` // seconds
+}
+
+func (a RenewLockArgs) Validate() error {
+	return LockArgs{Owner: "-", Region: Selection{Width: 1, Height: 1}, TTL: a.TTL}.Validate()
+}
+
+// AcquireLock on a region of a Canvas; the Lock returned includes the Token needed to edit it
+func (s CanvasService) AcquireLock(ctx context.Context, id string, args LockArgs) (*Lock, error) {
+	if s.Locker == nil {
+		return nil, ErrNotSupported
+	}
+
+	s.Logger.Debug("AcquireLock::Validating", zap.String("id", id), zap.String("owner", args.Owner))
+
+	if err := args.Validate(); err != nil {
+		s.Logger.Debug("AcquireLock::Validate::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	var canvas, err = s.Repo.Get(ctx, id)
+	if err == ErrNotFound {
+		s.Logger.Debug("AcquireLock:NotFound", zap.String("id", id))
+		return nil, err
+	} else if err != nil {
+		s.Logger.Error("AcquireLock::Fetch::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	var bottomRight = Coordinates{X: args.Region.TopLeft.X + args.Region.Width - 1, Y: args.Region.TopLeft.Y + args.Region.Height - 1}
+	if !canvas.Contains(args.Region.TopLeft) || !canvas.Contains(bottomRight) {
+		return nil, ErrOutOfBounds
+	}
+
+	var lock = Lock{
+		CanvasId: id,
+		Owner:    strings.TrimSpace(args.Owner),
+		Region:   args.Region,
+	}
+
+	if lock.Id, err = s.GenerateID(); err != nil {
+		return nil, err
+	}
+
+	if lock.Token, err = s.GenerateID(); err != nil {
+		return nil, err
+	}
+
+	acquired, err := s.Locker.Acquire(ctx, lock, args.ttl())
+	if err != nil {
+		s.Logger.Debug("AcquireLock::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	s.Logger.Debug("AcquireLock::Acquired", acquired.AsLogFields()...)
+	s.broadcastLock(ctx, CanvasEventLocked, *acquired)
+
+	return acquired, nil
+}
+
+// RenewLock extends the expiry of a Lock; the caller must hold its token via WithLockTokens
+func (s CanvasService) RenewLock(ctx context.Context, id string, lockID string, args RenewLockArgs) (*Lock, error) {
+	if s.Locker == nil {
+		return nil, ErrNotSupported
+	}
+
+	if err := args.Validate(); err != nil {
+		s.Logger.Debug("RenewLock::Validate::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	var ttl = LockArgs{TTL: args.TTL}.ttl()
+
+	for _, token := range LockTokens(ctx) {
+		var lock, err = s.Locker.Renew(ctx, id, lockID, token, ttl)
+		if err == ErrLocked {
+			continue
+		} else if err != nil {
+			s.Logger.Debug("RenewLock::Failed", zap.Error(err))
+			return nil, err
+		}
+
+		s.Logger.Debug("RenewLock::Renewed", lock.AsLogFields()...)
+		s.broadcastLock(ctx, CanvasEventLocked, *lock)
+
+		return lock, nil
+	}
+
+	return nil, ErrLocked
+}
+
+// ReleaseLock before it expires; the caller must hold its token via WithLockTokens
+func (s CanvasService) ReleaseLock(ctx context.Context, id string, lockID string) error {
+	if s.Locker == nil {
+		return ErrNotSupported
+	}
+
+	for _, token := range LockTokens(ctx) {
+		var lock, err = s.Locker.Release(ctx, id, lockID, token)
+		if err == ErrLocked {
+			continue
+		} else if err != nil {
+			s.Logger.Debug("ReleaseLock::Failed", zap.Error(err))
+			return err
+		}
+
+		s.Logger.Debug("ReleaseLock::Released", lock.AsLogFields()...)
+		s.broadcastLock(ctx, CanvasEventUnlocked, *lock)
+
+		return nil
+	}
+
+	return ErrLocked
+}
+
+// ListLocks active on a Canvas, without their tokens
+func (s CanvasService) ListLocks(ctx context.Context, id string) ([]Lock, error) {
+	if s.Locker == nil {
+		return []Lock{}, nil
+	}
+
+	var locks, err = s.Locker.List(ctx, id)
+	if err != nil {
+		s.Logger.Error("ListLocks::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	for i := range locks {
+		locks[i] = locks[i].Public()
+	}
+
+	return locks, nil
+}
+
+// ExpireLocks removes expired locks and notifies observers; meant to be called periodically
+func (s CanvasService) ExpireLocks(ctx context.Context) error {
+	if s.Locker == nil {
+		return nil
+	}
+
+	var locks, err = s.Locker.Expire(ctx)
+	if err != nil {
+		s.Logger.Error("ExpireLocks::Failed", zap.Error(err))
+		return err
+	}
+
+	for i := range locks {
+		s.Logger.Debug("ExpireLocks::Expired", locks[i].AsLogFields()...)
+		s.broadcastLock(ctx, CanvasEventUnlocked, locks[i])
+	}
+
+	return nil
+}
+
+func (s CanvasService) broadcastLock(ctx context.Context, name CanvasEventName, lock Lock) {
+	var public = lock.Public()
+
+	s.Broadcast(ctx, s.BroadCaster, s.Logger, CanvasEvent{
+		Name:   name,
+		Canvas: Canvas{Id: lock.CanvasId},
+		Lock:   &public,
+	})
+}
+
+// touchedArea tells if a change touches any cell of a region, whether the cell ends up changed or not
+type touchedArea func(region Selection) bool
+
+// rectangleArea covers the bounds of a rectangle, its inside included even when only outlined
+func rectangleArea(args TransformRectangleArgs) touchedArea {
+	var bounds = Selection{TopLeft: args.TopLeft, Width: args.Width, Height: args.Height}
+
+	if bounds.Width == 0 {
+		bounds.Width = 1
+	}
+
+	if bounds.Height == 0 {
+		bounds.Height = 1
+	}
+
+	return bounds.Intersects
+}
+
+// cellsArea covers cells one by one, e.g. those reached by a floodfill or written by a sync
+func cellsArea(cells []Coordinates) touchedArea {
+	return func(region Selection) bool {
+		for i := range cells {
+			if region.Contains(cells[i]) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// checkLocks rejects changes of a Canvas which touch regions locked by someone else
+func (s CanvasService) checkLocks(ctx context.Context, id string, touched touchedArea) error {
+	if s.Locker == nil {
+		return nil
+	}
+
+	var locks, err = s.Locker.List(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var held = make(map[string]bool)
+	for _, token := range LockTokens(ctx) {
+		held[token] = true
+	}
+
+	for i := range locks {
+		if held[locks[i].Token] {
+			continue
+		}
+
+		if touched(locks[i].Region) {
+			return fmt.Errorf("%w: region %s is locked by %s", ErrLocked, locks[i].Region, locks[i].Owner)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package ascanvas_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	mb "github.com/fluxynet/ascanvas/broadcaster/mocks"
+	lm "github.com/fluxynet/ascanvas/locker/memory"
+	mr "github.com/fluxynet/ascanvas/repo/mocks"
+)
+
+func TestCanvasService_Locks(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		repo = &mr.CanvasRepository{}
+		brd  = &mb.CanvasBroadcaster{}
+
+		s = ascanvas.CanvasService{
+			Repo:        repo,
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			GenerateID:  ascanvas.StaticUUIDGenerator("L1", nil),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Locker:      lm.New(),
+		}
+
+		region = ascanvas.Selection{
+			TopLeft: ascanvas.Coordinates{X: 1, Y: 1},
+			Width:   2,
+			Height:  2,
+		}
+	)
+
+	repo.On("Get", mock.Anything, "1").Return(func(context.Context, string) *ascanvas.Canvas {
+		return &ascanvas.Canvas{Id: "1", Name: "Foo", Content: strings.Repeat(".", 16), Width: 4, Height: 4}
+	}, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	brd.On("Broadcast", mock.Anything, mock.Anything).Return(nil)
+
+	lock, err := s.AcquireLock(ctx, "1", ascanvas.LockArgs{Owner: "Alice", Region: region})
+	if err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+
+	if lock.Token == "" || lock.Region != region || lock.Owner != "Alice" {
+		t.Errorf("AcquireLock() got = %v", lock)
+	}
+
+	brd.AssertCalled(t, "Broadcast", ctx, ascanvas.CanvasEvent{
+		Name:   ascanvas.CanvasEventLocked,
+		Canvas: ascanvas.Canvas{Id: "1"},
+		Lock:   &ascanvas.Lock{Id: lock.Id, CanvasId: "1", Owner: "Alice", Region: region, ExpiresAt: lock.ExpiresAt},
+	})
+
+	if _, err = s.AcquireLock(ctx, "1", ascanvas.LockArgs{Owner: "Bob", Region: region}); !errors.Is(err, ascanvas.ErrLocked) {
+		t.Errorf("AcquireLock() intersecting error = %v, want ErrLocked", err)
+	}
+
+	var overflowing = ascanvas.Selection{TopLeft: ascanvas.Coordinates{X: 3, Y: 3}, Width: 2, Height: 1}
+	if _, err = s.AcquireLock(ctx, "1", ascanvas.LockArgs{Owner: "Bob", Region: overflowing}); !errors.Is(err, ascanvas.ErrOutOfBounds) {
+		t.Errorf("AcquireLock() overflowing error = %v, want ErrOutOfBounds", err)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		args    ascanvas.TransformRectangleArgs
+		wantErr error
+	}{
+		{
+			name: "outside locked region",
+			ctx:  ctx,
+			args: ascanvas.TransformRectangleArgs{
+				TopLeft: ascanvas.Coordinates{X: 3, Y: 0},
+				Width:   1,
+				Height:  4,
+				Fill:    "x",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "intersecting locked region",
+			ctx:  ctx,
+			args: ascanvas.TransformRectangleArgs{
+				TopLeft: ascanvas.Coordinates{X: 0, Y: 0},
+				Width:   2,
+				Height:  2,
+				Fill:    "x",
+			},
+			wantErr: ascanvas.ErrLocked,
+		},
+		{
+			name: "intersecting with another token",
+			ctx:  ascanvas.WithLockTokens(ctx, "foo"),
+			args: ascanvas.TransformRectangleArgs{
+				TopLeft: ascanvas.Coordinates{X: 2, Y: 2},
+				Width:   1,
+				Height:  1,
+				Fill:    "x",
+			},
+			wantErr: ascanvas.ErrLocked,
+		},
+		{
+			name: "intersecting with lock token",
+			ctx:  ascanvas.WithLockTokens(ctx, "foo", lock.Token),
+			args: ascanvas.TransformRectangleArgs{
+				TopLeft: ascanvas.Coordinates{X: 0, Y: 0},
+				Width:   2,
+				Height:  2,
+				Fill:    "x",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "unchanged cells in locked region",
+			ctx:  ctx,
+			args: ascanvas.TransformRectangleArgs{
+				TopLeft: ascanvas.Coordinates{X: 0, Y: 0},
+				Width:   4,
+				Height:  4,
+				Fill:    ".",
+			},
+			wantErr: ascanvas.ErrLocked,
+		},
+		{
+			name: "outline around locked region",
+			ctx:  ctx,
+			args: ascanvas.TransformRectangleArgs{
+				TopLeft: ascanvas.Coordinates{X: 0, Y: 0},
+				Width:   4,
+				Height:  4,
+				Outline: "#",
+			},
+			wantErr: ascanvas.ErrLocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ApplyRectangle(tt.ctx, "1", tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ApplyRectangle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err = s.ApplyFloodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Fill: "o"}); !errors.Is(err, ascanvas.ErrLocked) {
+		t.Errorf("ApplyFloodfill() error = %v, want ErrLocked", err)
+	}
+
+	repo.AssertNumberOfCalls(t, "Update", 2)
+
+	if err = s.ReleaseLock(ctx, "1", lock.Id); !errors.Is(err, ascanvas.ErrLocked) {
+		t.Errorf("ReleaseLock() without token error = %v, want ErrLocked", err)
+	}
+
+	if err = s.ReleaseLock(ascanvas.WithLockTokens(ctx, lock.Token), "1", lock.Id); err != nil {
+		t.Errorf("ReleaseLock() error = %v", err)
+	}
+
+	if locks, _ := s.ListLocks(ctx, "1"); len(locks) != 0 {
+		t.Errorf("ListLocks() got = %v, want none", locks)
+	}
+
+	if _, err = s.ApplyFloodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Fill: "o"}); err != nil {
+		t.Errorf("ApplyFloodfill() after release error = %v", err)
+	}
+}
+
+func TestCanvasService_Locks_Floodfill(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		repo = &mr.CanvasRepository{}
+		brd  = &mb.CanvasBroadcaster{}
+
+		s = ascanvas.CanvasService{
+			Repo:        repo,
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			GenerateID:  ascanvas.StaticUUIDGenerator("L1", nil),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Locker:      lm.New(),
+		}
+	)
+
+	// a wall splits the canvas in two, the right side being locked
+	repo.On("Get", mock.Anything, "1").Return(func(context.Context, string) *ascanvas.Canvas {
+		return &ascanvas.Canvas{Id: "1", Content: "..#...#...#...#.", Width: 4, Height: 4}
+	}, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	brd.On("Broadcast", mock.Anything, mock.Anything).Return(nil)
+
+	var region = ascanvas.Selection{TopLeft: ascanvas.Coordinates{X: 3, Y: 0}, Width: 1, Height: 4}
+	if _, err := s.AcquireLock(ctx, "1", ascanvas.LockArgs{Owner: "Alice", Region: region}); err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		args    ascanvas.TransformFloodfillArgs
+		wantErr error
+	}{
+		{name: "not reaching locked region", args: ascanvas.TransformFloodfillArgs{Fill: "o"}},
+		{name: "reaching locked region", args: ascanvas.TransformFloodfillArgs{Start: ascanvas.Coordinates{X: 3, Y: 3}, Fill: "o"}, wantErr: ascanvas.ErrLocked},
+		{name: "wall along locked region", args: ascanvas.TransformFloodfillArgs{Start: ascanvas.Coordinates{X: 2, Y: 0}, Fill: "|"}},
+		{name: "same character in locked region", args: ascanvas.TransformFloodfillArgs{Start: ascanvas.Coordinates{X: 3, Y: 0}, Fill: "."}, wantErr: ascanvas.ErrLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.ApplyFloodfill(ctx, "1", tt.args); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ApplyFloodfill() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
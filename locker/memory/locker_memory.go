@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// Memory keeps locks within the process
+type Memory struct {
+	// Now is the clock used for expiry
+	Now func() time.Time
+
+	locks map[string]map[string]ascanvas.Lock
+	mutex sync.Mutex
+}
+
+func New() *Memory {
+	return &Memory{
+		Now:   time.Now,
+		locks: make(map[string]map[string]ascanvas.Lock),
+	}
+}
+
+func (m *Memory) Acquire(ctx context.Context, lock ascanvas.Lock, ttl time.Duration) (*ascanvas.Lock, error) {
+	defer m.mutex.Unlock()
+	m.mutex.Lock()
+
+	var now = m.Now()
+
+	for _, l := range m.locks[lock.CanvasId] {
+		if l.ExpiresAt.After(now) && l.Region.Intersects(lock.Region) {
+			return nil, ascanvas.ErrLocked
+		}
+	}
+
+	if _, ok := m.locks[lock.CanvasId]; !ok {
+		m.locks[lock.CanvasId] = make(map[string]ascanvas.Lock)
+	}
+
+	lock.ExpiresAt = now.Add(ttl)
+	m.locks[lock.CanvasId][lock.Id] = lock
+
+	return &lock, nil
+}
+
+func (m *Memory) Renew(ctx context.Context, canvasID, lockID, token string, ttl time.Duration) (*ascanvas.Lock, error) {
+	defer m.mutex.Unlock()
+	m.mutex.Lock()
+
+	var lock, err = m.find(canvasID, lockID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	lock.ExpiresAt = m.Now().Add(ttl)
+	m.locks[canvasID][lockID] = lock
+
+	return &lock, nil
+}
+
+func (m *Memory) Release(ctx context.Context, canvasID, lockID, token string) (*ascanvas.Lock, error) {
+	defer m.mutex.Unlock()
+	m.mutex.Lock()
+
+	var lock, err = m.find(canvasID, lockID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	m.remove(canvasID, lockID)
+
+	return &lock, nil
+}
+
+func (m *Memory) List(ctx context.Context, canvasID string) ([]ascanvas.Lock, error) {
+	defer m.mutex.Unlock()
+	m.mutex.Lock()
+
+	var (
+		now   = m.Now()
+		locks = make([]ascanvas.Lock, 0, len(m.locks[canvasID]))
+	)
+
+	for _, l := range m.locks[canvasID] {
+		if l.ExpiresAt.After(now) {
+			locks = append(locks, l)
+		}
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].Id < locks[j].Id
+	})
+
+	return locks, nil
+}
+
+func (m *Memory) Expire(ctx context.Context) ([]ascanvas.Lock, error) {
+	defer m.mutex.Unlock()
+	m.mutex.Lock()
+
+	var (
+		now     = m.Now()
+		expired []ascanvas.Lock
+	)
+
+	for canvasID := range m.locks {
+		for lockID, l := range m.locks[canvasID] {
+			if !l.ExpiresAt.After(now) {
+				expired = append(expired, l)
+				m.remove(canvasID, lockID)
+			}
+		}
+	}
+
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].Id < expired[j].Id
+	})
+
+	return expired, nil
+}
+
+// find an active lock, checking its token; mutex must be held
+func (m *Memory) find(canvasID, lockID, token string) (ascanvas.Lock, error) {
+	var lock, ok = m.locks[canvasID][lockID]
+
+	if !ok || !lock.ExpiresAt.After(m.Now()) {
+		return lock, ascanvas.ErrNotFound
+	}
+
+	if lock.Token != token {
+		return lock, ascanvas.ErrLocked
+	}
+
+	return lock, nil
+}
+
+// remove a lock; mutex must be held
+func (m *Memory) remove(canvasID, lockID string) {
+	delete(m.locks[canvasID], lockID)
+
+	if len(m.locks[canvasID]) == 0 {
+		delete(m.locks, canvasID)
+	}
+}
@@ -0,0 +1,140 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/locker/memory"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func lock(id, canvasID string, x, y, w, h int) ascanvas.Lock {
+	return ascanvas.Lock{
+		Id:       id,
+		CanvasId: canvasID,
+		Owner:    "owner " + id,
+		Token:    "token " + id,
+		Region: ascanvas.Selection{
+			TopLeft: ascanvas.Coordinates{X: x, Y: y},
+			Width:   w,
+			Height:  h,
+		},
+	}
+}
+
+func TestMemory_Acquire(t *testing.T) {
+	tests := []struct {
+		name    string
+		lock    ascanvas.Lock
+		wantErr error
+	}{
+		{
+			name:    "first lock",
+			lock:    lock("1", "c1", 0, 0, 2, 2),
+			wantErr: nil,
+		},
+		{
+			name:    "intersecting",
+			lock:    lock("2", "c1", 1, 1, 2, 2),
+			wantErr: ascanvas.ErrLocked,
+		},
+		{
+			name:    "adjacent",
+			lock:    lock("3", "c1", 2, 0, 2, 2),
+			wantErr: nil,
+		},
+		{
+			name:    "same region on another canvas",
+			lock:    lock("4", "c2", 0, 0, 2, 2),
+			wantErr: nil,
+		},
+	}
+
+	var (
+		c = &clock{now: time.Unix(1000, 0)}
+		m = memory.New()
+	)
+
+	m.Now = c.Now
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Acquire(context.Background(), tt.lock, time.Minute)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Acquire() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err == nil && !got.ExpiresAt.Equal(c.now.Add(time.Minute)) {
+				t.Errorf("Acquire() ExpiresAt = %v", got.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestMemory_Lifecycle(t *testing.T) {
+	var (
+		ctx = context.Background()
+		c   = &clock{now: time.Unix(1000, 0)}
+		m   = memory.New()
+	)
+
+	m.Now = c.Now
+
+	for _, l := range []ascanvas.Lock{lock("1", "c1", 0, 0, 1, 1), lock("2", "c1", 5, 5, 1, 1)} {
+		if _, err := m.Acquire(ctx, l, time.Minute); err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+	}
+
+	if _, err := m.Renew(ctx, "c1", "1", "token 2", time.Hour); !errors.Is(err, ascanvas.ErrLocked) {
+		t.Errorf("Renew() with wrong token error = %v", err)
+	}
+
+	if _, err := m.Renew(ctx, "c1", "1", "token 1", time.Hour); err != nil {
+		t.Errorf("Renew() error = %v", err)
+	}
+
+	c.now = c.now.Add(2 * time.Minute)
+
+	if got, _ := m.List(ctx, "c1"); len(got) != 1 || got[0].Id != "1" {
+		t.Errorf("List() got = %v, want lock 1 only", got)
+	}
+
+	if _, err := m.Release(ctx, "c1", "2", "token 2"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Release() of expired lock error = %v", err)
+	}
+
+	expired, err := m.Expire(ctx)
+	if err != nil {
+		t.Fatalf("Expire() error = %v", err)
+	}
+
+	if len(expired) != 1 || expired[0].Id != "2" {
+		t.Errorf("Expire() got = %v, want lock 2", expired)
+	}
+
+	released, err := m.Release(ctx, "c1", "1", "token 1")
+	if err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+
+	if released.Id != "1" {
+		t.Errorf("Release() got = %v, want lock 1", released)
+	}
+
+	if got, _ := m.List(ctx, "c1"); !reflect.DeepEqual(got, []ascanvas.Lock{}) {
+		t.Errorf("List() got = %v, want empty", got)
+	}
+}
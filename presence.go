@@ -0,0 +1,376 @@
+package ascanvas
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// CanvasEventJoin is emitted when an observer joins a Canvas
+	CanvasEventJoin CanvasEventName = "JOIN"
+
+	// CanvasEventLeave is emitted when an observer leaves a Canvas
+	CanvasEventLeave CanvasEventName = "LEAVE"
+
+	// CanvasEventCursor is emitted when an observer moves its cursor or changes its selection
+	CanvasEventCursor CanvasEventName = "CURSOR"
+
+	// CanvasEventHeartbeat is emitted periodically while an observer is connected, to keep its presence alive
+	CanvasEventHeartbeat CanvasEventName = "HEARTBEAT"
+)
+
+const (
+	// PresenceHeartbeat is how often connected observers emit a heartbeat
+	PresenceHeartbeat = 15 * time.Second
+
+	// PresenceTTL is how long a presence lasts without any event from its observer;
+	// it outlives a few missed heartbeats so that presences of crashed instances go away too
+	PresenceTTL = 3 * PresenceHeartbeat
+)
+
+// Presence of an observer on a Canvas
+type Presence struct {
+	Session   string       `json:"session"`
+	Name      string       `json:"name"`
+	Cursor    *Coordinates `json:"cursor,omitempty"`
+	Selection *Selection   `json:"selection,omitempty"`
+}
+
+// AsLogFields is a helper for logging
+func (p Presence) AsLogFields() []zap.Field {
+	return []zap.Field{
+		zap.String("Session", p.Session),
+		zap.String("Name", p.Name),
+	}
+}
+
+// Selection is a rectangular area of a Canvas
+type Selection struct {
+	TopLeft Coordinates `json:"top_left"`
+	Width   int         `json:"width"`
+	Height  int         `json:"height"`
+}
+
+type JoinArgs struct {
+	Name string `json:"name"`
+}
+
+func (a JoinArgs) Validate() error {
+	if strings.TrimSpace(a.Name) == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidInput)
+	}
+
+	return nil
+}
+
+type CursorArgs struct {
+	Session   string       `json:"session"`
+	Name      string       `json:"name"`
+	Cursor    *Coordinates `json:"cursor"`
+	Selection *Selection   `json:"selection"`
+}
+
+func (a CursorArgs) Validate() error {
+	var errs []string
+
+	if a.Session == "" {
+		errs = append(errs, "session cannot be empty")
+	}
+
+	if a.Cursor != nil && (a.Cursor.X < 0 || a.Cursor.Y < 0) {
+		errs = append(errs, "cursor must not be negative")
+	}
+
+	if a.Selection != nil && (a.Selection.TopLeft.X < 0 || a.Selection.TopLeft.Y < 0) {
+		errs = append(errs, "selection must not be negative")
+	}
+
+	if a.Selection != nil && (a.Selection.Width < 0 || a.Selection.Height < 0) {
+		errs = append(errs, "selection size cannot be negative")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidInput, strings.Join(errs, ", "))
+}
+
+// Join a Canvas as an observer with a display name; the Presence returned identifies the session
+func (s CanvasService) Join(ctx context.Context, id string, args JoinArgs) (*Presence, error) {
+	s.Logger.Debug("Join::Validating", zap.String("id", id), zap.String("name", args.Name))
+
+	if err := args.Validate(); err != nil {
+		s.Logger.Debug("Join::Validate::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	var _, err = s.Repo.Get(ctx, id)
+	if err == ErrNotFound {
+		s.Logger.Debug("Join:NotFound", zap.String("id", id))
+		return nil, err
+	} else if err != nil {
+		s.Logger.Error("Join::Fetch::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	session, err := s.GenerateID()
+	if err != nil {
+		return nil, err
+	}
+
+	var presence = Presence{
+		Session: session,
+		Name:    strings.TrimSpace(args.Name),
+	}
+
+	s.Logger.Debug("Join::Joined", presence.AsLogFields()...)
+	s.presenceEvent(ctx, CanvasEventJoin, id, presence)
+
+	return &presence, nil
+}
+
+// Leave a Canvas previously joined
+func (s CanvasService) Leave(ctx context.Context, id string, presence Presence) {
+	s.Logger.Debug("Leave::Left", presence.AsLogFields()...)
+	s.presenceEvent(ctx, CanvasEventLeave, id, presence)
+}
+
+// Heartbeat of a session still connected, keeping its presence alive; meant to be called every PresenceHeartbeat
+func (s CanvasService) Heartbeat(ctx context.Context, id string, presence Presence) {
+	s.presenceEvent(ctx, CanvasEventHeartbeat, id, presence)
+}
+
+// MoveCursor publishes the cursor position and selection of a session joined on the canvas
+func (s CanvasService) MoveCursor(ctx context.Context, id string, args CursorArgs) error {
+	if s.Presences == nil {
+		return ErrNotSupported
+	}
+
+	if err := args.Validate(); err != nil {
+		s.Logger.Debug("MoveCursor::Validate::Failed", zap.Error(err))
+		return err
+	}
+
+	var _, err = s.Repo.Get(ctx, id)
+	if err == ErrNotFound {
+		s.Logger.Debug("MoveCursor:NotFound", zap.String("id", id))
+		return err
+	} else if err != nil {
+		s.Logger.Error("MoveCursor::Fetch::Failed", zap.Error(err))
+		return err
+	}
+
+	var joined, ok = s.Presences.Get(id, args.Session)
+	if !ok {
+		s.Logger.Debug("MoveCursor::NotJoined", zap.String("id", id), zap.String("session", args.Session))
+		return fmt.Errorf("%w: session %s has not joined canvas %s", ErrInvalidInput, args.Session, id)
+	}
+
+	joined.Cursor, joined.Selection = args.Cursor, args.Selection
+	s.presenceEvent(ctx, CanvasEventCursor, id, joined)
+
+	return nil
+}
+
+// ExpirePresences of sessions not heard of for PresenceTTL, notifying observers they left; meant to be called periodically
+func (s CanvasService) ExpirePresences(ctx context.Context) {
+	if s.Presences == nil {
+		return
+	}
+
+	for id, presences := range s.Presences.Expire() {
+		for i := range presences {
+			s.Logger.Debug("ExpirePresences::Expired", presences[i].AsLogFields()...)
+			s.Leave(ctx, id, presences[i])
+		}
+	}
+}
+
+// presenceEvent is applied to the presences tracked by this instance right away, so that sessions joined here
+// are known before the event makes it back from the broadcaster, then broadcast to all
+func (s CanvasService) presenceEvent(ctx context.Context, name CanvasEventName, id string, presence Presence) {
+	var event = CanvasEvent{
+		Name:     name,
+		Canvas:   Canvas{Id: id},
+		Presence: &presence,
+	}
+
+	if s.Presences != nil {
+		s.Presences.Apply(event)
+	}
+
+	s.Broadcast(ctx, s.BroadCaster, s.Logger, event)
+}
+
+// PresenceTracker keeps track of who is present on each Canvas from the events it is fed.
+// Presences are refreshed by any event of their session, and expire once not refreshed for TTL.
+type PresenceTracker struct {
+	// TTL of presences, PresenceTTL by default
+	TTL time.Duration
+
+	// Clock tells when events are applied; SystemClock when nil
+	Clock ClockFunc
+
+	canvases map[string]map[string]trackedPresence
+
+	// left keeps sessions which left until TTL, so that their events arriving late are ignored
+	left  map[string]time.Time
+	mutex sync.RWMutex
+}
+
+type trackedPresence struct {
+	Presence
+	seen time.Time
+}
+
+func NewPresenceTracker() *PresenceTracker {
+	return &PresenceTracker{
+		TTL:      PresenceTTL,
+		canvases: make(map[string]map[string]trackedPresence),
+		left:     make(map[string]time.Time),
+	}
+}
+
+// Track events until the channel is closed; typically fed by observing ObserveALL
+func (t *PresenceTracker) Track(events <-chan CanvasEvent) {
+	for event := range events {
+		t.Apply(event)
+	}
+}
+
+// Apply a single event to the tracked presences; CURSOR only updates sessions already known by a JOIN or HEARTBEAT,
+// and events of sessions which left are ignored
+func (t *PresenceTracker) Apply(event CanvasEvent) {
+	defer t.mutex.Unlock()
+	t.mutex.Lock()
+
+	var (
+		id  = event.Canvas.Id
+		now = t.now()
+	)
+
+	switch event.Name {
+	case CanvasEventJoin, CanvasEventCursor, CanvasEventHeartbeat:
+		if event.Presence == nil {
+			return
+		}
+
+		var session = event.Presence.Session
+		if _, ok := t.left[session]; ok {
+			return
+		}
+
+		var current, ok = t.canvases[id][session]
+
+		switch {
+		case event.Name == CanvasEventCursor && !ok:
+			return
+		case event.Name == CanvasEventCursor:
+			current.Presence = *event.Presence
+		case !ok:
+			current = trackedPresence{Presence: *event.Presence}
+		}
+
+		if _, ok := t.canvases[id]; !ok {
+			t.canvases[id] = make(map[string]trackedPresence)
+		}
+
+		current.seen = now
+		t.canvases[id][session] = current
+	case CanvasEventLeave:
+		if event.Presence != nil {
+			delete(t.canvases[id], event.Presence.Session)
+			t.left[event.Presence.Session] = now
+		}
+
+		if len(t.canvases[id]) == 0 {
+			delete(t.canvases, id)
+		}
+	case CanvasEventDeleted:
+		for session := range t.canvases[id] {
+			t.left[session] = now
+		}
+
+		delete(t.canvases, id)
+	}
+}
+
+// Get the presence of a session on a Canvas, if joined
+func (t *PresenceTracker) Get(id string, session string) (Presence, bool) {
+	defer t.mutex.RUnlock()
+	t.mutex.RLock()
+
+	var p, ok = t.canvases[id][session]
+
+	return p.Presence, ok
+}
+
+// Expire presences not refreshed for TTL, returning them by Canvas; sessions which left are forgotten after TTL too
+func (t *PresenceTracker) Expire() map[string][]Presence {
+	defer t.mutex.Unlock()
+	t.mutex.Lock()
+
+	var (
+		expired = make(map[string][]Presence)
+		now     = t.now()
+		before  = now.Add(-t.TTL)
+	)
+
+	for id, presences := range t.canvases {
+		for session, p := range presences {
+			if p.seen.Before(before) {
+				expired[id] = append(expired[id], p.Presence)
+				delete(presences, session)
+				t.left[session] = now
+			}
+		}
+
+		if len(presences) == 0 {
+			delete(t.canvases, id)
+		}
+	}
+
+	for session, at := range t.left {
+		if at.Before(before) {
+			delete(t.left, session)
+		}
+	}
+
+	return expired
+}
+
+// List presences on a Canvas, sorted by name
+func (t *PresenceTracker) List(id string) []Presence {
+	defer t.mutex.RUnlock()
+	t.mutex.RLock()
+
+	var items = make([]Presence, 0, len(t.canvases[id]))
+	for _, p := range t.canvases[id] {
+		items = append(items, p.Presence)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Name == items[j].Name {
+			return items[i].Session < items[j].Session
+		}
+
+		return items[i].Name < items[j].Name
+	})
+
+	return items
+}
+
+func (t *PresenceTracker) now() time.Time {
+	if t.Clock == nil {
+		return SystemClock()
+	}
+
+	return t.Clock()
+}
@@ -0,0 +1,410 @@
+package ascanvas_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	mb "github.com/fluxynet/ascanvas/broadcaster/mocks"
+	mr "github.com/fluxynet/ascanvas/repo/mocks"
+)
+
+func TestCanvasService_Join(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		args       ascanvas.JoinArgs
+		repoGetErr error
+		want       *ascanvas.Presence
+		wantErr    error
+	}{
+		{
+			name:    "empty name",
+			id:      "1",
+			args:    ascanvas.JoinArgs{Name: "  "},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:       "canvas not found",
+			id:         "1",
+			args:       ascanvas.JoinArgs{Name: "Alice"},
+			repoGetErr: ascanvas.ErrNotFound,
+			wantErr:    ascanvas.ErrNotFound,
+		},
+		{
+			name: "joined",
+			id:   "1",
+			args: ascanvas.JoinArgs{Name: " Alice "},
+			want: &ascanvas.Presence{Session: "s1", Name: "Alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &mr.CanvasRepository{}
+			brd := &mb.CanvasBroadcaster{}
+
+			s := ascanvas.CanvasService{
+				Repo:        repo,
+				BroadCaster: brd,
+				Logger:      zaptest.NewLogger(t),
+				GenerateID:  ascanvas.StaticUUIDGenerator("s1", nil),
+				Broadcast:   ascanvas.SyncBroadcast,
+			}
+
+			var canvas *ascanvas.Canvas
+			if tt.repoGetErr == nil {
+				canvas = &ascanvas.Canvas{Id: tt.id}
+			}
+
+			repo.On("Get", ctx, tt.id).Return(canvas, tt.repoGetErr)
+
+			if tt.want != nil {
+				brd.On("Broadcast", ctx, ascanvas.CanvasEvent{
+					Name:     ascanvas.CanvasEventJoin,
+					Canvas:   ascanvas.Canvas{Id: tt.id},
+					Presence: tt.want,
+				}).Return(nil)
+			}
+
+			got, err := s.Join(ctx, tt.id, tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Join() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Join() got = %v, want %v", got, tt.want)
+			}
+
+			if tt.want == nil && !brd.AssertNotCalled(t, "Broadcast") {
+				return
+			}
+
+			if tt.want != nil && !brd.AssertNumberOfCalls(t, "Broadcast", 1) {
+				return
+			}
+		})
+	}
+}
+
+func TestCanvasService_MoveCursor(t *testing.T) {
+	var alice = ascanvas.Presence{Session: "s1", Name: "Alice"}
+
+	tests := []struct {
+		name       string
+		args       ascanvas.CursorArgs
+		joined     []ascanvas.CanvasEvent
+		noTracker  bool
+		repoGetErr error
+		want       *ascanvas.Presence
+		wantErr    error
+	}{
+		{
+			name:      "not supported",
+			args:      ascanvas.CursorArgs{Session: "s1", Cursor: &ascanvas.Coordinates{X: 1, Y: 1}},
+			noTracker: true,
+			wantErr:   ascanvas.ErrNotSupported,
+		},
+		{
+			name:    "no session",
+			args:    ascanvas.CursorArgs{Name: "Alice", Cursor: &ascanvas.Coordinates{X: 1, Y: 1}},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "negative cursor",
+			args:    ascanvas.CursorArgs{Session: "s1", Cursor: &ascanvas.Coordinates{X: -1, Y: 1}},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:       "canvas not found",
+			args:       ascanvas.CursorArgs{Session: "s1", Cursor: &ascanvas.Coordinates{X: 1, Y: 1}},
+			joined:     []ascanvas.CanvasEvent{{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice}},
+			repoGetErr: ascanvas.ErrNotFound,
+			wantErr:    ascanvas.ErrNotFound,
+		},
+		{
+			name:    "not joined",
+			args:    ascanvas.CursorArgs{Session: "s1", Cursor: &ascanvas.Coordinates{X: 1, Y: 1}},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "joined another canvas",
+			args:    ascanvas.CursorArgs{Session: "s1", Cursor: &ascanvas.Coordinates{X: 1, Y: 1}},
+			joined:  []ascanvas.CanvasEvent{{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "2"}, Presence: &alice}},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name: "left",
+			args: ascanvas.CursorArgs{Session: "s1", Cursor: &ascanvas.Coordinates{X: 1, Y: 1}},
+			joined: []ascanvas.CanvasEvent{
+				{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice},
+				{Name: ascanvas.CanvasEventLeave, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice},
+			},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name: "cursor and selection, named as joined",
+			args: ascanvas.CursorArgs{
+				Session: "s1",
+				Name:    "Mallory",
+				Cursor:  &ascanvas.Coordinates{X: 1, Y: 2},
+				Selection: &ascanvas.Selection{
+					TopLeft: ascanvas.Coordinates{X: 1, Y: 2},
+					Width:   3,
+					Height:  4,
+				},
+			},
+			joined: []ascanvas.CanvasEvent{{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice}},
+			want: &ascanvas.Presence{
+				Session: "s1",
+				Name:    "Alice",
+				Cursor:  &ascanvas.Coordinates{X: 1, Y: 2},
+				Selection: &ascanvas.Selection{
+					TopLeft: ascanvas.Coordinates{X: 1, Y: 2},
+					Width:   3,
+					Height:  4,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &mr.CanvasRepository{}
+			brd := &mb.CanvasBroadcaster{}
+
+			s := ascanvas.CanvasService{
+				Repo:        repo,
+				BroadCaster: brd,
+				Logger:      zaptest.NewLogger(t),
+				Broadcast:   ascanvas.SyncBroadcast,
+			}
+
+			if !tt.noTracker {
+				s.Presences = ascanvas.NewPresenceTracker()
+			}
+
+			for i := range tt.joined {
+				s.Presences.Apply(tt.joined[i])
+			}
+
+			var canvas *ascanvas.Canvas
+			if tt.repoGetErr == nil {
+				canvas = &ascanvas.Canvas{Id: "1"}
+			}
+
+			repo.On("Get", ctx, "1").Return(canvas, tt.repoGetErr)
+
+			event := ascanvas.CanvasEvent{
+				Name:     ascanvas.CanvasEventCursor,
+				Canvas:   ascanvas.Canvas{Id: "1"},
+				Presence: tt.want,
+			}
+
+			brd.On("Broadcast", ctx, event).Return(nil)
+
+			if err := s.MoveCursor(ctx, "1", tt.args); !errors.Is(err, tt.wantErr) {
+				t.Errorf("MoveCursor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr != nil && !brd.AssertNotCalled(t, "Broadcast") {
+				return
+			}
+
+			if tt.wantErr == nil && !brd.AssertCalled(t, "Broadcast", ctx, event) {
+				return
+			}
+
+			if tt.want != nil {
+				if got := s.Presences.List("1"); !reflect.DeepEqual(got, []ascanvas.Presence{*tt.want}) {
+					t.Errorf("List() got = %v, want %v", got, *tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPresenceTracker(t *testing.T) {
+	var (
+		alice = ascanvas.Presence{Session: "a", Name: "Alice"}
+		bob   = ascanvas.Presence{Session: "b", Name: "Bob"}
+		moved = ascanvas.Presence{Session: "b", Name: "Bob", Cursor: &ascanvas.Coordinates{X: 3, Y: 4}}
+	)
+
+	tests := []struct {
+		name   string
+		events []ascanvas.CanvasEvent
+		want   map[string][]ascanvas.Presence
+	}{
+		{
+			name: "join",
+			events: []ascanvas.CanvasEvent{
+				{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &bob},
+				{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice},
+				{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "2"}, Presence: &alice},
+			},
+			want: map[string][]ascanvas.Presence{
+				"1": {alice, bob},
+				"2": {alice},
+				"3": {},
+			},
+		},
+		{
+			name: "cursor and leave",
+			events: []ascanvas.CanvasEvent{
+				{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice},
+				{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &bob},
+				{Name: ascanvas.CanvasEventCursor, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &moved},
+				{Name: ascanvas.CanvasEventLeave, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice},
+			},
+			want: map[string][]ascanvas.Presence{
+				"1": {moved},
+			},
+		},
+		{
+			name: "cursor of sessions not joined",
+			events: []ascanvas.CanvasEvent{
+				{Name: ascanvas.CanvasEventCursor, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &moved},
+			},
+			want: map[string][]ascanvas.Presence{
+				"1": {},
+			},
+		},
+		{
+			name: "late events of sessions which left",
+			events: []ascanvas.CanvasEvent{
+				{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &bob},
+				{Name: ascanvas.CanvasEventLeave, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &bob},
+				{Name: ascanvas.CanvasEventCursor, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &moved},
+				{Name: ascanvas.CanvasEventHeartbeat, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &bob},
+				{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &bob},
+			},
+			want: map[string][]ascanvas.Presence{
+				"1": {},
+			},
+		},
+		{
+			name: "heartbeat of sessions joined elsewhere",
+			events: []ascanvas.CanvasEvent{
+				{Name: ascanvas.CanvasEventHeartbeat, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &bob},
+				{Name: ascanvas.CanvasEventCursor, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &moved},
+				{Name: ascanvas.CanvasEventHeartbeat, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &bob},
+			},
+			want: map[string][]ascanvas.Presence{
+				"1": {moved},
+			},
+		},
+		{
+			name: "canvas deleted",
+			events: []ascanvas.CanvasEvent{
+				{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice},
+				{Name: ascanvas.CanvasEventUpdated, Canvas: ascanvas.Canvas{Id: "1"}},
+				{Name: ascanvas.CanvasEventDeleted, Canvas: ascanvas.Canvas{Id: "1"}},
+			},
+			want: map[string][]ascanvas.Presence{
+				"1": {},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				tracker = ascanvas.NewPresenceTracker()
+				events  = make(chan ascanvas.CanvasEvent, len(tt.events))
+			)
+
+			for i := range tt.events {
+				events <- tt.events[i]
+			}
+
+			close(events)
+			tracker.Track(events)
+
+			for id, want := range tt.want {
+				if got := tracker.List(id); !reflect.DeepEqual(got, want) {
+					t.Errorf("List(%s) got = %v, want %v", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPresenceTracker_Expire(t *testing.T) {
+	var (
+		alice   = ascanvas.Presence{Session: "a", Name: "Alice"}
+		bob     = ascanvas.Presence{Session: "b", Name: "Bob"}
+		at      = now
+		tracker = ascanvas.NewPresenceTracker()
+	)
+
+	tracker.Clock = func() time.Time { return at }
+
+	tracker.Apply(ascanvas.CanvasEvent{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice})
+	tracker.Apply(ascanvas.CanvasEvent{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &bob})
+
+	// only bob keeps beating
+	at = at.Add(ascanvas.PresenceHeartbeat)
+	tracker.Apply(ascanvas.CanvasEvent{Name: ascanvas.CanvasEventHeartbeat, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &bob})
+
+	at = at.Add(ascanvas.PresenceTTL - ascanvas.PresenceHeartbeat)
+	if got := tracker.Expire(); len(got) != 0 {
+		t.Errorf("Expire() within ttl got = %v, want none", got)
+	}
+
+	at = at.Add(time.Second)
+	if got, want := tracker.Expire(), map[string][]ascanvas.Presence{"1": {alice}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expire() got = %v, want %v", got, want)
+	}
+
+	if got, want := tracker.List("1"), []ascanvas.Presence{bob}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() got = %v, want %v", got, want)
+	}
+
+	// a heartbeat of the expired session arriving late does not bring it back
+	tracker.Apply(ascanvas.CanvasEvent{Name: ascanvas.CanvasEventHeartbeat, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice})
+	if got, want := tracker.List("1"), []ascanvas.Presence{bob}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() after late heartbeat got = %v, want %v", got, want)
+	}
+}
+
+func TestCanvasService_ExpirePresences(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		alice = ascanvas.Presence{Session: "a", Name: "Alice"}
+		at    = now
+		brd   = &mb.CanvasBroadcaster{}
+		s     = ascanvas.CanvasService{
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Presences:   ascanvas.NewPresenceTracker(),
+		}
+		leave = ascanvas.CanvasEvent{Name: ascanvas.CanvasEventLeave, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice}
+	)
+
+	s.Presences.Clock = func() time.Time { return at }
+	s.Presences.Apply(ascanvas.CanvasEvent{Name: ascanvas.CanvasEventJoin, Canvas: ascanvas.Canvas{Id: "1"}, Presence: &alice})
+
+	brd.On("Broadcast", ctx, leave).Return(nil)
+
+	s.ExpirePresences(ctx)
+	brd.AssertNotCalled(t, "Broadcast", ctx, leave)
+
+	at = at.Add(ascanvas.PresenceTTL + time.Second)
+	s.ExpirePresences(ctx)
+	brd.AssertNumberOfCalls(t, "Broadcast", 1)
+
+	if got := s.Presences.List("1"); len(got) != 0 {
+		t.Errorf("List() got = %v, want none", got)
+	}
+}
@@ -0,0 +1,296 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// DefaultMaxBytes is the default bound on the content cached
+const DefaultMaxBytes = 64 << 20
+
+// Cache is a read-through CanvasRepository decorator, keeping recently used canvases of Repo
+// in a least recently used list bounded by the total bytes of their content.
+// The result of List is kept apart while within half of MaxBytes.
+// Writes go through to Repo and then to the cache. When several instances share Repo,
+// Track the events of a broadcaster so that canvases changed elsewhere are not served stale.
+type Cache struct {
+	Repo     ascanvas.CanvasRepository
+	MaxBytes int
+
+	entries    map[string]*list.Element
+	recent     *list.List
+	canvases   []ascanvas.Canvas
+	listed     bool
+	bytes      int
+	generation uint64
+	stats      Stats
+	mutex      sync.Mutex
+}
+
+// Stats of a Cache; List is counted along with Get
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	Bytes     int    `json:"bytes"`
+}
+
+// New Cache of repo, bounded to maxBytes of content; DefaultMaxBytes is used when not positive
+func New(repo ascanvas.CanvasRepository, maxBytes int) *Cache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	return &Cache{
+		Repo:     repo,
+		MaxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		recent:   list.New(),
+	}
+}
+
+func (c *Cache) Create(ctx context.Context, canvas ascanvas.Canvas) error {
+	if err := c.Repo.Create(ctx, canvas); err != nil {
+		return err
+	}
+
+	defer c.mutex.Unlock()
+	c.mutex.Lock()
+
+	c.invalidateList()
+	c.put(canvas)
+
+	return nil
+}
+
+func (c *Cache) Update(ctx context.Context, canvas ascanvas.Canvas) error {
+	if err := c.Repo.Update(ctx, canvas); err != nil {
+		return err
+	}
+
+	defer c.mutex.Unlock()
+	c.mutex.Lock()
+
+	c.invalidateList()
+
+	// a canvas not cached may not exist at all, in which case Update did nothing
+	if _, ok := c.entries[canvas.Id]; ok {
+		c.put(canvas)
+	}
+
+	return nil
+}
+
+func (c *Cache) Get(ctx context.Context, id string) (*ascanvas.Canvas, error) {
+	c.mutex.Lock()
+
+	if e, ok := c.entries[id]; ok {
+		c.recent.MoveToFront(e)
+		c.stats.Hits += 1
+
+		var canvas = e.Value.(ascanvas.Canvas)
+		c.mutex.Unlock()
+
+		return &canvas, nil
+	}
+
+	c.stats.Misses += 1
+	var generation = c.generation
+	c.mutex.Unlock()
+
+	var canvas, err = c.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.mutex.Unlock()
+	c.mutex.Lock()
+
+	// an invalidation while reading might mean that canvas is already stale
+	if generation == c.generation {
+		c.put(*canvas)
+	}
+
+	return canvas, nil
+}
+
+func (c *Cache) List(ctx context.Context) ([]ascanvas.Canvas, error) {
+	c.mutex.Lock()
+
+	if c.listed {
+		c.stats.Hits += 1
+
+		var canvases = append([]ascanvas.Canvas{}, c.canvases...)
+		c.mutex.Unlock()
+
+		return canvases, nil
+	}
+
+	c.stats.Misses += 1
+	var generation = c.generation
+	c.mutex.Unlock()
+
+	var canvases, err = c.Repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.mutex.Unlock()
+	c.mutex.Lock()
+
+	if generation == c.generation && size(canvases) <= c.MaxBytes/2 {
+		c.canvases = append([]ascanvas.Canvas{}, canvases...)
+		c.listed = true
+	}
+
+	return canvases, nil
+}
+
+// ListPage is not cached; it is delegated to Repo when it is an ascanvas.CanvasPager
+func (c *Cache) ListPage(ctx context.Context, args ascanvas.ListArgs) (*ascanvas.CanvasPage, error) {
+	if pager, ok := c.Repo.(ascanvas.CanvasPager); ok {
+		return pager.ListPage(ctx, args)
+	}
+
+	var canvases, err = c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ascanvas.Paginate(canvases, args)
+}
+
+// Search is not cached; it is delegated to Repo when it is an ascanvas.CanvasSearcher
+func (c *Cache) Search(ctx context.Context, args ascanvas.SearchArgs) ([]ascanvas.CanvasSummary, error) {
+	if searcher, ok := c.Repo.(ascanvas.CanvasSearcher); ok {
+		return searcher.Search(ctx, args)
+	}
+
+	var canvases, err = c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ascanvas.SearchCanvases(canvases, args), nil
+}
+
+func (c *Cache) Delete(ctx context.Context, id string) error {
+	if err := c.Repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	c.Invalidate(id)
+
+	return nil
+}
+
+// Invalidate a canvas, so that it is read from Repo next time
+func (c *Cache) Invalidate(id string) {
+	defer c.mutex.Unlock()
+	c.mutex.Lock()
+
+	c.invalidateList()
+
+	if e, ok := c.entries[id]; ok {
+		c.remove(e)
+	}
+}
+
+// Track events of a broadcaster, invalidating canvases changed; meant to be run in a goroutine
+func (c *Cache) Track(events <-chan ascanvas.CanvasEvent) {
+	for event := range events {
+		c.Apply(event)
+	}
+}
+
+// Apply a single event, invalidating the canvas unless the event leaves its content unchanged
+// or the canvas cached is already as in the event, e.g. when written through this cache
+func (c *Cache) Apply(event ascanvas.CanvasEvent) {
+	switch event.Name {
+	case ascanvas.CanvasEventJoin, ascanvas.CanvasEventLeave, ascanvas.CanvasEventCursor, ascanvas.CanvasEventHeartbeat,
+		ascanvas.CanvasEventLocked, ascanvas.CanvasEventUnlocked:
+		return
+	case ascanvas.CanvasEventDeleted:
+	default:
+		if c.cached(event.Canvas) {
+			return
+		}
+	}
+
+	c.Invalidate(event.Canvas.Id)
+}
+
+// cached tells if a canvas is cached with the same content as of the same time
+func (c *Cache) cached(canvas ascanvas.Canvas) bool {
+	defer c.mutex.Unlock()
+	c.mutex.Lock()
+
+	var e, ok = c.entries[canvas.Id]
+	if !ok {
+		return false
+	}
+
+	var entry = e.Value.(ascanvas.Canvas)
+
+	return entry.Content == canvas.Content && entry.UpdatedAt.Equal(canvas.UpdatedAt)
+}
+
+// Stats of the cache so far
+func (c *Cache) Stats() Stats {
+	defer c.mutex.Unlock()
+	c.mutex.Lock()
+
+	var s = c.stats
+	s.Entries = len(c.entries)
+	s.Bytes = c.bytes
+
+	return s
+}
+
+// put a canvas in front, evicting the least recently used ones beyond MaxBytes; the lock must be held
+func (c *Cache) put(canvas ascanvas.Canvas) {
+	if e, ok := c.entries[canvas.Id]; ok {
+		c.remove(e)
+	}
+
+	// too large to be cached without evicting everything else
+	if len(canvas.Content) > c.MaxBytes/2 {
+		return
+	}
+
+	c.entries[canvas.Id] = c.recent.PushFront(canvas)
+	c.bytes += len(canvas.Content)
+
+	for c.bytes > c.MaxBytes {
+		c.remove(c.recent.Back())
+		c.stats.Evictions += 1
+	}
+}
+
+// remove an entry; the lock must be held
+func (c *Cache) remove(e *list.Element) {
+	var canvas = c.recent.Remove(e).(ascanvas.Canvas)
+
+	delete(c.entries, canvas.Id)
+	c.bytes -= len(canvas.Content)
+}
+
+// invalidateList and any read in progress; the lock must be held
+func (c *Cache) invalidateList() {
+	c.generation += 1
+	c.listed = false
+	c.canvases = nil
+}
+
+func size(canvases []ascanvas.Canvas) int {
+	var n = 0
+	for i := range canvases {
+		n += len(canvases[i].Content)
+	}
+
+	return n
+}
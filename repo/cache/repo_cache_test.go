@@ -0,0 +1,223 @@
+package cache_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	bm "github.com/fluxynet/ascanvas/broadcaster/memory"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/repo/cache"
+	"github.com/fluxynet/ascanvas/repo/memory"
+)
+
+func TestCache(t *testing.T) {
+	conformance.TestRepository(t, func(t *testing.T) ascanvas.CanvasRepository {
+		return cache.New(memory.New(), 0)
+	})
+}
+
+func canvas(id string, size int) ascanvas.Canvas {
+	return ascanvas.Canvas{Id: id, Name: id, Content: strings.Repeat(".", size), Width: size, Height: 1}
+}
+
+func TestCache_LRU(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		repo = memory.New()
+		c    = cache.New(repo, 100)
+	)
+
+	for _, cv := range []ascanvas.Canvas{canvas("1", 30), canvas("2", 30), canvas("3", 50)} {
+		if err := repo.Create(ctx, cv); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	// 1 is used again, so 2 is evicted for 3, then 3 for 2
+	for _, id := range []string{"1", "2", "1", "3", "1", "2"} {
+		if _, err := c.Get(ctx, id); err != nil {
+			t.Fatalf("Get(%s) error = %v", id, err)
+		}
+	}
+
+	var want = cache.Stats{Hits: 2, Misses: 4, Evictions: 2, Entries: 2, Bytes: 60}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats() got = %+v, want %+v", got, want)
+	}
+
+	// too large to be cached
+	if err := c.Create(ctx, canvas("4", 51)); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if got := c.Stats(); got.Entries != 2 || got.Bytes != 60 {
+		t.Errorf("Stats() after large Create got = %+v", got)
+	}
+}
+
+func TestCache_WriteThrough(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		repo = memory.New()
+		c    = cache.New(repo, 0)
+		cv   = canvas("1", 4)
+	)
+
+	if err := c.Create(ctx, cv); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	cv.Content = "xxxx"
+	if err := c.Update(ctx, cv); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if got, _ := repo.Get(ctx, "1"); !reflect.DeepEqual(*got, cv) {
+		t.Errorf("repository got = %v, want %v", got, cv)
+	}
+
+	if got, _ := c.Get(ctx, "1"); !reflect.DeepEqual(*got, cv) {
+		t.Errorf("Get() got = %v, want %v", got, cv)
+	}
+
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 0 {
+		t.Errorf("Stats() got = %+v, want 1 hit and no miss", s)
+	}
+
+	for i := 0; i < 2; i++ {
+		if got, _ := c.List(ctx); !reflect.DeepEqual(got, []ascanvas.Canvas{cv}) {
+			t.Errorf("List() got = %v, want %v", got, []ascanvas.Canvas{cv})
+		}
+	}
+
+	if s := c.Stats(); s.Hits != 2 || s.Misses != 1 {
+		t.Errorf("Stats() got = %+v, want 2 hits and 1 miss", s)
+	}
+
+	if err := c.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if got, _ := c.List(ctx); len(got) != 0 {
+		t.Errorf("List() after Delete() got = %v, want empty", got)
+	}
+}
+
+func TestCache_Track(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		repo        = memory.New()
+		broadcaster = bm.New()
+		cv          = canvas("1", 4)
+
+		// two instances sharing a repository and a broadcaster
+		a = &ascanvas.CanvasService{
+			Repo:        cache.New(repo, 0),
+			BroadCaster: broadcaster,
+			Logger:      zaptest.NewLogger(t),
+			Broadcast:   ascanvas.SyncBroadcast,
+		}
+		b = cache.New(repo, 0)
+	)
+
+	defer broadcaster.Close()
+
+	_, events, err := broadcaster.Observe(ctx, ascanvas.ObserveALL)
+	if err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+
+	go b.Track(events)
+
+	if err = repo.Create(ctx, cv); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if _, err = b.Get(ctx, "1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	// presence does not invalidate
+	b.Apply(ascanvas.CanvasEvent{Name: ascanvas.CanvasEventCursor, Canvas: ascanvas.Canvas{Id: "1"}})
+
+	if s := b.Stats(); s.Entries != 1 {
+		t.Errorf("Stats() after cursor event got = %+v, want 1 entry", s)
+	}
+
+	if _, err = a.ApplyFloodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Fill: "x"}); err != nil {
+		t.Fatalf("ApplyFloodfill() error = %v", err)
+	}
+
+	var deadline = time.Now().Add(time.Second)
+	for b.Stats().Entries != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if got, _ := b.Get(ctx, "1"); got.Content != "xxxx" {
+		t.Errorf("Get() after update elsewhere got = %v, want xxxx", got)
+	}
+}
+
+func TestCache_Apply_OwnEvents(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		broadcaster = bm.New()
+		c           = cache.New(memory.New(), 0)
+		s           = &ascanvas.CanvasService{
+			Repo:        c,
+			BroadCaster: broadcaster,
+			Logger:      zaptest.NewLogger(t),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Clock:       ascanvas.StaticClock(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)),
+		}
+	)
+
+	defer broadcaster.Close()
+
+	if err := c.Create(ctx, canvas("1", 4)); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	updated, err := s.ApplyFloodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Fill: "x"})
+	if err != nil {
+		t.Fatalf("ApplyFloodfill() error = %v", err)
+	}
+
+	var before = c.Stats()
+
+	c.Apply(ascanvas.CanvasEvent{Name: ascanvas.CanvasEventUpdated, Canvas: *updated})
+
+	if got, _ := c.Get(ctx, "1"); got.Content != "xxxx" {
+		t.Errorf("Get() after own event got = %v, want xxxx", got)
+	}
+
+	if s := c.Stats(); s.Hits != before.Hits+1 || s.Misses != before.Misses {
+		t.Errorf("Stats() after own event got = %+v, want a hit since %+v", s, before)
+	}
+
+	// the same content updated at another time elsewhere
+	var other = *updated
+	other.UpdatedAt = other.UpdatedAt.Add(time.Second)
+
+	c.Apply(ascanvas.CanvasEvent{Name: ascanvas.CanvasEventUpdated, Canvas: other})
+
+	if s := c.Stats(); s.Entries != 0 {
+		t.Errorf("Stats() after event from elsewhere got = %+v, want no entry", s)
+	}
+
+	if _, err = c.Get(ctx, "1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	c.Apply(ascanvas.CanvasEvent{Name: ascanvas.CanvasEventDeleted, Canvas: *updated})
+
+	if s := c.Stats(); s.Entries != 0 {
+		t.Errorf("Stats() after delete event got = %+v, want no entry", s)
+	}
+}
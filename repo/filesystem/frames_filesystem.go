@@ -0,0 +1,102 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// DirFrames is the directory of a Repository in which Frames keeps frames of animated canvases
+const DirFrames = ".frames"
+
+// Frames keeps the frames of every animated canvas of a Repository as <id>.json in its DirFrames directory,
+// sharing its lock
+type Frames struct {
+	Repo *Repository
+}
+
+// NewFrames of repo, creating its directory if needed
+func NewFrames(repo *Repository) (*Frames, error) {
+	if err := os.MkdirAll(filepath.Join(repo.Dir, DirFrames), 0755); err != nil {
+		return nil, err
+	}
+
+	return &Frames{Repo: repo}, nil
+}
+
+func (f *Frames) Get(ctx context.Context, id string) ([]ascanvas.Frame, error) {
+	var frames = []ascanvas.Frame{}
+
+	if !validID(id) {
+		return frames, nil
+	}
+
+	unlock, err := f.Repo.lock(false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	b, err := os.ReadFile(f.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return frames, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(b, &frames); err != nil {
+		return nil, fmt.Errorf("frames of canvas %s: %w", id, err)
+	}
+
+	return frames, nil
+}
+
+func (f *Frames) Save(ctx context.Context, id string, frames []ascanvas.Frame) error {
+	if !validID(id) {
+		return fmt.Errorf("%w: id %q cannot be used as a file name", ascanvas.ErrInvalidInput, id)
+	}
+
+	var b, err = json.Marshal(append([]ascanvas.Frame{}, frames...))
+	if err != nil {
+		return err
+	}
+
+	unlock, err := f.Repo.lock(true)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	return writeAtomic(f.path(id), append(b, '\n'))
+}
+
+func (f *Frames) Delete(ctx context.Context, id string) error {
+	if !validID(id) {
+		return nil
+	}
+
+	unlock, err := f.Repo.lock(true)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	if err = os.Remove(f.path(id)); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (f *Frames) path(id string) string {
+	return filepath.Join(f.Repo.Dir, DirFrames, id+ExtMeta)
+}
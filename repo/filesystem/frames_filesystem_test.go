@@ -0,0 +1,20 @@
+package filesystem_test
+
+import (
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/repo/filesystem"
+)
+
+func TestFrames(t *testing.T) {
+	conformance.TestFrames(t, func(t *testing.T) ascanvas.CanvasFrameRepository {
+		var frames, err = filesystem.NewFrames(makeRepo(t))
+		if err != nil {
+			t.Fatalf("NewFrames() error = %s", err)
+		}
+
+		return frames
+	})
+}
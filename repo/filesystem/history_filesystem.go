@@ -0,0 +1,140 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+)
+
+const (
+	// DirHistory is the directory of a Repository in which History keeps revisions of canvases
+	DirHistory = ".history"
+
+	// ExtHistory is the extension of the file of revisions of a canvas, one json object per line
+	ExtHistory = ".jsonl"
+)
+
+// History keeps revisions of every canvas of a Repository as <id>.jsonl in its DirHistory directory,
+// appending a line per revision, sharing its lock
+type History struct {
+	Repo *Repository
+}
+
+// NewHistory of repo, creating its directory if needed
+func NewHistory(repo *Repository) (*History, error) {
+	if err := os.MkdirAll(filepath.Join(repo.Dir, DirHistory), 0755); err != nil {
+		return nil, err
+	}
+
+	return &History{Repo: repo}, nil
+}
+
+func (h *History) Append(ctx context.Context, id string, revision ascanvas.Revision) error {
+	if !validID(id) {
+		return fmt.Errorf("%w: id %q cannot be used as a file name", ascanvas.ErrInvalidInput, id)
+	}
+
+	var b, err = json.Marshal(revision)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := h.Repo.lock(true)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	f, err := os.OpenFile(h.path(id), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(append(b, '\n')); err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+func (h *History) List(ctx context.Context, id string) ([]ascanvas.Revision, error) {
+	var revisions = []ascanvas.Revision{}
+
+	if !validID(id) {
+		return revisions, nil
+	}
+
+	unlock, err := h.Repo.lock(false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	f, err := os.Open(h.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return revisions, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer internal.Closed(f)
+
+	var r = bufio.NewReader(f)
+
+	for line := 1; ; line++ {
+		var b, err = r.ReadBytes('\n')
+		if len(bytes.TrimSpace(b)) != 0 {
+			var revision ascanvas.Revision
+			if uerr := json.Unmarshal(b, &revision); uerr != nil {
+				return nil, fmt.Errorf("history of canvas %s, line %d: %w", id, line, uerr)
+			}
+
+			revisions = append(revisions, revision)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return revisions, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (h *History) Delete(ctx context.Context, id string) error {
+	if !validID(id) {
+		return nil
+	}
+
+	unlock, err := h.Repo.lock(true)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	if err = os.Remove(h.path(id)); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (h *History) path(id string) string {
+	return filepath.Join(h.Repo.Dir, DirHistory, id+ExtHistory)
+}
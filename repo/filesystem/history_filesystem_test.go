@@ -0,0 +1,20 @@
+package filesystem_test
+
+import (
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/repo/filesystem"
+)
+
+func TestHistory(t *testing.T) {
+	conformance.TestHistory(t, func(t *testing.T) ascanvas.CanvasHistory {
+		var history, err = filesystem.NewHistory(makeRepo(t))
+		if err != nil {
+			t.Fatalf("NewHistory() error = %s", err)
+		}
+
+		return history
+	})
+}
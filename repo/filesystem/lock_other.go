@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package filesystem
+
+import "os"
+
+// lockFD is a no-op where flock is not available; only goroutines of the same process are serialized
+func lockFD(_ *os.File, _ bool) error {
+	return nil
+}
+
+func unlockFD(_ *os.File) error {
+	return nil
+}
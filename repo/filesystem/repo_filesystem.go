@@ -0,0 +1,377 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+const (
+	// ExtText is the extension of the file holding the art of a canvas, one row per line
+	ExtText = ".txt"
+
+	// ExtMeta is the extension of the sidecar file holding the other attributes of a canvas
+	ExtMeta = ".json"
+
+	// lockFile guards the directory against concurrent writes from other processes
+	lockFile = ".lock"
+)
+
+// ErrMalformed is returned for a text file whose rows do not match the size in its sidecar, e.g. once edited by hand
+var ErrMalformed = errors.New("malformed canvas file")
+
+// Repository stores every canvas as <id>.txt, readable and diffable as is, along with an <id>.json sidecar.
+// Files are replaced atomically and access is serialized with a lock file, so that several processes may share Dir.
+type Repository struct {
+	Dir string
+
+	mutex sync.RWMutex
+}
+
+// meta is the content of the sidecar file
+type meta struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+}
+
+// New Repository in dir, which is created if needed
+func New(dir string) (*Repository, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &Repository{Dir: dir}, nil
+}
+
+func (r *Repository) Create(ctx context.Context, canvas ascanvas.Canvas) error {
+	if err := validate(canvas); err != nil {
+		return err
+	}
+
+	unlock, err := r.lock(true)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	if _, err = os.Stat(r.path(canvas.Id, ExtMeta)); err == nil {
+		return fmt.Errorf("%w: canvas %s already exists", ascanvas.ErrInvalidInput, canvas.Id)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return r.write(canvas)
+}
+
+func (r *Repository) Update(ctx context.Context, canvas ascanvas.Canvas) error {
+	if err := validate(canvas); err != nil {
+		return err
+	}
+
+	unlock, err := r.lock(true)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	// like an sql update, nothing happens when the canvas does not exist
+	if _, err = os.Stat(r.path(canvas.Id, ExtMeta)); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return r.write(canvas)
+}
+
+func (r *Repository) Get(ctx context.Context, id string) (*ascanvas.Canvas, error) {
+	if !validID(id) {
+		return nil, ascanvas.ErrNotFound
+	}
+
+	unlock, err := r.lock(false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	return r.read(id)
+}
+
+func (r *Repository) List(ctx context.Context) ([]ascanvas.Canvas, error) {
+	unlock, err := r.lock(false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	matches, err := filepath.Glob(filepath.Join(r.Dir, "*"+ExtMeta))
+	if err != nil {
+		return nil, err
+	}
+
+	var canvases = make([]ascanvas.Canvas, 0, len(matches))
+
+	for _, m := range matches {
+		var id = strings.TrimSuffix(filepath.Base(m), ExtMeta)
+		if !validID(id) {
+			continue
+		}
+
+		canvas, err := r.read(id)
+		if errors.Is(err, ascanvas.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		canvases = append(canvases, *canvas)
+	}
+
+	sort.Slice(canvases, func(i, j int) bool {
+		return canvases[i].Id < canvases[j].Id
+	})
+
+	return canvases, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	if !validID(id) {
+		return nil
+	}
+
+	unlock, err := r.lock(true)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	// the sidecar goes first, so that a canvas is never listed without its art
+	for _, ext := range []string{ExtMeta, ExtText} {
+		if err = os.Remove(r.path(id, ext)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) path(id, ext string) string {
+	return filepath.Join(r.Dir, id+ext)
+}
+
+// read a canvas; the lock must be held
+func (r *Repository) read(id string) (*ascanvas.Canvas, error) {
+	var b, err = os.ReadFile(r.path(id, ExtMeta))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ascanvas.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var m meta
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("canvas %s: %w", id, err)
+	}
+
+	text, err := os.ReadFile(r.path(id, ExtText))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ascanvas.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var content string
+	if content, err = rows(string(text), m.Width, m.Height); err != nil {
+		return nil, fmt.Errorf("%s: %w", r.path(id, ExtText), err)
+	}
+
+	return &ascanvas.Canvas{
+		Id:      id,
+		Name:    m.Name,
+		Content: content,
+		Width:   m.Width,
+		Height:  m.Height,
+
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+		CreatedBy: m.CreatedBy,
+		UpdatedBy: m.UpdatedBy,
+	}, nil
+}
+
+// rows of a text file as the content of a canvas of width by height, lines possibly ending with \r\n
+func rows(text string, width, height int) (string, error) {
+	var lines = strings.Split(strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r"), "\n")
+
+	if len(lines) != height {
+		return "", fmt.Errorf("%w: %d rows, want %d", ErrMalformed, len(lines), height)
+	}
+
+	for i, line := range lines {
+		if lines[i] = strings.TrimSuffix(line, "\r"); len(lines[i]) != width {
+			return "", fmt.Errorf("%w: row %d is %d long, want %d", ErrMalformed, i+1, len(lines[i]), width)
+		}
+	}
+
+	return strings.Join(lines, ""), nil
+}
+
+// write a canvas, art first; the exclusive lock must be held
+func (r *Repository) write(canvas ascanvas.Canvas) error {
+	var b, err = json.MarshalIndent(meta{
+		Id:     canvas.Id,
+		Name:   canvas.Name,
+		Width:  canvas.Width,
+		Height: canvas.Height,
+
+		CreatedAt: canvas.CreatedAt,
+		UpdatedAt: canvas.UpdatedAt,
+		CreatedBy: canvas.CreatedBy,
+		UpdatedBy: canvas.UpdatedBy,
+	}, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err = writeAtomic(r.path(canvas.Id, ExtText), []byte(Text(canvas))); err != nil {
+		return err
+	}
+
+	return writeAtomic(r.path(canvas.Id, ExtMeta), append(b, '\n'))
+}
+
+// Text of a canvas, one row per line as ascanvas.ParseText parses
+func Text(canvas ascanvas.Canvas) string {
+	var (
+		b       strings.Builder
+		content = canvas.Content
+	)
+
+	if canvas.Width <= 0 {
+		return content + "\n"
+	}
+
+	for len(content) > canvas.Width {
+		b.WriteString(content[:canvas.Width])
+		b.WriteString("\n")
+		content = content[canvas.Width:]
+	}
+
+	b.WriteString(content)
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// writeAtomic writes to a temporary file in the same directory and renames it over filename,
+// so that readers see either the previous or the new content but never a partial one
+func writeAtomic(filename string, data []byte) error {
+	var f, err = os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+
+	var tmp = f.Name()
+
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+
+	return err
+}
+
+// lock the directory, shared for reading or exclusive for writing, returning the function to unlock it
+func (r *Repository) lock(exclusive bool) (func(), error) {
+	if exclusive {
+		r.mutex.Lock()
+	} else {
+		r.mutex.RLock()
+	}
+
+	var unlockMutex = func() {
+		if exclusive {
+			r.mutex.Unlock()
+		} else {
+			r.mutex.RUnlock()
+		}
+	}
+
+	f, err := os.OpenFile(filepath.Join(r.Dir, lockFile), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		unlockMutex()
+		return nil, err
+	}
+
+	if err = lockFD(f, exclusive); err != nil {
+		_ = f.Close()
+		unlockMutex()
+		return nil, err
+	}
+
+	return func() {
+		_ = unlockFD(f)
+		_ = f.Close()
+		unlockMutex()
+	}, nil
+}
+
+// validID tells if an id can safely be used as a file name
+func validID(id string) bool {
+	return id != "" && !strings.HasPrefix(id, ".") && !strings.ContainsAny(id, `/\:`) && filepath.Base(id) == id
+}
+
+func validate(canvas ascanvas.Canvas) error {
+	if !validID(canvas.Id) {
+		return fmt.Errorf("%w: id %q cannot be used as a file name", ascanvas.ErrInvalidInput, canvas.Id)
+	}
+
+	if strings.ContainsAny(canvas.Content, "\r\n") {
+		return fmt.Errorf("%w: content cannot contain line breaks", ascanvas.ErrInvalidInput)
+	}
+
+	// rows are read back as per the size, see rows
+	if canvas.Width < 1 || canvas.Height < 1 || len(canvas.Content) != canvas.Width*canvas.Height {
+		return fmt.Errorf("%w: content must be %d by %d", ascanvas.ErrInvalidInput, canvas.Width, canvas.Height)
+	}
+
+	return nil
+}
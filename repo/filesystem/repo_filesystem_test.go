@@ -0,0 +1,183 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/internal"
+	"github.com/fluxynet/ascanvas/repo/filesystem"
+)
+
+func makeRepo(t *testing.T) *filesystem.Repository {
+	var r, err = filesystem.New(filepath.Join(t.TempDir(), "canvas"))
+	if err != nil {
+		t.Fatalf("New() error = %s", err)
+	}
+
+	return r
+}
+
+func TestRepository(t *testing.T) {
+	conformance.TestRepository(t, func(t *testing.T) ascanvas.CanvasRepository {
+		return makeRepo(t)
+	})
+}
+
+func TestRepository_Files(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		r      = makeRepo(t)
+		canvas = internal.CanvasFromText("1", "Foo", `
+..x..
+.xxx.
+xxxxx`)
+	)
+
+	if err := r.Create(ctx, *canvas); err != nil {
+		t.Fatalf("Create() error = %s", err)
+	}
+
+	text, err := os.ReadFile(filepath.Join(r.Dir, "1"+filesystem.ExtText))
+	if err != nil {
+		t.Fatalf("failed to read text file: %s", err)
+	}
+
+	if got, want := string(text), "..x..\n.xxx.\nxxxxx\n"; got != want {
+		t.Errorf("text file got = %q, want %q", got, want)
+	}
+
+	if got := internal.CanvasFromText("1", "Foo", string(text)); !reflect.DeepEqual(got, canvas) {
+		t.Errorf("CanvasFromText() of text file got = %v, want %v", got, canvas)
+	}
+
+	meta, err := os.ReadFile(filepath.Join(r.Dir, "1"+filesystem.ExtMeta))
+	if err != nil {
+		t.Fatalf("failed to read sidecar file: %s", err)
+	}
+
+	if !strings.Contains(string(meta), `"name": "Foo"`) {
+		t.Errorf("sidecar file got = %s, want name Foo", meta)
+	}
+
+	// only the canvas files and the lock remain, no temporary files
+	entries, _ := os.ReadDir(r.Dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	if want := []string{".lock", "1.json", "1.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("files got = %v, want %v", names, want)
+	}
+}
+
+func TestRepository_HandEdited(t *testing.T) {
+	var ctx = context.Background()
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr error
+	}{
+		{name: "as written", text: "ab.\n.cd\n", want: "ab..cd"},
+		{name: "no final line break", text: "ab.\n.cd", want: "ab..cd"},
+		{name: "crlf", text: "ab.\r\n.cd\r\n", want: "ab..cd"},
+		{name: "row too short", text: "ab\n.cd\n", wantErr: filesystem.ErrMalformed},
+		{name: "row too long", text: "ab.\n.cde\n", wantErr: filesystem.ErrMalformed},
+		{name: "row missing", text: "ab.\n", wantErr: filesystem.ErrMalformed},
+		{name: "row too many", text: "ab.\n.cd\n...\n", wantErr: filesystem.ErrMalformed},
+		{name: "blank line", text: "ab.\n.cd\n\n", wantErr: filesystem.ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r = makeRepo(t)
+
+			if err := r.Create(ctx, ascanvas.Canvas{Id: "1", Content: "......", Width: 3, Height: 2}); err != nil {
+				t.Fatalf("Create() error = %s", err)
+			}
+
+			var path = filepath.Join(r.Dir, "1"+filesystem.ExtText)
+			if err := os.WriteFile(path, []byte(tt.text), 0644); err != nil {
+				t.Fatalf("failed to edit text file: %s", err)
+			}
+
+			var got, err = r.Get(ctx, "1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get() error = %v, want %v", err, tt.wantErr)
+			} else if err != nil {
+				if !strings.Contains(err.Error(), path) {
+					t.Errorf("Get() error = %v, want it to name %s", err, path)
+				}
+
+				return
+			}
+
+			if got.Content != tt.want {
+				t.Errorf("Get() content = %q, want %q", got.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_InvalidID(t *testing.T) {
+	var (
+		ctx = context.Background()
+		r   = makeRepo(t)
+	)
+
+	for _, id := range []string{"", "../1", "a/b", ".hidden"} {
+		if err := r.Create(ctx, ascanvas.Canvas{Id: id, Content: "."}); !errors.Is(err, ascanvas.ErrInvalidInput) {
+			t.Errorf("Create(%q) error = %v, want ErrInvalidInput", id, err)
+		}
+
+		if _, err := r.Get(ctx, id); !errors.Is(err, ascanvas.ErrNotFound) {
+			t.Errorf("Get(%q) error = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestRepository_Concurrent(t *testing.T) {
+	var (
+		ctx = context.Background()
+		a   = makeRepo(t)
+		b   = &filesystem.Repository{Dir: a.Dir}
+		wg  sync.WaitGroup
+	)
+
+	// two instances sharing a directory, as two processes would
+	for i, r := range []*filesystem.Repository{a, b} {
+		for j := 0; j < 20; j++ {
+			wg.Add(1)
+
+			go func(r *filesystem.Repository, id string) {
+				defer wg.Done()
+
+				var canvas = ascanvas.Canvas{Id: id, Name: id, Content: strings.Repeat(id[:1], 6), Width: 3, Height: 2}
+				if err := r.Create(ctx, canvas); err != nil {
+					t.Errorf("Create() error = %s", err)
+				} else if _, err = r.List(ctx); err != nil {
+					t.Errorf("List() error = %s", err)
+				}
+			}(r, fmt.Sprintf("%d-%02d", i, j))
+		}
+	}
+
+	wg.Wait()
+
+	if got, err := b.List(ctx); err != nil {
+		t.Errorf("List() error = %s", err)
+	} else if len(got) != 40 {
+		t.Errorf("List() got %d canvases, want 40", len(got))
+	}
+}
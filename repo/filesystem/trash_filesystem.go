@@ -0,0 +1,186 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// DirTrash is the directory of a Repository in which Trash keeps deleted canvases
+const DirTrash = ".trash"
+
+// Trash keeps every deleted canvas of a Repository as <id>.json in its DirTrash directory, sharing its lock
+type Trash struct {
+	Repo *Repository
+}
+
+// NewTrash of repo, creating its directory if needed
+func NewTrash(repo *Repository) (*Trash, error) {
+	if err := os.MkdirAll(filepath.Join(repo.Dir, DirTrash), 0755); err != nil {
+		return nil, err
+	}
+
+	return &Trash{Repo: repo}, nil
+}
+
+func (t *Trash) Put(ctx context.Context, canvas ascanvas.DeletedCanvas) error {
+	if !validID(canvas.Id) {
+		return fmt.Errorf("%w: id %q cannot be used as a file name", ascanvas.ErrInvalidInput, canvas.Id)
+	}
+
+	var b, err = json.MarshalIndent(canvas, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	unlock, err := t.Repo.lock(true)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	return writeAtomic(t.path(canvas.Id), append(b, '\n'))
+}
+
+func (t *Trash) Get(ctx context.Context, id string) (*ascanvas.DeletedCanvas, error) {
+	if !validID(id) {
+		return nil, ascanvas.ErrNotFound
+	}
+
+	unlock, err := t.Repo.lock(false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	return t.read(t.path(id))
+}
+
+func (t *Trash) List(ctx context.Context) ([]ascanvas.DeletedCanvas, error) {
+	unlock, err := t.Repo.lock(false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	canvases, err := t.list()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(canvases, func(i, j int) bool {
+		var a, b = canvases[i], canvases[j]
+		return a.DeletedAt.After(b.DeletedAt) || (a.DeletedAt.Equal(b.DeletedAt) && a.Id < b.Id)
+	})
+
+	return canvases, nil
+}
+
+func (t *Trash) Remove(ctx context.Context, id string) error {
+	if !validID(id) {
+		return nil
+	}
+
+	unlock, err := t.Repo.lock(true)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	if err = os.Remove(t.path(id)); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (t *Trash) Expire(ctx context.Context, before time.Time) ([]ascanvas.DeletedCanvas, error) {
+	unlock, err := t.Repo.lock(true)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	canvases, err := t.list()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []ascanvas.DeletedCanvas
+
+	for _, c := range canvases {
+		if !c.DeletedAt.Before(before) {
+			continue
+		}
+
+		if err = os.Remove(t.path(c.Id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return expired, err
+		}
+
+		expired = append(expired, c)
+	}
+
+	sort.Slice(expired, func(i, j int) bool {
+		var a, b = expired[i], expired[j]
+		return a.DeletedAt.Before(b.DeletedAt) || (a.DeletedAt.Equal(b.DeletedAt) && a.Id < b.Id)
+	})
+
+	return expired, nil
+}
+
+func (t *Trash) path(id string) string {
+	return filepath.Join(t.Repo.Dir, DirTrash, id+ExtMeta)
+}
+
+// read a deleted canvas; the lock must be held
+func (t *Trash) read(filename string) (*ascanvas.DeletedCanvas, error) {
+	var b, err = os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ascanvas.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var canvas ascanvas.DeletedCanvas
+	if err = json.Unmarshal(b, &canvas); err != nil {
+		return nil, fmt.Errorf("deleted canvas %s: %w", filepath.Base(filename), err)
+	}
+
+	return &canvas, nil
+}
+
+// list all deleted canvases, in no particular order; the lock must be held
+func (t *Trash) list() ([]ascanvas.DeletedCanvas, error) {
+	var matches, err = filepath.Glob(filepath.Join(t.Repo.Dir, DirTrash, "*"+ExtMeta))
+	if err != nil {
+		return nil, err
+	}
+
+	var canvases = make([]ascanvas.DeletedCanvas, 0, len(matches))
+
+	for _, m := range matches {
+		canvas, err := t.read(m)
+		if errors.Is(err, ascanvas.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		canvases = append(canvases, *canvas)
+	}
+
+	return canvases, nil
+}
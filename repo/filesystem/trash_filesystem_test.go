@@ -0,0 +1,46 @@
+package filesystem_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/repo/filesystem"
+)
+
+func TestTrash(t *testing.T) {
+	conformance.TestTrash(t, func(t *testing.T) ascanvas.CanvasTrash {
+		var trash, err = filesystem.NewTrash(makeRepo(t))
+		if err != nil {
+			t.Fatalf("NewTrash() error = %s", err)
+		}
+
+		return trash
+	})
+}
+
+func TestTrash_NotListed(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		repo       = makeRepo(t)
+		trash, err = filesystem.NewTrash(repo)
+	)
+
+	if err != nil {
+		t.Fatalf("NewTrash() error = %s", err)
+	}
+
+	var canvas = ascanvas.Canvas{Id: "1", Name: "One", Content: "1", Width: 1, Height: 1}
+	if err = trash.Put(ctx, ascanvas.DeletedCanvas{Canvas: canvas}); err != nil {
+		t.Fatalf("Put() error = %s", err)
+	}
+
+	if got, err := repo.List(ctx); err != nil || len(got) != 0 {
+		t.Errorf("List() got = %v, %v, want no canvas", got, err)
+	}
+
+	if err = trash.Put(ctx, ascanvas.DeletedCanvas{Canvas: ascanvas.Canvas{Id: "../1"}}); err == nil {
+		t.Errorf("Put() of invalid id error = nil")
+	}
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// Frames keeps frames of animated canvases in a map, safe for concurrent use.
+// When Snapshot is set, they are loaded from that file by LoadFrames and written back to it by Close.
+type Frames struct {
+	Snapshot string
+
+	frames map[string][]ascanvas.Frame
+	mutex  sync.RWMutex
+}
+
+// NewFrames without any animated canvas
+func NewFrames() *Frames {
+	return &Frames{
+		frames: make(map[string][]ascanvas.Frame),
+	}
+}
+
+// LoadFrames from a snapshot file, which is also where they are saved on Close; a missing file gives no frames
+func LoadFrames(snapshot string) (*Frames, error) {
+	var f = NewFrames()
+	f.Snapshot = snapshot
+
+	if err := readSnapshot(snapshot, &f.frames); err != nil {
+		return nil, err
+	} else if f.frames == nil {
+		f.frames = make(map[string][]ascanvas.Frame)
+	}
+
+	return f, nil
+}
+
+func (f *Frames) Get(ctx context.Context, id string) ([]ascanvas.Frame, error) {
+	defer f.mutex.RUnlock()
+	f.mutex.RLock()
+
+	return append([]ascanvas.Frame{}, f.frames[id]...), nil
+}
+
+func (f *Frames) Save(ctx context.Context, id string, frames []ascanvas.Frame) error {
+	defer f.mutex.Unlock()
+	f.mutex.Lock()
+
+	f.frames[id] = append([]ascanvas.Frame{}, frames...)
+
+	return nil
+}
+
+func (f *Frames) Delete(ctx context.Context, id string) error {
+	defer f.mutex.Unlock()
+	f.mutex.Lock()
+
+	delete(f.frames, id)
+
+	return nil
+}
+
+// Close saves the snapshot, if any
+func (f *Frames) Close() error {
+	if f.Snapshot == "" {
+		return nil
+	}
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return writeSnapshot(f.Snapshot, f.frames)
+}
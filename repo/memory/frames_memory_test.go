@@ -0,0 +1,57 @@
+package memory_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/repo/memory"
+)
+
+func TestFrames(t *testing.T) {
+	conformance.TestFrames(t, func(t *testing.T) ascanvas.CanvasFrameRepository {
+		return memory.NewFrames()
+	})
+}
+
+func TestFrames_Snapshot(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		snapshot = filepath.Join(t.TempDir(), "frames.json")
+		frames   = []ascanvas.Frame{{Content: "x", Delay: 100}, {Content: "o", Delay: 200}}
+	)
+
+	f, err := memory.LoadFrames(snapshot)
+	if err != nil {
+		t.Fatalf("LoadFrames() of missing snapshot error = %s", err)
+	}
+
+	if err = f.Save(ctx, "1", frames); err != nil {
+		t.Fatalf("Save() error = %s", err)
+	}
+
+	if err = f.Close(); err != nil {
+		t.Fatalf("Close() error = %s", err)
+	}
+
+	loaded, err := memory.LoadFrames(snapshot)
+	if err != nil {
+		t.Fatalf("LoadFrames() error = %s", err)
+	}
+
+	if got, _ := loaded.Get(ctx, "1"); !reflect.DeepEqual(got, frames) {
+		t.Errorf("Get() after LoadFrames() got = %v, want %v", got, frames)
+	}
+
+	if err = os.WriteFile(snapshot, []byte("not json"), 0644); err != nil {
+		t.Fatalf("failed to write snapshot: %s", err)
+	}
+
+	if _, err = memory.LoadFrames(snapshot); err == nil {
+		t.Errorf("LoadFrames() of invalid snapshot error = nil, want error")
+	}
+}
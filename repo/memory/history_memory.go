@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// History keeps revisions of canvases in a map, safe for concurrent use.
+// When Snapshot is set, they are loaded from that file by LoadHistory and written back to it by Close.
+type History struct {
+	Snapshot string
+
+	revisions map[string][]ascanvas.Revision
+	mutex     sync.RWMutex
+}
+
+// NewHistory without any revision
+func NewHistory() *History {
+	return &History{
+		revisions: make(map[string][]ascanvas.Revision),
+	}
+}
+
+// LoadHistory from a snapshot file, which is also where it is saved on Close; a missing file gives no revisions
+func LoadHistory(snapshot string) (*History, error) {
+	var h = NewHistory()
+	h.Snapshot = snapshot
+
+	if err := readSnapshot(snapshot, &h.revisions); err != nil {
+		return nil, err
+	} else if h.revisions == nil {
+		h.revisions = make(map[string][]ascanvas.Revision)
+	}
+
+	return h, nil
+}
+
+func (h *History) Append(ctx context.Context, id string, revision ascanvas.Revision) error {
+	defer h.mutex.Unlock()
+	h.mutex.Lock()
+
+	h.revisions[id] = append(h.revisions[id], revision)
+
+	return nil
+}
+
+func (h *History) List(ctx context.Context, id string) ([]ascanvas.Revision, error) {
+	defer h.mutex.RUnlock()
+	h.mutex.RLock()
+
+	return append([]ascanvas.Revision{}, h.revisions[id]...), nil
+}
+
+func (h *History) Delete(ctx context.Context, id string) error {
+	defer h.mutex.Unlock()
+	h.mutex.Lock()
+
+	delete(h.revisions, id)
+
+	return nil
+}
+
+// Close saves the snapshot, if any
+func (h *History) Close() error {
+	if h.Snapshot == "" {
+		return nil
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return writeSnapshot(h.Snapshot, h.revisions)
+}
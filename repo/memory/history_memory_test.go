@@ -0,0 +1,63 @@
+package memory_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/repo/memory"
+)
+
+func TestHistory(t *testing.T) {
+	conformance.TestHistory(t, func(t *testing.T) ascanvas.CanvasHistory {
+		return memory.NewHistory()
+	})
+}
+
+func TestHistory_Snapshot(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		snapshot  = filepath.Join(t.TempDir(), "history.json")
+		revisions = []ascanvas.Revision{
+			{Op: ascanvas.RevisionCreated, Content: "..", Width: 2, Height: 1, At: time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)},
+			{Op: ascanvas.RevisionRectangle, Content: "x.", Width: 2, Height: 1, At: time.Date(2021, 9, 1, 0, 1, 0, 0, time.UTC), By: "carol"},
+		}
+	)
+
+	h, err := memory.LoadHistory(snapshot)
+	if err != nil {
+		t.Fatalf("LoadHistory() of missing snapshot error = %s", err)
+	}
+
+	for i := range revisions {
+		if err = h.Append(ctx, "1", revisions[i]); err != nil {
+			t.Fatalf("Append() error = %s", err)
+		}
+	}
+
+	if err = h.Close(); err != nil {
+		t.Fatalf("Close() error = %s", err)
+	}
+
+	loaded, err := memory.LoadHistory(snapshot)
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %s", err)
+	}
+
+	if got, _ := loaded.List(ctx, "1"); !reflect.DeepEqual(got, revisions) {
+		t.Errorf("List() after LoadHistory() got = %v, want %v", got, revisions)
+	}
+
+	if err = os.WriteFile(snapshot, []byte("not json"), 0644); err != nil {
+		t.Fatalf("failed to write snapshot: %s", err)
+	}
+
+	if _, err = memory.LoadHistory(snapshot); err == nil {
+		t.Errorf("LoadHistory() of invalid snapshot error = nil, want error")
+	}
+}
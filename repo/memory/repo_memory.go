@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// Memory keeps canvases in a map, safe for concurrent use.
+// When Snapshot is set, canvases are loaded from that file by Load and written back to it by Close.
+type Memory struct {
+	Snapshot string
+
+	canvases map[string]ascanvas.Canvas
+	mutex    sync.RWMutex
+}
+
+// New empty Memory, without snapshot
+func New() *Memory {
+	return &Memory{
+		canvases: make(map[string]ascanvas.Canvas),
+	}
+}
+
+// Load Memory from a snapshot file, which is also where it is saved on Close; a missing file gives an empty Memory
+func Load(snapshot string) (*Memory, error) {
+	var m = New()
+	m.Snapshot = snapshot
+
+	var canvases []ascanvas.Canvas
+	if err := readSnapshot(snapshot, &canvases); err != nil {
+		return nil, err
+	}
+
+	for i := range canvases {
+		m.canvases[canvases[i].Id] = canvases[i]
+	}
+
+	return m, nil
+}
+
+func (m *Memory) Create(ctx context.Context, canvas ascanvas.Canvas) error {
+	defer m.mutex.Unlock()
+	m.mutex.Lock()
+
+	if _, ok := m.canvases[canvas.Id]; ok {
+		return fmt.Errorf("%w: canvas %s already exists", ascanvas.ErrInvalidInput, canvas.Id)
+	}
+
+	m.canvases[canvas.Id] = canvas
+
+	return nil
+}
+
+func (m *Memory) Update(ctx context.Context, canvas ascanvas.Canvas) error {
+	defer m.mutex.Unlock()
+	m.mutex.Lock()
+
+	// like an sql update, nothing happens when the canvas does not exist
+	if _, ok := m.canvases[canvas.Id]; ok {
+		m.canvases[canvas.Id] = canvas
+	}
+
+	return nil
+}
+
+func (m *Memory) Get(ctx context.Context, id string) (*ascanvas.Canvas, error) {
+	defer m.mutex.RUnlock()
+	m.mutex.RLock()
+
+	var canvas, ok = m.canvases[id]
+	if !ok {
+		return nil, ascanvas.ErrNotFound
+	}
+
+	return &canvas, nil
+}
+
+func (m *Memory) List(ctx context.Context) ([]ascanvas.Canvas, error) {
+	defer m.mutex.RUnlock()
+	m.mutex.RLock()
+
+	return m.list(), nil
+}
+
+func (m *Memory) Delete(ctx context.Context, id string) error {
+	defer m.mutex.Unlock()
+	m.mutex.Lock()
+
+	delete(m.canvases, id)
+
+	return nil
+}
+
+// Save all canvases to a snapshot file, replacing it atomically
+func (m *Memory) Save(snapshot string) error {
+	m.mutex.RLock()
+	var canvases = m.list()
+	m.mutex.RUnlock()
+
+	return writeSnapshot(snapshot, canvases)
+}
+
+// Close saves the snapshot, if any
+func (m *Memory) Close() error {
+	if m.Snapshot == "" {
+		return nil
+	}
+
+	return m.Save(m.Snapshot)
+}
+
+// list canvases ordered by id; the lock must be held
+func (m *Memory) list() []ascanvas.Canvas {
+	var canvases = make([]ascanvas.Canvas, 0, len(m.canvases))
+	for _, c := range m.canvases {
+		canvases = append(canvases, c)
+	}
+
+	sort.Slice(canvases, func(i, j int) bool {
+		return canvases[i].Id < canvases[j].Id
+	})
+
+	return canvases
+}
@@ -0,0 +1,111 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/repo/memory"
+)
+
+func TestMemory(t *testing.T) {
+	conformance.TestRepository(t, func(t *testing.T) ascanvas.CanvasRepository {
+		return memory.New()
+	})
+}
+
+func TestMemory_Snapshot(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		snapshot = filepath.Join(t.TempDir(), "snapshot.json")
+		canvases = []ascanvas.Canvas{
+			{Id: "1", Name: "Foo", Content: "..x..x", Width: 3, Height: 2},
+			{Id: "2", Name: "Bar", Content: "o", Width: 1, Height: 1},
+		}
+	)
+
+	m, err := memory.Load(snapshot)
+	if err != nil {
+		t.Fatalf("Load() of missing snapshot error = %s", err)
+	}
+
+	for i := range canvases {
+		if err = m.Create(ctx, canvases[i]); err != nil {
+			t.Fatalf("Create() error = %s", err)
+		}
+	}
+
+	if err = m.Close(); err != nil {
+		t.Fatalf("Close() error = %s", err)
+	}
+
+	loaded, err := memory.Load(snapshot)
+	if err != nil {
+		t.Fatalf("Load() error = %s", err)
+	}
+
+	if got, _ := loaded.List(ctx); !reflect.DeepEqual(got, canvases) {
+		t.Errorf("List() after Load() got = %v, want %v", got, canvases)
+	}
+
+	if err = os.WriteFile(snapshot, []byte("not json"), 0644); err != nil {
+		t.Fatalf("failed to write snapshot: %s", err)
+	}
+
+	if _, err = memory.Load(snapshot); err == nil {
+		t.Errorf("Load() of invalid snapshot error = nil, want error")
+	}
+
+	if err = memory.New().Close(); err != nil {
+		t.Errorf("Close() without snapshot error = %s", err)
+	}
+}
+
+func TestMemory_Concurrent(t *testing.T) {
+	var (
+		ctx = context.Background()
+		m   = memory.New()
+		wg  sync.WaitGroup
+	)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(id string) {
+			defer wg.Done()
+
+			var canvas = ascanvas.Canvas{Id: id, Name: id, Content: "..", Width: 2, Height: 1}
+			if err := m.Create(ctx, canvas); err != nil {
+				t.Errorf("Create() error = %s", err)
+			}
+
+			canvas.Content = "xx"
+			if err := m.Update(ctx, canvas); err != nil {
+				t.Errorf("Update() error = %s", err)
+			}
+
+			if got, err := m.Get(ctx, id); err != nil || got.Content != "xx" {
+				t.Errorf("Get() got = %v, error = %v", got, err)
+			}
+
+			_, _ = m.List(ctx)
+		}(fmt.Sprintf("%02d", i))
+	}
+
+	wg.Wait()
+
+	if got, _ := m.List(ctx); len(got) != 50 {
+		t.Errorf("List() got %d canvases, want 50", len(got))
+	}
+
+	if err := m.Create(ctx, ascanvas.Canvas{Id: "01"}); !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("Create() of duplicate error = %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// readSnapshot file into v, leaving it untouched when the file is missing
+func readSnapshot(snapshot string, v interface{}) error {
+	var b, err = os.ReadFile(snapshot)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("snapshot %s: %w", snapshot, err)
+	}
+
+	return nil
+}
+
+// writeSnapshot of v, replacing the file atomically
+func writeSnapshot(snapshot string, v interface{}) error {
+	var b, err = json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(snapshot), "."+filepath.Base(snapshot)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(b); err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), snapshot)
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+
+	return err
+}
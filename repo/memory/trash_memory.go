@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+// Trash keeps deleted canvases in a map, safe for concurrent use.
+// When Snapshot is set, they are loaded from that file by LoadTrash and written back to it by Close.
+type Trash struct {
+	Snapshot string
+
+	canvases map[string]ascanvas.DeletedCanvas
+	mutex    sync.RWMutex
+}
+
+// NewTrash that is empty
+func NewTrash() *Trash {
+	return &Trash{
+		canvases: make(map[string]ascanvas.DeletedCanvas),
+	}
+}
+
+// LoadTrash from a snapshot file, which is also where it is saved on Close; a missing file gives an empty Trash
+func LoadTrash(snapshot string) (*Trash, error) {
+	var t = NewTrash()
+	t.Snapshot = snapshot
+
+	var canvases []ascanvas.DeletedCanvas
+	if err := readSnapshot(snapshot, &canvases); err != nil {
+		return nil, err
+	}
+
+	for i := range canvases {
+		t.canvases[canvases[i].Id] = canvases[i]
+	}
+
+	return t, nil
+}
+
+func (t *Trash) Put(ctx context.Context, canvas ascanvas.DeletedCanvas) error {
+	defer t.mutex.Unlock()
+	t.mutex.Lock()
+
+	t.canvases[canvas.Id] = canvas
+
+	return nil
+}
+
+func (t *Trash) Get(ctx context.Context, id string) (*ascanvas.DeletedCanvas, error) {
+	defer t.mutex.RUnlock()
+	t.mutex.RLock()
+
+	var canvas, ok = t.canvases[id]
+	if !ok {
+		return nil, ascanvas.ErrNotFound
+	}
+
+	return &canvas, nil
+}
+
+func (t *Trash) List(ctx context.Context) ([]ascanvas.DeletedCanvas, error) {
+	t.mutex.RLock()
+
+	var canvases = make([]ascanvas.DeletedCanvas, 0, len(t.canvases))
+	for _, c := range t.canvases {
+		canvases = append(canvases, c)
+	}
+
+	t.mutex.RUnlock()
+
+	sort.Slice(canvases, func(i, j int) bool {
+		var a, b = canvases[i], canvases[j]
+		return a.DeletedAt.After(b.DeletedAt) || (a.DeletedAt.Equal(b.DeletedAt) && a.Id < b.Id)
+	})
+
+	return canvases, nil
+}
+
+func (t *Trash) Remove(ctx context.Context, id string) error {
+	defer t.mutex.Unlock()
+	t.mutex.Lock()
+
+	delete(t.canvases, id)
+
+	return nil
+}
+
+func (t *Trash) Expire(ctx context.Context, before time.Time) ([]ascanvas.DeletedCanvas, error) {
+	defer t.mutex.Unlock()
+	t.mutex.Lock()
+
+	var expired []ascanvas.DeletedCanvas
+
+	for id, c := range t.canvases {
+		if c.DeletedAt.Before(before) {
+			expired = append(expired, c)
+			delete(t.canvases, id)
+		}
+	}
+
+	sort.Slice(expired, func(i, j int) bool {
+		var a, b = expired[i], expired[j]
+		return a.DeletedAt.Before(b.DeletedAt) || (a.DeletedAt.Equal(b.DeletedAt) && a.Id < b.Id)
+	})
+
+	return expired, nil
+}
+
+// Close saves the snapshot, if any
+func (t *Trash) Close() error {
+	if t.Snapshot == "" {
+		return nil
+	}
+
+	var canvases, _ = t.List(context.Background())
+
+	return writeSnapshot(t.Snapshot, canvases)
+}
@@ -0,0 +1,62 @@
+package memory_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/repo/memory"
+)
+
+func TestTrash(t *testing.T) {
+	conformance.TestTrash(t, func(t *testing.T) ascanvas.CanvasTrash {
+		return memory.NewTrash()
+	})
+}
+
+func TestTrash_Snapshot(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		snapshot = filepath.Join(t.TempDir(), "trash.json")
+		deleted  = ascanvas.DeletedCanvas{
+			Canvas:    ascanvas.Canvas{Id: "1", Name: "Foo", Content: "..x..x", Width: 3, Height: 2},
+			DeletedAt: time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC),
+			DeletedBy: "carol",
+		}
+	)
+
+	trash, err := memory.LoadTrash(snapshot)
+	if err != nil {
+		t.Fatalf("LoadTrash() of missing snapshot error = %s", err)
+	}
+
+	if err = trash.Put(ctx, deleted); err != nil {
+		t.Fatalf("Put() error = %s", err)
+	}
+
+	if err = trash.Close(); err != nil {
+		t.Fatalf("Close() error = %s", err)
+	}
+
+	loaded, err := memory.LoadTrash(snapshot)
+	if err != nil {
+		t.Fatalf("LoadTrash() error = %s", err)
+	}
+
+	if got, _ := loaded.List(ctx); !reflect.DeepEqual(got, []ascanvas.DeletedCanvas{deleted}) {
+		t.Errorf("List() after LoadTrash() got = %v, want %v", got, deleted)
+	}
+
+	if err = os.WriteFile(snapshot, []byte("not json"), 0644); err != nil {
+		t.Fatalf("failed to write snapshot: %s", err)
+	}
+
+	if _, err = memory.LoadTrash(snapshot); err == nil {
+		t.Errorf("LoadTrash() of invalid snapshot error = nil, want error")
+	}
+}
@@ -0,0 +1,201 @@
+package sequel
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+)
+
+// CellRegisters persists ascanvas.CellWrite registers in the canvas_cell table
+type CellRegisters struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+func (r CellRegisters) Since(ctx context.Context, id string, seq uint64) ([]ascanvas.CellWrite, error) {
+	var (
+		writes []ascanvas.CellWrite
+
+		rows, err = conn(ctx, r.DB).QueryContext(
+			ctx,
+			r.Dialect.Rebind(`SELECT "x", "y", "value", "lamport", "site", "seq" FROM "canvas_cell" WHERE "canvas_id" = ? AND "seq" > ? ORDER BY "seq"`),
+			id,
+			seq,
+		)
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer internal.Closed(rows)
+
+	for rows.Next() {
+		var w ascanvas.CellWrite
+
+		err = rows.Scan(&w.X, &w.Y, &w.Value, &w.Lamport, &w.Site, &w.Seq)
+		if err != nil {
+			return nil, err
+		}
+
+		writes = append(writes, w)
+	}
+
+	if writes == nil {
+		return []ascanvas.CellWrite{}, rows.Err()
+	}
+
+	return writes, rows.Err()
+}
+
+// Merge writes by comparing and setting every cell, in a transaction started by writing the clock of the canvas
+// so that merges of the same canvas wait for each other; Seq and Lamport timestamps are allocated from that clock
+func (r CellRegisters) Merge(ctx context.Context, id string, writes []ascanvas.CellWrite, apply func(ctx context.Context, applied []ascanvas.CellWrite) error) error {
+	return InTx(ctx, r.DB, func(ctx context.Context) error {
+		var (
+			tx                = conn(ctx, r.DB)
+			seq, lamport, err = r.clock(ctx, tx, id)
+			known             = lamport
+			applied           []ascanvas.CellWrite
+		)
+
+		if err != nil {
+			return err
+		}
+
+		for _, w := range writes {
+			if w.Lamport == 0 {
+				w.Lamport = known + 1
+			}
+
+			w.Seq = seq + 1
+
+			var won bool
+			if won, err = r.set(ctx, tx, id, w); err != nil {
+				return err
+			} else if !won {
+				continue
+			}
+
+			seq = w.Seq
+			if w.Lamport > lamport {
+				lamport = w.Lamport
+			}
+
+			applied = append(applied, w)
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			r.Dialect.Rebind(`UPDATE "canvas_cell_clock" SET "seq" = ?, "lamport" = ? WHERE "canvas_id" = ?`),
+			seq,
+			lamport,
+			id,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		return apply(ctx, applied)
+	})
+}
+
+// clock of a canvas, locked until the end of the transaction; created from its registers the first time
+func (r CellRegisters) clock(ctx context.Context, tx querier, id string) (uint64, uint64, error) {
+	var _, err = tx.ExecContext(
+		ctx,
+		r.Dialect.Rebind(r.Dialect.InsertIgnore(`INSERT INTO "canvas_cell_clock" ("canvas_id", "seq", "lamport")
+SELECT ?, COALESCE(MAX("seq"), 0), COALESCE(MAX("lamport"), 0) FROM "canvas_cell" WHERE "canvas_id" = ?`)),
+		id,
+		id,
+	)
+
+	if err == nil {
+		_, err = tx.ExecContext(ctx, r.Dialect.Rebind(`UPDATE "canvas_cell_clock" SET "seq" = "seq" WHERE "canvas_id" = ?`), id)
+	}
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rows, err := tx.QueryContext(ctx, r.Dialect.Rebind(`SELECT "seq", "lamport" FROM "canvas_cell_clock" WHERE "canvas_id" = ?`), id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer internal.Closed(rows)
+
+	var seq, lamport uint64
+	if rows.Next() {
+		err = rows.Scan(&seq, &lamport)
+	} else if err = rows.Err(); err == nil {
+		err = sql.ErrNoRows
+	}
+
+	return seq, lamport, err
+}
+
+// set the register of a cell to w if w wins over it, telling if it did
+func (r CellRegisters) set(ctx context.Context, tx querier, id string, w ascanvas.CellWrite) (bool, error) {
+	var res, err = tx.ExecContext(
+		ctx,
+		r.Dialect.Rebind(`UPDATE "canvas_cell" SET "value" = ?, "lamport" = ?, "site" = ?, "seq" = ?
+WHERE "canvas_id" = ? AND "x" = ? AND "y" = ? AND ("lamport" < ? OR ("lamport" = ? AND "site" < ?))`),
+		w.Value,
+		w.Lamport,
+		w.Site,
+		w.Seq,
+		id,
+		w.X,
+		w.Y,
+		w.Lamport,
+		w.Lamport,
+		w.Site,
+	)
+
+	var n int64
+	if err == nil {
+		n, err = res.RowsAffected()
+	}
+
+	if err != nil || n != 0 {
+		return n != 0, err
+	}
+
+	// the cell was never written, or its register wins
+	res, err = tx.ExecContext(
+		ctx,
+		r.Dialect.Rebind(r.Dialect.InsertIgnore(`INSERT INTO "canvas_cell" ("canvas_id", "x", "y", "value", "lamport", "site", "seq") VALUES (?,?,?,?,?,?,?)`)),
+		id,
+		w.X,
+		w.Y,
+		w.Value,
+		w.Lamport,
+		w.Site,
+		w.Seq,
+	)
+
+	if err == nil {
+		n, err = res.RowsAffected()
+	}
+
+	return n != 0, err
+}
+
+func (r CellRegisters) Delete(ctx context.Context, id string) error {
+	return InTx(ctx, r.DB, func(ctx context.Context) error {
+		var (
+			tx     = conn(ctx, r.DB)
+			_, err = tx.ExecContext(ctx, r.Dialect.Rebind(`DELETE FROM "canvas_cell" WHERE "canvas_id" = ?`), id)
+		)
+
+		if err == nil {
+			_, err = tx.ExecContext(ctx, r.Dialect.Rebind(`DELETE FROM "canvas_cell_clock" WHERE "canvas_id" = ?`), id)
+		}
+
+		return err
+	})
+}
@@ -0,0 +1,159 @@
+package sequel_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+	"github.com/fluxynet/ascanvas/repo/sequel"
+)
+
+func TestCellRegisters(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = makeDb()
+		r   = sequel.CellRegisters{DB: db}
+	)
+
+	defer internal.Closed(db)
+
+	var writes = []ascanvas.CellWrite{
+		{X: 0, Y: 0, Value: "a", Lamport: 1, Site: "A", Seq: 1},
+		{X: 1, Y: 0, Value: "b", Lamport: 2, Site: "B", Seq: 2},
+		{X: 0, Y: 0, Value: "c", Lamport: 3, Site: "B", Seq: 3},
+	}
+
+	merge(t, r, "1", writes, writes)
+	merge(t, r, "2", writes[:1], writes[:1])
+
+	tests := []struct {
+		name string
+		id   string
+		seq  uint64
+		want []ascanvas.CellWrite
+	}{
+		{
+			name: "all, replaced cell",
+			id:   "1",
+			seq:  0,
+			want: []ascanvas.CellWrite{writes[1], writes[2]},
+		},
+		{
+			name: "since seq 2",
+			id:   "1",
+			seq:  2,
+			want: []ascanvas.CellWrite{writes[2]},
+		},
+		{
+			name: "other canvas",
+			id:   "2",
+			seq:  0,
+			want: []ascanvas.CellWrite{writes[0]},
+		},
+		{
+			name: "unknown canvas",
+			id:   "3",
+			seq:  0,
+			want: []ascanvas.CellWrite{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Since(ctx, tt.id, tt.seq)
+			if err != nil {
+				t.Errorf("Since() error = %v", err)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Since() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := r.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if got, _ := r.Since(ctx, "1", 0); len(got) != 0 {
+		t.Errorf("Since() after Delete() got = %v, want none", got)
+	}
+}
+
+func TestCellRegisters_Merge(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = makeDb()
+		r   = sequel.CellRegisters{DB: db}
+	)
+
+	defer internal.Closed(db)
+
+	merge(t, r, "1", []ascanvas.CellWrite{
+		{X: 0, Y: 0, Value: "a", Lamport: 5, Site: "B"},
+		{X: 1, Y: 0, Value: "b", Lamport: 5, Site: "B"},
+	}, []ascanvas.CellWrite{
+		{X: 0, Y: 0, Value: "a", Lamport: 5, Site: "B", Seq: 1},
+		{X: 1, Y: 0, Value: "b", Lamport: 5, Site: "B", Seq: 2},
+	})
+
+	// older, tie lost to a higher site, tie won, then server writes winning over all
+	merge(t, r, "1", []ascanvas.CellWrite{
+		{X: 0, Y: 0, Value: "c", Lamport: 4, Site: "C"},
+		{X: 0, Y: 0, Value: "d", Lamport: 5, Site: "A"},
+		{X: 1, Y: 0, Value: "e", Lamport: 5, Site: "C"},
+		{X: 2, Y: 0, Value: "f", Site: ascanvas.SiteServer},
+		{X: 0, Y: 0, Value: "g", Site: ascanvas.SiteServer},
+	}, []ascanvas.CellWrite{
+		{X: 1, Y: 0, Value: "e", Lamport: 5, Site: "C", Seq: 3},
+		{X: 2, Y: 0, Value: "f", Lamport: 6, Site: ascanvas.SiteServer, Seq: 4},
+		{X: 0, Y: 0, Value: "g", Lamport: 6, Site: ascanvas.SiteServer, Seq: 5},
+	})
+
+	var failed = errors.New("failed")
+
+	err := r.Merge(ctx, "1", []ascanvas.CellWrite{{X: 3, Y: 0, Value: "h", Lamport: 9, Site: "A"}}, func(ctx context.Context, applied []ascanvas.CellWrite) error {
+		if got, _ := r.Since(ctx, "1", 5); len(got) != 1 {
+			t.Errorf("Since() within Merge() got = %v, want the write merged", got)
+		}
+
+		return failed
+	})
+
+	if err != failed {
+		t.Errorf("Merge() error = %v, want %v", err, failed)
+	}
+
+	if got, _ := r.Since(ctx, "1", 5); len(got) != 0 {
+		t.Errorf("Since() after failed Merge() got = %v, want none", got)
+	}
+
+	// the clock was rolled back as well
+	merge(t, r, "1", []ascanvas.CellWrite{{X: 3, Y: 0, Value: "i", Site: ascanvas.SiteServer}}, []ascanvas.CellWrite{
+		{X: 3, Y: 0, Value: "i", Lamport: 7, Site: ascanvas.SiteServer, Seq: 6},
+	})
+}
+
+// merge writes into the registers of a canvas, failing unless those applied are as wanted
+func merge(t *testing.T, r sequel.CellRegisters, id string, writes, want []ascanvas.CellWrite) {
+	t.Helper()
+
+	var got []ascanvas.CellWrite
+
+	err := r.Merge(context.Background(), id, writes, func(ctx context.Context, applied []ascanvas.CellWrite) error {
+		got = applied
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Merge() applied = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package sequel
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect of sql spoken by a database.
+// Queries are written with "quoted" identifiers and ? placeholders, and rebound for the dialect.
+type Dialect string
+
+const (
+	// DialectSQLite is the default dialect
+	DialectSQLite Dialect = "sqlite"
+
+	// DialectPostgres uses $1 placeholders
+	DialectPostgres Dialect = "postgres"
+
+	// DialectMySQL uses `quoted` identifiers
+	DialectMySQL Dialect = "mysql"
+)
+
+// DialectOf a database/sql driver name; unknown drivers are their own dialect, spoken like DialectSQLite
+func DialectOf(driver string) Dialect {
+	switch driver {
+	case "sqlite", "sqlite3":
+		return DialectSQLite
+	case "postgres", "pgx":
+		return DialectPostgres
+	case "mysql":
+		return DialectMySQL
+	default:
+		return Dialect(driver)
+	}
+}
+
+// Rebind a query written with "quoted" identifiers and ? placeholders for the dialect; the zero value is DialectSQLite.
+// 'string literals' are left as they are, quotes within them being doubled.
+func (d Dialect) Rebind(query string) string {
+	if d != DialectPostgres && d != DialectMySQL {
+		return query
+	}
+
+	var (
+		b       strings.Builder
+		n       = 0
+		literal = false
+	)
+
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			literal = !literal
+			b.WriteRune(r)
+		case literal:
+			b.WriteRune(r)
+		case r == '?' && d == DialectPostgres:
+			n += 1
+			b.WriteString("$" + strconv.Itoa(n))
+		case r == '"' && d == DialectMySQL:
+			b.WriteRune('`')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// InsertIgnore turns an INSERT query into one skipping rows conflicting with existing ones, before Rebind
+func (d Dialect) InsertIgnore(query string) string {
+	switch d {
+	case DialectPostgres:
+		return query + ` ON CONFLICT DO NOTHING`
+	case DialectMySQL:
+		return strings.Replace(query, "INSERT INTO", "INSERT IGNORE INTO", 1)
+	default:
+		return strings.Replace(query, "INSERT INTO", "INSERT OR IGNORE INTO", 1)
+	}
+}
@@ -0,0 +1,111 @@
+package sequel_test
+
+import (
+	"testing"
+
+	"github.com/fluxynet/ascanvas/repo/sequel"
+)
+
+func TestDialect_Rebind(t *testing.T) {
+	var (
+		query   = `SELECT "id" FROM "canvas" WHERE "id" = ? AND "name" = ?`
+		literal = `SELECT "id" FROM "canvas" WHERE "name" = 'who? "me"' AND "id" = ? AND "name" <> 'it''s ?'`
+	)
+
+	tests := []struct {
+		name    string
+		dialect sequel.Dialect
+		query   string
+		want    string
+	}{
+		{
+			name:    "zero value",
+			dialect: "",
+			query:   query,
+			want:    query,
+		},
+		{
+			name:    "sqlite",
+			dialect: sequel.DialectOf("sqlite"),
+			query:   query,
+			want:    query,
+		},
+		{
+			name:    "postgres",
+			dialect: sequel.DialectOf("postgres"),
+			query:   query,
+			want:    `SELECT "id" FROM "canvas" WHERE "id" = $1 AND "name" = $2`,
+		},
+		{
+			name:    "mysql",
+			dialect: sequel.DialectOf("mysql"),
+			query:   query,
+			want:    "SELECT `id` FROM `canvas` WHERE `id` = ? AND `name` = ?",
+		},
+		{
+			name:    "sqlite with literals",
+			dialect: sequel.DialectSQLite,
+			query:   literal,
+			want:    literal,
+		},
+		{
+			name:    "postgres with literals",
+			dialect: sequel.DialectPostgres,
+			query:   literal,
+			want:    `SELECT "id" FROM "canvas" WHERE "name" = 'who? "me"' AND "id" = $1 AND "name" <> 'it''s ?'`,
+		},
+		{
+			name:    "mysql with literals",
+			dialect: sequel.DialectMySQL,
+			query:   literal,
+			want:    "SELECT `id` FROM `canvas` WHERE `name` = 'who? \"me\"' AND `id` = ? AND `name` <> 'it''s ?'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dialect.Rebind(tt.query); got != tt.want {
+				t.Errorf("Rebind() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialect_InsertIgnore(t *testing.T) {
+	var query = `INSERT INTO "canvas" ("id") VALUES (?)`
+
+	tests := []struct {
+		name    string
+		dialect sequel.Dialect
+		want    string
+	}{
+		{
+			name:    "zero value",
+			dialect: "",
+			want:    `INSERT OR IGNORE INTO "canvas" ("id") VALUES (?)`,
+		},
+		{
+			name:    "sqlite",
+			dialect: sequel.DialectSQLite,
+			want:    `INSERT OR IGNORE INTO "canvas" ("id") VALUES (?)`,
+		},
+		{
+			name:    "postgres",
+			dialect: sequel.DialectPostgres,
+			want:    `INSERT INTO "canvas" ("id") VALUES (?) ON CONFLICT DO NOTHING`,
+		},
+		{
+			name:    "mysql",
+			dialect: sequel.DialectMySQL,
+			want:    `INSERT IGNORE INTO "canvas" ("id") VALUES (?)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dialect.InsertIgnore(query); got != tt.want {
+				t.Errorf("InsertIgnore() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
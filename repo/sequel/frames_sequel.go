@@ -0,0 +1,69 @@
+package sequel
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+)
+
+// Frames keeps ascanvas.Frame items of animated canvases in the canvas_frame table
+type Frames struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+func (f Frames) Get(ctx context.Context, id string) ([]ascanvas.Frame, error) {
+	var rows, err = conn(ctx, f.DB).QueryContext(
+		ctx,
+		f.Dialect.Rebind(`SELECT "content", "delay" FROM "canvas_frame" WHERE "canvas_id" = ? ORDER BY "position"`),
+		id,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer internal.Closed(rows)
+
+	var frames = []ascanvas.Frame{}
+
+	for rows.Next() {
+		var frame ascanvas.Frame
+		if err = rows.Scan(&frame.Content, &frame.Delay); err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, frame)
+	}
+
+	return frames, rows.Err()
+}
+
+func (f Frames) Save(ctx context.Context, id string, frames []ascanvas.Frame) error {
+	return InTx(ctx, f.DB, func(ctx context.Context) error {
+		var (
+			tx     = conn(ctx, f.DB)
+			_, err = tx.ExecContext(ctx, f.Dialect.Rebind(`DELETE FROM "canvas_frame" WHERE "canvas_id" = ?`), id)
+		)
+
+		for i := 0; err == nil && i < len(frames); i++ {
+			_, err = tx.ExecContext(
+				ctx,
+				f.Dialect.Rebind(`INSERT INTO "canvas_frame" ("canvas_id", "position", "content", "delay") VALUES (?,?,?,?)`),
+				id,
+				i,
+				frames[i].Content,
+				frames[i].Delay,
+			)
+		}
+
+		return err
+	})
+}
+
+func (f Frames) Delete(ctx context.Context, id string) error {
+	var _, err = conn(ctx, f.DB).ExecContext(ctx, f.Dialect.Rebind(`DELETE FROM "canvas_frame" WHERE "canvas_id" = ?`), id)
+	return err
+}
@@ -0,0 +1,71 @@
+package sequel
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+)
+
+// History keeps ascanvas.Revision items of canvases in the canvas_revision table, in order of seq
+type History struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+func (h History) Append(ctx context.Context, id string, revision ascanvas.Revision) error {
+	var _, err = conn(ctx, h.DB).ExecContext(
+		ctx,
+		h.Dialect.Rebind(`INSERT INTO "canvas_revision" ("canvas_id", "op", "frame", "content", "width", "height", "created_at", "created_by") VALUES (?,?,?,?,?,?,?,?)`),
+		id,
+		string(revision.Op),
+		revision.Frame,
+		revision.Content,
+		revision.Width,
+		revision.Height,
+		ascanvas.UnixNano(revision.At),
+		revision.By,
+	)
+
+	return err
+}
+
+func (h History) List(ctx context.Context, id string) ([]ascanvas.Revision, error) {
+	var rows, err = conn(ctx, h.DB).QueryContext(
+		ctx,
+		h.Dialect.Rebind(`SELECT "op", "frame", "content", "width", "height", "created_at", "created_by" FROM "canvas_revision" WHERE "canvas_id" = ? ORDER BY "seq"`),
+		id,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer internal.Closed(rows)
+
+	var revisions = []ascanvas.Revision{}
+
+	for rows.Next() {
+		var (
+			revision ascanvas.Revision
+			op       string
+			at       int64
+		)
+
+		if err = rows.Scan(&op, &revision.Frame, &revision.Content, &revision.Width, &revision.Height, &at, &revision.By); err != nil {
+			return nil, err
+		}
+
+		revision.Op = ascanvas.RevisionOp(op)
+		revision.At = ascanvas.FromUnixNano(at)
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (h History) Delete(ctx context.Context, id string) error {
+	var _, err = conn(ctx, h.DB).ExecContext(ctx, h.Dialect.Rebind(`DELETE FROM "canvas_revision" WHERE "canvas_id" = ?`), id)
+	return err
+}
@@ -0,0 +1,38 @@
+package sequel_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/internal"
+	"github.com/fluxynet/ascanvas/repo/sequel"
+)
+
+func TestHistory(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		conformance.TestHistory(t, func(t *testing.T) ascanvas.CanvasHistory {
+			var db = makeDb()
+			t.Cleanup(func() { internal.Closed(db) })
+
+			return &sequel.History{DB: db, Dialect: sequel.DialectSQLite}
+		})
+	})
+
+	for _, d := range testDatabases {
+		var dsn = os.Getenv(d.Env)
+		if dsn == "" {
+			continue
+		}
+
+		t.Run(d.Driver, func(t *testing.T) {
+			conformance.TestHistory(t, func(t *testing.T) ascanvas.CanvasHistory {
+				var db = openTestDb(t, d.Driver, dsn)
+				t.Cleanup(func() { internal.Closed(db) })
+
+				return &sequel.History{DB: db, Dialect: sequel.DialectOf(d.Driver)}
+			})
+		})
+	}
+}
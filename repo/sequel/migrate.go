@@ -0,0 +1,300 @@
+package sequel
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fluxynet/ascanvas/internal"
+)
+
+// Migrations are sql files named <version>_<name>.up.sql and <version>_<name>.down.sql, in a directory per driver
+//
+//go:embed migrations
+var Migrations embed.FS
+
+var (
+	// ErrUnknownDriver means there are no migrations for a database driver
+	ErrUnknownDriver = errors.New("no migrations for driver")
+
+	// ErrInvalidMigration means a migration file is not named or paired as expected
+	ErrInvalidMigration = errors.New("invalid migration")
+)
+
+// Migration is a numbered change of schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells if and when a Migration has been applied
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies migrations of a driver in order, keeping track of them in the schema_migrations table.
+// Drivers of the same Dialect share the same migrations.
+type Migrator struct {
+	DB     *sql.DB
+	Driver string
+	FS     fs.FS
+}
+
+// NewMigrator using the embedded Migrations
+func NewMigrator(db *sql.DB, driver string) *Migrator {
+	return &Migrator{
+		DB:     db,
+		Driver: driver,
+		FS:     Migrations,
+	}
+}
+
+// Load migrations of the driver, ordered by version
+func (m *Migrator) Load() ([]Migration, error) {
+	var (
+		dir        = path.Join("migrations", string(DialectOf(m.Driver)))
+		entries, _ = fs.ReadDir(m.FS, dir)
+		byVersion  = make(map[int]*Migration)
+	)
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, m.Driver)
+	}
+
+	for _, e := range entries {
+		var (
+			filename  = e.Name()
+			direction string
+		)
+
+		switch {
+		case strings.HasSuffix(filename, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(filename, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		var (
+			base  = strings.TrimSuffix(filename, "."+direction+".sql")
+			parts = strings.SplitN(base, "_", 2)
+		)
+
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 || version < 1 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMigration, filename)
+		}
+
+		b, err := fs.ReadFile(m.FS, path.Join(dir, filename))
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := byVersion[version]; !ok {
+			byVersion[version] = &Migration{Version: version, Name: parts[1]}
+		} else if byVersion[version].Name != parts[1] {
+			return nil, fmt.Errorf("%w: version %d used more than once", ErrInvalidMigration, version)
+		}
+
+		if direction == "up" {
+			byVersion[version].Up = string(b)
+		} else {
+			byVersion[version].Down = string(b)
+		}
+	}
+
+	var migrations = make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.Up == "" {
+			return nil, fmt.Errorf("%w: version %d has no up migration", ErrInvalidMigration, mg.Version)
+		}
+
+		migrations = append(migrations, *mg)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Status of every migration of the driver
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var migrations, err = m.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses = make([]MigrationStatus, len(migrations))
+	for i := range migrations {
+		statuses[i].Migration = migrations[i]
+
+		if t, ok := applied[migrations[i].Version]; ok {
+			statuses[i].AppliedAt = &t
+		}
+	}
+
+	return statuses, nil
+}
+
+// Up applies all pending migrations, returning those applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var statuses, err = m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+
+	for i := range statuses {
+		if statuses[i].AppliedAt != nil {
+			continue
+		}
+
+		var mg = statuses[i].Migration
+
+		err = m.run(ctx, mg.Up, `INSERT INTO "schema_migrations" ("version", "name", "applied_at") VALUES (?,?,?)`,
+			mg.Version, mg.Name, time.Now().Unix())
+
+		if err != nil {
+			return done, fmt.Errorf("migration %d %s: %w", mg.Version, mg.Name, err)
+		}
+
+		done = append(done, mg)
+	}
+
+	return done, nil
+}
+
+// Down reverts the latest steps applied migrations, returning those reverted
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var statuses, err = m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		if statuses[i].AppliedAt == nil {
+			continue
+		}
+
+		var mg = statuses[i].Migration
+		if mg.Down == "" {
+			return done, fmt.Errorf("%w: version %d has no down migration", ErrInvalidMigration, mg.Version)
+		}
+
+		err = m.run(ctx, mg.Down, `DELETE FROM "schema_migrations" WHERE "version" = ?`, mg.Version)
+		if err != nil {
+			return done, fmt.Errorf("migration %d %s: %w", mg.Version, mg.Name, err)
+		}
+
+		done = append(done, mg)
+	}
+
+	return done, nil
+}
+
+// run the statements of a migration and record it, within a transaction
+func (m *Migrator) run(ctx context.Context, script string, record string, args ...interface{}) error {
+	var tx, err = m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range statements(script) {
+		if _, err = tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, DialectOf(m.Driver).Rebind(record), args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applied migrations and when, creating the schema_migrations table if needed
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	var d = DialectOf(m.Driver)
+
+	var _, err = m.DB.ExecContext(ctx, d.Rebind(`CREATE TABLE IF NOT EXISTS "schema_migrations" (
+    version INT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at BIGINT NOT NULL
+)`))
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.DB.QueryContext(ctx, d.Rebind(`SELECT "version", "applied_at" FROM "schema_migrations"`))
+	if err != nil {
+		return nil, err
+	}
+
+	defer internal.Closed(rows)
+
+	var applied = make(map[int]time.Time)
+
+	for rows.Next() {
+		var version, at int64
+
+		if err = rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+
+		applied[int(version)] = time.Unix(at, 0)
+	}
+
+	return applied, rows.Err()
+}
+
+// statements of a script, split on semicolons ending a line
+func statements(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+	)
+
+	for _, line := range strings.Split(script, "\n") {
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			if s := strings.TrimSpace(current.String()); s != ";" {
+				stmts = append(stmts, s)
+			}
+
+			current.Reset()
+		}
+	}
+
+	if s := strings.TrimSpace(current.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+
+	return stmts
+}
@@ -0,0 +1,131 @@
+package sequel_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fluxynet/ascanvas/internal"
+	"github.com/fluxynet/ascanvas/repo/sequel"
+)
+
+func versions(migrations []sequel.Migration) []int {
+	var v = []int{}
+	for _, m := range migrations {
+		v = append(v, m.Version)
+	}
+
+	return v
+}
+
+func tables(t *testing.T, db *sql.DB) []string {
+	var rows, err = db.Query(`SELECT "name" FROM "sqlite_master" WHERE "type" = 'table' AND "name" LIKE 't_%' ORDER BY "name"`)
+	if err != nil {
+		t.Fatalf("failed to list tables: %s", err)
+	}
+
+	defer internal.Closed(rows)
+
+	var names = []string{}
+	for rows.Next() {
+		var name string
+		_ = rows.Scan(&name)
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func TestMigrator(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		db, _ = sql.Open("sqlite", ":memory:")
+		mfs   = fstest.MapFS{
+			"migrations/sqlite/0001_one.up.sql":   {Data: []byte("CREATE TABLE t_one (id INT);\nCREATE TABLE t_uno (id INT);")},
+			"migrations/sqlite/0001_one.down.sql": {Data: []byte("DROP TABLE t_one;\nDROP TABLE t_uno;")},
+			"migrations/sqlite/0002_two.up.sql":   {Data: []byte("CREATE TABLE t_two (id INT);")},
+			"migrations/sqlite/0002_two.down.sql": {Data: []byte("DROP TABLE t_two;")},
+			"migrations/sqlite/0003_bad.up.sql":   {Data: []byte("CREATE TABLE t_three (id INT);\nTHIS IS NOT SQL;")},
+			"migrations/sqlite/README.md":         {Data: []byte("ignored")},
+		}
+		m = &sequel.Migrator{DB: db, Driver: "sqlite", FS: mfs}
+	)
+
+	defer internal.Closed(db)
+	db.SetMaxOpenConns(1)
+
+	done, err := m.Up(ctx)
+	if err == nil {
+		t.Errorf("Up() with invalid migration did not fail")
+	}
+
+	if got := versions(done); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("Up() applied = %v, want [1 2]", got)
+	}
+
+	// failed migration is rolled back entirely
+	if got := tables(t, db); !reflect.DeepEqual(got, []string{"t_one", "t_two", "t_uno"}) {
+		t.Errorf("tables = %v", got)
+	}
+
+	delete(mfs, "migrations/sqlite/0003_bad.up.sql")
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	if len(statuses) != 2 || statuses[0].AppliedAt == nil || statuses[1].AppliedAt == nil {
+		t.Errorf("Status() got = %v", statuses)
+	}
+
+	if done, err = m.Up(ctx); err != nil || len(done) != 0 {
+		t.Errorf("Up() again = %v, %v; want nothing applied", done, err)
+	}
+
+	if done, err = m.Down(ctx, 1); err != nil || !reflect.DeepEqual(versions(done), []int{2}) {
+		t.Errorf("Down(1) = %v, %v; want [2]", versions(done), err)
+	}
+
+	if got := tables(t, db); !reflect.DeepEqual(got, []string{"t_one", "t_uno"}) {
+		t.Errorf("tables after Down(1) = %v", got)
+	}
+
+	if done, err = m.Down(ctx, 5); err != nil || !reflect.DeepEqual(versions(done), []int{1}) {
+		t.Errorf("Down(5) = %v, %v; want [1]", versions(done), err)
+	}
+
+	if got := tables(t, db); len(got) != 0 {
+		t.Errorf("tables after Down(5) = %v", got)
+	}
+
+	if _, err = (&sequel.Migrator{DB: db, Driver: "foo", FS: mfs}).Up(ctx); !errors.Is(err, sequel.ErrUnknownDriver) {
+		t.Errorf("Up() of unknown driver error = %v", err)
+	}
+}
+
+func TestMigrator_Embedded(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = makeDb()
+		m   = sequel.NewMigrator(db, "sqlite")
+	)
+
+	defer internal.Closed(db)
+
+	migrations, err := m.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if done, err := m.Down(ctx, len(migrations)); err != nil || len(done) != len(migrations) {
+		t.Errorf("Down() = %v, %v; want all reverted", versions(done), err)
+	}
+
+	if done, err := m.Up(ctx); err != nil || len(done) != len(migrations) {
+		t.Errorf("Up() = %v, %v; want all applied", versions(done), err)
+	}
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS `canvas` (
+    id VARCHAR(64) PRIMARY KEY,
+    name TEXT NOT NULL,
+    content LONGTEXT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL
+);
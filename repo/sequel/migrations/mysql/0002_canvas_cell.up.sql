@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `canvas_cell` (
+    canvas_id VARCHAR(64) NOT NULL,
+    x INT NOT NULL,
+    y INT NOT NULL,
+    value VARCHAR(1) NOT NULL,
+    lamport BIGINT UNSIGNED NOT NULL,
+    site VARCHAR(255) NOT NULL,
+    seq BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (canvas_id, x, y)
+);
+CREATE TABLE IF NOT EXISTS `canvas_cell_clock` (
+    canvas_id VARCHAR(64) NOT NULL PRIMARY KEY,
+    seq BIGINT UNSIGNED NOT NULL,
+    lamport BIGINT UNSIGNED NOT NULL
+);
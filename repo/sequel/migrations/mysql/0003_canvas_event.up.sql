@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS `canvas_event` (
+    seq BIGINT AUTO_INCREMENT PRIMARY KEY,
+    canvas_id VARCHAR(64) NOT NULL,
+    payload LONGTEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    INDEX `canvas_event_created_at` (created_at)
+);
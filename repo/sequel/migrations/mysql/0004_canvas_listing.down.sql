@@ -0,0 +1,7 @@
+DROP INDEX `canvas_name_trigram` ON `canvas`;
+DROP INDEX `canvas_updated_at` ON `canvas`;
+DROP INDEX `canvas_created_at` ON `canvas`;
+DROP INDEX `canvas_name` ON `canvas`;
+
+ALTER TABLE `canvas` DROP COLUMN updated_at;
+ALTER TABLE `canvas` DROP COLUMN created_at;
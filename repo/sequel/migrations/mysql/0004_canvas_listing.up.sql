@@ -0,0 +1,9 @@
+ALTER TABLE `canvas` ADD COLUMN created_at BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE `canvas` ADD COLUMN updated_at BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX `canvas_name` ON `canvas` (name(191), id);
+CREATE INDEX `canvas_created_at` ON `canvas` (created_at, id);
+CREATE INDEX `canvas_updated_at` ON `canvas` (updated_at, id);
+
+-- lets MATCH(name) AGAINST('"..."' IN BOOLEAN MODE) find names containing a string of 2 characters at least
+CREATE FULLTEXT INDEX `canvas_name_trigram` ON `canvas` (name) WITH PARSER ngram;
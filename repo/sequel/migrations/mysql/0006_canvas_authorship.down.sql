@@ -0,0 +1,2 @@
+ALTER TABLE `canvas` DROP COLUMN updated_by;
+ALTER TABLE `canvas` DROP COLUMN created_by;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `canvas_trash` (
+    id VARCHAR(64) PRIMARY KEY,
+    name TEXT NOT NULL,
+    content LONGTEXT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at BIGINT NOT NULL DEFAULT 0,
+    updated_at BIGINT NOT NULL DEFAULT 0,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    updated_by VARCHAR(255) NOT NULL DEFAULT '',
+    deleted_at BIGINT NOT NULL,
+    deleted_by VARCHAR(255) NOT NULL DEFAULT '',
+    INDEX `canvas_trash_deleted_at` (deleted_at)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `canvas_revision` (
+    seq BIGINT AUTO_INCREMENT PRIMARY KEY,
+    canvas_id VARCHAR(64) NOT NULL,
+    op VARCHAR(32) NOT NULL,
+    frame INT NOT NULL,
+    content LONGTEXT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at BIGINT NOT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    INDEX `canvas_revision_canvas_id` (canvas_id, seq)
+);
@@ -0,0 +1,2 @@
+DROP TABLE "canvas_cell_clock";
+DROP TABLE "canvas_cell";
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "canvas_event" (
+    seq BIGSERIAL PRIMARY KEY,
+    canvas_id VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "canvas_event_created_at" ON "canvas_event" (created_at);
@@ -0,0 +1,7 @@
+DROP INDEX "canvas_name_trigram";
+DROP INDEX "canvas_updated_at";
+DROP INDEX "canvas_created_at";
+DROP INDEX "canvas_name";
+
+ALTER TABLE "canvas" DROP COLUMN updated_at;
+ALTER TABLE "canvas" DROP COLUMN created_at;
@@ -0,0 +1,11 @@
+ALTER TABLE "canvas" ADD COLUMN created_at BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "canvas" ADD COLUMN updated_at BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX "canvas_name" ON "canvas" (name, id);
+CREATE INDEX "canvas_created_at" ON "canvas" (created_at, id);
+CREATE INDEX "canvas_updated_at" ON "canvas" (updated_at, id);
+
+-- lets LOWER(name) LIKE '%...%' use an index
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS "canvas_name_trigram" ON "canvas" USING GIN (LOWER(name) gin_trgm_ops);
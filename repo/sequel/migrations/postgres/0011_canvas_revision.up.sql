@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "canvas_revision" (
+    seq BIGSERIAL PRIMARY KEY,
+    canvas_id VARCHAR(64) NOT NULL,
+    op VARCHAR(32) NOT NULL,
+    frame INT NOT NULL,
+    content TEXT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at BIGINT NOT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS "canvas_revision_canvas_id" ON "canvas_revision" (canvas_id, seq);
@@ -4,4 +4,4 @@ CREATE TABLE IF NOT EXISTS "canvas" (
     content TEXT NOT NULL,
     width INT NOT NULL,
     height INT NOT NULL
-); 
\ No newline at end of file
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "canvas_cell" (
+    canvas_id TEXT NOT NULL,
+    x INT NOT NULL,
+    y INT NOT NULL,
+    value TEXT NOT NULL,
+    lamport INT NOT NULL,
+    site TEXT NOT NULL,
+    seq INT NOT NULL,
+    PRIMARY KEY (canvas_id, x, y)
+);
+CREATE TABLE IF NOT EXISTS "canvas_cell_clock" (
+    canvas_id TEXT NOT NULL PRIMARY KEY,
+    seq INT NOT NULL,
+    lamport INT NOT NULL
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "canvas_event" (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    canvas_id TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "canvas_event_created_at" ON "canvas_event" (created_at);
@@ -0,0 +1,6 @@
+ALTER TABLE "canvas" ADD COLUMN created_at BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "canvas" ADD COLUMN updated_at BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX "canvas_name" ON "canvas" (name, id);
+CREATE INDEX "canvas_created_at" ON "canvas" (created_at, id);
+CREATE INDEX "canvas_updated_at" ON "canvas" (updated_at, id);
@@ -0,0 +1,5 @@
+DROP TRIGGER "canvas_search_delete";
+DROP TRIGGER "canvas_search_update";
+DROP TRIGGER "canvas_search_insert";
+
+DROP TABLE "canvas_search";
@@ -0,0 +1,10 @@
+-- the trigram tokenizer allows finding any substring of at least 3 characters, ascii motifs included
+CREATE VIRTUAL TABLE "canvas_search" USING fts5(id UNINDEXED, name, content, tokenize = 'trigram');
+
+INSERT INTO "canvas_search" (id, name, content) SELECT id, name, content FROM "canvas";
+
+CREATE TRIGGER "canvas_search_insert" AFTER INSERT ON "canvas" BEGIN INSERT INTO "canvas_search" (id, name, content) VALUES (new.id, new.name, new.content); END;
+
+CREATE TRIGGER "canvas_search_update" AFTER UPDATE OF name, content ON "canvas" BEGIN UPDATE "canvas_search" SET name = new.name, content = new.content WHERE id = old.id; END;
+
+CREATE TRIGGER "canvas_search_delete" AFTER DELETE ON "canvas" BEGIN DELETE FROM "canvas_search" WHERE id = old.id; END;
@@ -0,0 +1,2 @@
+ALTER TABLE "canvas" ADD COLUMN created_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE "canvas" ADD COLUMN updated_by VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+DROP TABLE "canvas_trash";
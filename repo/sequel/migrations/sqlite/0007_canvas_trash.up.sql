@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "canvas_trash" (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    content TEXT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at INT NOT NULL DEFAULT 0,
+    updated_at INT NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL DEFAULT '',
+    updated_by TEXT NOT NULL DEFAULT '',
+    deleted_at INT NOT NULL,
+    deleted_by TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS "canvas_trash_deleted_at" ON "canvas_trash" (deleted_at);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS "canvas_frame" (
+    canvas_id TEXT NOT NULL,
+    position INT NOT NULL,
+    content TEXT NOT NULL,
+    delay INT NOT NULL,
+    PRIMARY KEY (canvas_id, position)
+);
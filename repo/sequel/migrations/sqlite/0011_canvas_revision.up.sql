@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "canvas_revision" (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    canvas_id TEXT NOT NULL,
+    op TEXT NOT NULL,
+    frame INT NOT NULL,
+    content TEXT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at INT NOT NULL,
+    created_by TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS "canvas_revision_canvas_id" ON "canvas_revision" (canvas_id, seq);
@@ -3,42 +3,51 @@ package sequel
 import (
 	"context"
 	"database/sql"
-	_ "embed"
+	"strings"
 
 	"github.com/fluxynet/ascanvas"
 	"github.com/fluxynet/ascanvas/internal"
 )
 
-// SQLiteSchemaInit initializes the schema for sqlite
-//go:embed sqlite/00-init.sql
-var SQLiteSchemaInit string
-
 type Repository struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Dialect Dialect
 }
 
+// columns of canvases as scanned by scan
+const columns = `"id", "name", "content", "width", "height", "created_at", "updated_at", "created_by", "updated_by"`
+
 func (r Repository) Create(ctx context.Context, canvas ascanvas.Canvas) error {
-	var _, err = r.DB.ExecContext(
+	var _, err = conn(ctx, r.DB).ExecContext(
 		ctx,
-		`INSERT INTO "canvas" ("id", "name", "content", "width", "height") VALUES (?,?,?,?,?)`,
+		r.Dialect.Rebind(`INSERT INTO "canvas" (`+columns+`) VALUES (?,?,?,?,?,?,?,?,?)`),
 		canvas.Id,
 		canvas.Name,
 		canvas.Content,
 		canvas.Width,
 		canvas.Height,
+		ascanvas.UnixNano(canvas.CreatedAt),
+		ascanvas.UnixNano(canvas.UpdatedAt),
+		canvas.CreatedBy,
+		canvas.UpdatedBy,
 	)
 
 	return err
 }
 
 func (r Repository) Update(ctx context.Context, canvas ascanvas.Canvas) error {
-	var _, err = r.DB.ExecContext(
+	var _, err = conn(ctx, r.DB).ExecContext(
 		ctx,
-		`UPDATE "canvas" SET "name" = ?, "content" = ?, "width" = ?, "height" = ? WHERE "id" = ?`,
+		r.Dialect.Rebind(`UPDATE "canvas" SET "name" = ?, "content" = ?, "width" = ?, "height" = ?,
+"created_at" = ?, "updated_at" = ?, "created_by" = ?, "updated_by" = ? WHERE "id" = ?`),
 		canvas.Name,
 		canvas.Content,
 		canvas.Width,
 		canvas.Height,
+		ascanvas.UnixNano(canvas.CreatedAt),
+		ascanvas.UnixNano(canvas.UpdatedAt),
+		canvas.CreatedBy,
+		canvas.UpdatedBy,
 		canvas.Id,
 	)
 
@@ -47,11 +56,11 @@ func (r Repository) Update(ctx context.Context, canvas ascanvas.Canvas) error {
 
 func (r Repository) Get(ctx context.Context, id string) (*ascanvas.Canvas, error) {
 	var (
-		canvas ascanvas.Canvas
+		canvas *ascanvas.Canvas
 
-		rows, err = r.DB.QueryContext(
+		rows, err = conn(ctx, r.DB).QueryContext(
 			ctx,
-			`SELECT "id", "name", "content", "width", "height" FROM "canvas" WHERE "id" = ?`,
+			r.Dialect.Rebind(`SELECT `+columns+` FROM "canvas" WHERE "id" = ?`),
 			id,
 		)
 	)
@@ -63,27 +72,21 @@ func (r Repository) Get(ctx context.Context, id string) (*ascanvas.Canvas, error
 	defer internal.Closed(rows)
 
 	if rows.Next() {
-		err = rows.Scan(
-			&canvas.Id,
-			&canvas.Name,
-			&canvas.Content,
-			&canvas.Width,
-			&canvas.Height,
-		)
+		canvas, err = scan(rows)
 	} else {
 		return nil, ascanvas.ErrNotFound
 	}
 
-	return &canvas, err
+	return canvas, err
 }
 
 func (r Repository) List(ctx context.Context) ([]ascanvas.Canvas, error) {
 	var (
 		canvases []ascanvas.Canvas
 
-		rows, err = r.DB.QueryContext(
+		rows, err = conn(ctx, r.DB).QueryContext(
 			ctx,
-			`SELECT "id", "name", "content", "width", "height" FROM "canvas"`,
+			r.Dialect.Rebind(`SELECT `+columns+` FROM "canvas" ORDER BY "id"`),
 		)
 	)
 
@@ -94,21 +97,12 @@ func (r Repository) List(ctx context.Context) ([]ascanvas.Canvas, error) {
 	defer internal.Closed(rows)
 
 	for rows.Next() {
-		var canvas ascanvas.Canvas
-
-		err = rows.Scan(
-			&canvas.Id,
-			&canvas.Name,
-			&canvas.Content,
-			&canvas.Width,
-			&canvas.Height,
-		)
-
+		var canvas, err = scan(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		canvases = append(canvases, canvas)
+		canvases = append(canvases, *canvas)
 	}
 
 	if canvases == nil {
@@ -118,7 +112,221 @@ func (r Repository) List(ctx context.Context) ([]ascanvas.Canvas, error) {
 	return canvases, err
 }
 
+// scan a canvas selected with columns
+func scan(rows *sql.Rows) (*ascanvas.Canvas, error) {
+	var (
+		canvas               ascanvas.Canvas
+		createdAt, updatedAt int64
+	)
+
+	var err = rows.Scan(
+		&canvas.Id,
+		&canvas.Name,
+		&canvas.Content,
+		&canvas.Width,
+		&canvas.Height,
+		&createdAt,
+		&updatedAt,
+		&canvas.CreatedBy,
+		&canvas.UpdatedBy,
+	)
+
+	canvas.CreatedAt = ascanvas.FromUnixNano(createdAt)
+	canvas.UpdatedAt = ascanvas.FromUnixNano(updatedAt)
+
+	return &canvas, err
+}
+
 func (r Repository) Delete(ctx context.Context, id string) error {
-	var _, err = r.DB.ExecContext(ctx, `DELETE FROM "canvas" WHERE "id" = ?`, id)
+	var _, err = conn(ctx, r.DB).ExecContext(ctx, r.Dialect.Rebind(`DELETE FROM "canvas" WHERE "id" = ?`), id)
 	return err
 }
+
+// ListPage of canvases using keyset pagination, so that later pages cost as little as the first one
+func (r Repository) ListPage(ctx context.Context, args ascanvas.ListArgs) (*ascanvas.CanvasPage, error) {
+	var (
+		key     = `"id"`
+		fields  = `"id", "name", "width", "height", "created_at", "updated_at", "created_by", "updated_by"`
+		where   []string
+		params  []interface{}
+		order   = "ASC"
+		compare = ">"
+	)
+
+	switch args.Sort {
+	case ascanvas.SortName:
+		key = `"name"`
+	case ascanvas.SortCreated:
+		key = `"created_at"`
+	case ascanvas.SortUpdated:
+		key = `"updated_at"`
+	}
+
+	if !args.Summary {
+		fields += `, "content"`
+	}
+
+	if args.Desc {
+		order = "DESC"
+		compare = "<"
+	}
+
+	if args.Name != "" {
+		var w, p = r.nameContains(args.Name)
+		where = append(where, w...)
+		params = append(params, p...)
+	}
+
+	if args.Prefix != "" {
+		where = append(where, `LOWER("name") LIKE ? ESCAPE '!'`)
+		params = append(params, escapeLike(strings.ToLower(args.Prefix))+"%")
+	}
+
+	if args.Cursor != "" {
+		var c, err = ascanvas.DecodeListCursor(args.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case `"id"`:
+			where = append(where, `"id" `+compare+` ?`)
+			params = append(params, c.Id)
+		case `"name"`:
+			where = append(where, `("name" `+compare+` ? OR ("name" = ? AND "id" `+compare+` ?))`)
+			params = append(params, c.Name, c.Name, c.Id)
+		default:
+			where = append(where, `(`+key+` `+compare+` ? OR (`+key+` = ? AND "id" `+compare+` ?))`)
+			params = append(params, c.At, c.At, c.Id)
+		}
+	}
+
+	var query = `SELECT ` + fields + ` FROM "canvas"`
+	if len(where) != 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+
+	query += ` ORDER BY ` + key + ` ` + order
+	if key != `"id"` {
+		query += `, "id" ` + order
+	}
+
+	// one more than the limit tells if there is a next page
+	if args.Limit > 0 {
+		query += ` LIMIT ?`
+		params = append(params, args.Limit+1)
+	}
+
+	var rows, err = conn(ctx, r.DB).QueryContext(ctx, r.Dialect.Rebind(query), params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer internal.Closed(rows)
+
+	var page = &ascanvas.CanvasPage{Canvases: []ascanvas.Canvas{}}
+
+	for rows.Next() {
+		if args.Limit > 0 && len(page.Canvases) == args.Limit {
+			page.Next = ascanvas.CursorAfter(page.Canvases[len(page.Canvases)-1], args)
+			break
+		}
+
+		var (
+			canvas               ascanvas.Canvas
+			createdAt, updatedAt int64
+			dest                 = []interface{}{&canvas.Id, &canvas.Name, &canvas.Width, &canvas.Height, &createdAt, &updatedAt, &canvas.CreatedBy, &canvas.UpdatedBy}
+		)
+
+		if !args.Summary {
+			dest = append(dest, &canvas.Content)
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		canvas.CreatedAt = ascanvas.FromUnixNano(createdAt)
+		canvas.UpdatedAt = ascanvas.FromUnixNano(updatedAt)
+
+		page.Canvases = append(page.Canvases, canvas)
+	}
+
+	return page, rows.Err()
+}
+
+// nameContains filters names containing a string ignoring case, using an index of trigrams when the string is long enough:
+// sqlite has the full-text index of Search, postgres an index of the lowercased names, mysql a full-text index of ngrams
+func (r Repository) nameContains(name string) ([]string, []interface{}) {
+	var (
+		where  = []string{`LOWER("name") LIKE ? ESCAPE '!'`}
+		params = []interface{}{"%" + escapeLike(strings.ToLower(name)) + "%"}
+	)
+
+	switch {
+	case (r.Dialect == DialectSQLite || r.Dialect == "") && len(name) >= 3:
+		where = append(where, `"id" IN (SELECT "id" FROM "canvas_search" WHERE "canvas_search" MATCH ?)`)
+		params = append(params, `{name} : "`+strings.ReplaceAll(name, `"`, `""`)+`"`)
+	case r.Dialect == DialectMySQL && len(name) >= 2:
+		where = append(where, `MATCH ("name") AGAINST (? IN BOOLEAN MODE)`)
+		// quotes cannot be escaped within a phrase, LIKE still matching them
+		params = append(params, `"`+strings.ReplaceAll(name, `"`, ` `)+`"`)
+	}
+
+	return where, params
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, using ! as escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// Search canvases by name or content, ordered like ascanvas.SearchCanvases;
+// sqlite uses the trigram full-text index, other dialects a plain LIKE
+func (r Repository) Search(ctx context.Context, args ascanvas.SearchArgs) ([]ascanvas.CanvasSummary, error) {
+	var (
+		like   = "%" + escapeLike(strings.ToLower(args.Query)) + "%"
+		query  string
+		params []interface{}
+	)
+
+	// trigrams need 3 characters at least
+	if (r.Dialect == DialectSQLite || r.Dialect == "") && len(args.Query) >= 3 {
+		query = `SELECT c."id", c."name", c."width", c."height" FROM "canvas_search" s JOIN "canvas" c ON c."id" = s."id"
+WHERE "canvas_search" MATCH ? ORDER BY s."name" LIKE ? ESCAPE '!' DESC, c."name", c."id"`
+		params = append(params, `"`+strings.ReplaceAll(args.Query, `"`, `""`)+`"`)
+	} else {
+		query = `SELECT "id", "name", "width", "height" FROM "canvas"
+WHERE LOWER("name") LIKE ? ESCAPE '!' OR LOWER("content") LIKE ? ESCAPE '!'
+ORDER BY LOWER("name") LIKE ? ESCAPE '!' DESC, "name", "id"`
+		params = append(params, like, like)
+	}
+
+	params = append(params, like)
+
+	if args.Limit > 0 {
+		query += ` LIMIT ?`
+		params = append(params, args.Limit)
+	}
+
+	var rows, err = conn(ctx, r.DB).QueryContext(ctx, r.Dialect.Rebind(query), params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer internal.Closed(rows)
+
+	var results = []ascanvas.CanvasSummary{}
+
+	for rows.Next() {
+		var c ascanvas.CanvasSummary
+
+		if err = rows.Scan(&c.Id, &c.Name, &c.Width, &c.Height); err != nil {
+			return nil, err
+		}
+
+		results = append(results, c)
+	}
+
+	return results, rows.Err()
+}
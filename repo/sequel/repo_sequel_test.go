@@ -4,13 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"math"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 	_ "modernc.org/sqlite"
 
 	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/conformance"
 	"github.com/fluxynet/ascanvas/internal"
 	"github.com/fluxynet/ascanvas/repo/sequel"
 )
@@ -19,29 +25,109 @@ func makeDb() *sql.DB {
 	var db, err = sql.Open("sqlite", ":memory:")
 	if err != nil {
 		panic("failed to open database connection: " + err.Error())
-	} else if err = db.Ping(); err != nil {
+	}
+
+	// every connection to :memory: is a distinct database
+	db.SetMaxOpenConns(1)
+
+	if err = db.Ping(); err != nil {
 		panic("failed to ping database: " + err.Error())
-	} else if _, err = db.Exec(sequel.SQLiteSchemaInit); err != nil {
+	} else if _, err = sequel.NewMigrator(db, "sqlite").Up(context.Background()); err != nil {
 		panic("failed to initialize schema: " + err.Error())
 	}
 
 	return db
 }
 
+// testDatabase is a database the repository tests run against
+type testDatabase struct {
+	Driver string
+	Env    string
+}
+
+// testDatabases other than sqlite are only used when a dsn is given in their environment variable
+var testDatabases = []testDatabase{
+	{Driver: "postgres", Env: "ASCANVAS_TEST_POSTGRES_DSN"},
+	{Driver: "mysql", Env: "ASCANVAS_TEST_MYSQL_DSN"},
+}
+
+// openTestDb with a freshly migrated schema
+func openTestDb(t *testing.T, driver, dsn string) *sql.DB {
+	var db, err = sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatalf("failed to open database connection: %s", err)
+	} else if err = db.Ping(); err != nil {
+		t.Fatalf("failed to ping database: %s", err)
+	}
+
+	var m = sequel.NewMigrator(db, driver)
+	if _, err = m.Down(context.Background(), math.MaxInt32); err != nil {
+		t.Fatalf("failed to reset schema: %s", err)
+	} else if _, err = m.Up(context.Background()); err != nil {
+		t.Fatalf("failed to initialize schema: %s", err)
+	}
+
+	return db
+}
+
 func TestRepository(t *testing.T) {
-	var (
-		db = makeDb()
-		r  = RepositoryTest{DB: db}
-	)
+	var db = makeDb()
+	defer internal.Closed(db)
+
+	t.Run("sqlite", RepositoryTest{DB: db, Dialect: sequel.DialectSQLite}.Run)
+
+	for _, d := range testDatabases {
+		var dsn = os.Getenv(d.Env)
+		if dsn == "" {
+			t.Logf("skipping %s: %s not set", d.Driver, d.Env)
+			continue
+		}
+
+		t.Run(d.Driver, func(t *testing.T) {
+			var db = openTestDb(t, d.Driver, dsn)
+			defer internal.Closed(db)
+
+			RepositoryTest{DB: db, Dialect: sequel.DialectOf(d.Driver)}.Run(t)
+		})
+	}
+}
+
+func TestRepository_Conformance(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		conformance.TestRepository(t, func(t *testing.T) ascanvas.CanvasRepository {
+			var db = makeDb()
+			t.Cleanup(func() { internal.Closed(db) })
+
+			return &sequel.Repository{DB: db, Dialect: sequel.DialectSQLite}
+		})
+	})
 
-	defer func(db *sql.DB) {
-		err := db.Close()
-		if err != nil {
-			panic(err)
+	for _, d := range testDatabases {
+		var dsn = os.Getenv(d.Env)
+		if dsn == "" {
+			continue
 		}
-	}(db)
 
-	// order of the tests is important for value checking; must be: Create, Delete, Get, Update, List
+		t.Run(d.Driver, func(t *testing.T) {
+			conformance.TestRepository(t, func(t *testing.T) ascanvas.CanvasRepository {
+				var db = openTestDb(t, d.Driver, dsn)
+				t.Cleanup(func() { internal.Closed(db) })
+
+				return &sequel.Repository{DB: db, Dialect: sequel.DialectOf(d.Driver)}
+			})
+		})
+	}
+}
+
+type RepositoryTest struct {
+	DB      *sql.DB
+	Dialect sequel.Dialect
+}
+
+// Run all tests in order, starting from an empty database
+func (r RepositoryTest) Run(t *testing.T) {
+	// order of the tests is important for value checking; must be: Empty, Create, Delete, Get, Update, List
+	t.Run("repository_Empty", r.Empty)
 	t.Run("repository_Create", r.Create)
 	t.Run("repository_Delete", r.Delete)
 	t.Run("repository_Get", r.Get)
@@ -49,8 +135,20 @@ func TestRepository(t *testing.T) {
 	t.Run("repository_List", r.List)
 }
 
-type RepositoryTest struct {
-	DB *sql.DB
+func (r *RepositoryTest) repo() sequel.Repository {
+	return sequel.Repository{DB: r.DB, Dialect: r.Dialect}
+}
+
+func (r *RepositoryTest) Empty(t *testing.T) {
+	got, err := r.repo().List(context.Background())
+	if err != nil {
+		t.Errorf("List() error = %v", err)
+		return
+	}
+
+	if !reflect.DeepEqual(got, []ascanvas.Canvas{}) {
+		t.Errorf("List() got = %v, want empty", got)
+	}
 }
 
 func (r *RepositoryTest) Create(t *testing.T) {
@@ -107,9 +205,7 @@ func (r *RepositoryTest) Create(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := sequel.Repository{
-				DB: r.DB,
-			}
+			repo := r.repo()
 
 			if err := repo.Create(context.Background(), tt.canvas); (err != nil) != tt.wantErr {
 				t.Errorf("Create() error = %s, wantErr %v", err, tt.wantErr)
@@ -135,9 +231,7 @@ func (r *RepositoryTest) Delete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := sequel.Repository{
-				DB: r.DB,
-			}
+			repo := r.repo()
 
 			if err := repo.Delete(context.Background(), tt.id); err != nil {
 				t.Errorf("Delete() error = %v", err)
@@ -175,9 +269,7 @@ func (r *RepositoryTest) Get(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := sequel.Repository{
-				DB: r.DB,
-			}
+			repo := r.repo()
 
 			got, err := repo.Get(context.Background(), tt.id)
 			if !errors.Is(err, tt.wantErr) {
@@ -221,9 +313,7 @@ func (r *RepositoryTest) Update(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := sequel.Repository{
-				DB: r.DB,
-			}
+			repo := r.repo()
 
 			if err := repo.Update(context.Background(), tt.canvas); err != nil {
 				t.Errorf("Update() error = %v", err)
@@ -233,22 +323,12 @@ func (r *RepositoryTest) Update(t *testing.T) {
 }
 
 func (r *RepositoryTest) List(t *testing.T) {
-	var empty = makeDb()
-	defer internal.Closed(empty)
-
 	tests := []struct {
 		name string
-		db   *sql.DB
 		want []ascanvas.Canvas
 	}{
-		{
-			name: "empty db",
-			db:   empty,
-			want: []ascanvas.Canvas{},
-		},
 		{
 			name: "non-empty db",
-			db:   r.DB,
 			want: []ascanvas.Canvas{
 				{
 					Id:      "1",
@@ -270,9 +350,7 @@ func (r *RepositoryTest) List(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := sequel.Repository{
-				DB: tt.db,
-			}
+			repo := r.repo()
 
 			got, err := repo.List(context.Background())
 			if err != nil {
@@ -286,3 +364,203 @@ func (r *RepositoryTest) List(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_ListPage(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		db   = makeDb()
+		repo = sequel.Repository{DB: db}
+
+		canvases = []ascanvas.Canvas{
+			{Id: "1", Name: "Banana", Content: "b", Width: 1, Height: 1},
+			{Id: "2", Name: "apple", Content: "a", Width: 1, Height: 1},
+			{Id: "3", Name: "Cherry pie", Content: "c", Width: 1, Height: 1},
+			{Id: "4", Name: "Apple pie", Content: "p", Width: 1, Height: 1},
+			{Id: "5", Name: "Banana", Content: "B", Width: 1, Height: 1},
+			{Id: "6", Name: "100% apple_pie", Content: "%", Width: 1, Height: 1},
+		}
+	)
+
+	defer internal.Closed(db)
+
+	var start = time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := range canvases {
+		canvases[i].CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		canvases[i].UpdatedAt = canvases[i].CreatedAt
+
+		if err := repo.Create(ctx, canvases[i]); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	canvases[1].UpdatedAt = start.Add(time.Hour)
+	if err := repo.Update(ctx, canvases[1]); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	var pages = func(args ascanvas.ListArgs, list func(args ascanvas.ListArgs) (*ascanvas.CanvasPage, error)) [][]ascanvas.Canvas {
+		var got [][]ascanvas.Canvas
+
+		for i := 0; i < 10; i++ {
+			var page, err = list(args)
+			if err != nil {
+				t.Fatalf("ListPage(%+v) error = %v", args, err)
+			}
+
+			got = append(got, page.Canvases)
+
+			if page.Next == "" {
+				return got
+			}
+
+			args.Cursor = page.Next
+		}
+
+		t.Fatalf("too many pages")
+		return nil
+	}
+
+	var (
+		listPage = func(args ascanvas.ListArgs) (*ascanvas.CanvasPage, error) {
+			return repo.ListPage(ctx, args)
+		}
+		paginate = func(args ascanvas.ListArgs) (*ascanvas.CanvasPage, error) {
+			return ascanvas.Paginate(canvases, args)
+		}
+	)
+
+	// same as paging in memory
+	for _, args := range []ascanvas.ListArgs{
+		{},
+		{Limit: 4},
+		{Limit: 2, Sort: ascanvas.SortName},
+		{Limit: 2, Sort: ascanvas.SortName, Desc: true},
+		{Limit: 1, Sort: ascanvas.SortID, Desc: true},
+		{Name: "PIE", Sort: ascanvas.SortName},
+		{Prefix: "apple", Limit: 1},
+		{Name: "%"},
+		{Name: "e_p"},
+		{Name: "zzz"},
+		{Name: "ANA", Limit: 1},
+		{Name: "0% apple_"},
+		{Name: `"pie`},
+		{Summary: true, Limit: 3, Sort: ascanvas.SortName},
+		{Limit: 4, Sort: ascanvas.SortCreated, Desc: true},
+		{Limit: 3, Sort: ascanvas.SortUpdated},
+	} {
+		if got, want := pages(args, listPage), pages(args, paginate); !reflect.DeepEqual(got, want) {
+			t.Errorf("ListPage(%+v) got = %v, want %v", args, got, want)
+		}
+	}
+
+	var byTime = func(args ascanvas.ListArgs) [][]string {
+		var got [][]string
+		for _, p := range pages(args, listPage) {
+			var ids []string
+			for _, c := range p {
+				ids = append(ids, c.Id)
+			}
+
+			got = append(got, ids)
+		}
+
+		return got
+	}
+
+	var tests = []struct {
+		args ascanvas.ListArgs
+		want [][]string
+	}{
+		{
+			args: ascanvas.ListArgs{Limit: 4, Sort: ascanvas.SortCreated},
+			want: [][]string{{"1", "2", "3", "4"}, {"5", "6"}},
+		},
+		{
+			args: ascanvas.ListArgs{Limit: 4, Sort: ascanvas.SortCreated, Desc: true},
+			want: [][]string{{"6", "5", "4", "3"}, {"2", "1"}},
+		},
+		{
+			args: ascanvas.ListArgs{Limit: 3, Sort: ascanvas.SortUpdated},
+			want: [][]string{{"1", "3", "4"}, {"5", "6", "2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := byTime(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ListPage(%+v) got = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRepository_Search(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = makeDb()
+
+		canvases = []ascanvas.Canvas{
+			{Id: "1", Name: "Cat", Content: `/\_/\ ( o.o)`, Width: 6, Height: 2},
+			{Id: "2", Name: "Dog", Content: "catalog", Width: 7, Height: 1},
+			{Id: "3", Name: "Bird", Content: "~~v~~", Width: 5, Height: 1},
+			{Id: "4", Name: "Another cat", Content: "..%..", Width: 5, Height: 1},
+			{Id: "5", Name: "Deleted cat", Content: "xxxxx", Width: 5, Height: 1},
+		}
+	)
+
+	defer internal.Closed(db)
+
+	var fts = sequel.Repository{DB: db}
+
+	for i := range canvases {
+		if err := fts.Create(ctx, canvases[i]); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	// the index follows updates and deletes
+	canvases[2].Content = "~~w~~"
+	if err := fts.Update(ctx, canvases[2]); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	} else if err = fts.Delete(ctx, "5"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	canvases = canvases[:4]
+
+	var ids = func(results []ascanvas.CanvasSummary) []string {
+		var got = []string{}
+		for _, r := range results {
+			got = append(got, r.Id)
+		}
+
+		return got
+	}
+
+	for name, repo := range map[string]sequel.Repository{
+		"full-text": fts,
+		"generic":   {DB: db, Dialect: "generic"},
+	} {
+		for _, args := range []ascanvas.SearchArgs{
+			{Query: "CAT"},
+			{Query: "cat", Limit: 2},
+			{Query: "o.o"},
+			{Query: `/\_`},
+			{Query: "~w~"},
+			{Query: "~v~"},
+			{Query: "w"},
+			{Query: "%"},
+			{Query: "xxx"},
+			{Query: `"`},
+		} {
+			var got, err = repo.Search(ctx, args)
+			if err != nil {
+				t.Errorf("%s: Search(%+v) error = %v", name, args, err)
+				continue
+			}
+
+			if want := ids(ascanvas.SearchCanvases(canvases, args)); !reflect.DeepEqual(ids(got), want) {
+				t.Errorf("%s: Search(%+v) got = %v, want %v", name, args, ids(got), want)
+			}
+		}
+	}
+}
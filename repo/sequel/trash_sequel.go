@@ -0,0 +1,138 @@
+package sequel
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+)
+
+// Trash keeps ascanvas.DeletedCanvas items in the canvas_trash table
+type Trash struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+// trashColumns of deleted canvases as scanned by scanDeleted
+const trashColumns = columns + `, "deleted_at", "deleted_by"`
+
+func (t Trash) Put(ctx context.Context, canvas ascanvas.DeletedCanvas) error {
+	return InTx(ctx, t.DB, func(ctx context.Context) error {
+		var tx = conn(ctx, t.DB)
+
+		var _, err = tx.ExecContext(ctx, t.Dialect.Rebind(`DELETE FROM "canvas_trash" WHERE "id" = ?`), canvas.Id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			t.Dialect.Rebind(`INSERT INTO "canvas_trash" (`+trashColumns+`) VALUES (?,?,?,?,?,?,?,?,?,?,?)`),
+			canvas.Id,
+			canvas.Name,
+			canvas.Content,
+			canvas.Width,
+			canvas.Height,
+			ascanvas.UnixNano(canvas.CreatedAt),
+			ascanvas.UnixNano(canvas.UpdatedAt),
+			canvas.CreatedBy,
+			canvas.UpdatedBy,
+			ascanvas.UnixNano(canvas.DeletedAt),
+			canvas.DeletedBy,
+		)
+
+		return err
+	})
+}
+
+func (t Trash) Get(ctx context.Context, id string) (*ascanvas.DeletedCanvas, error) {
+	var found, err = t.query(ctx, conn(ctx, t.DB), `WHERE "id" = ?`, id)
+	if err != nil {
+		return nil, err
+	} else if len(found) == 0 {
+		return nil, ascanvas.ErrNotFound
+	}
+
+	return &found[0], nil
+}
+
+func (t Trash) List(ctx context.Context) ([]ascanvas.DeletedCanvas, error) {
+	return t.query(ctx, conn(ctx, t.DB), `ORDER BY "deleted_at" DESC, "id"`)
+}
+
+func (t Trash) Remove(ctx context.Context, id string) error {
+	var _, err = conn(ctx, t.DB).ExecContext(ctx, t.Dialect.Rebind(`DELETE FROM "canvas_trash" WHERE "id" = ?`), id)
+	return err
+}
+
+func (t Trash) Expire(ctx context.Context, before time.Time) ([]ascanvas.DeletedCanvas, error) {
+	var expired []ascanvas.DeletedCanvas
+
+	var err = InTx(ctx, t.DB, func(ctx context.Context) error {
+		var (
+			tx  = conn(ctx, t.DB)
+			err error
+		)
+
+		expired, err = t.query(ctx, tx, `WHERE "deleted_at" < ? ORDER BY "deleted_at", "id"`, ascanvas.UnixNano(before))
+
+		for i := 0; err == nil && i < len(expired); i++ {
+			_, err = tx.ExecContext(ctx, t.Dialect.Rebind(`DELETE FROM "canvas_trash" WHERE "id" = ?`), expired[i].Id)
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return expired, nil
+}
+
+// query deleted canvases; clause follows FROM
+func (t Trash) query(ctx context.Context, q querier, clause string, args ...interface{}) ([]ascanvas.DeletedCanvas, error) {
+	var rows, err = q.QueryContext(ctx, t.Dialect.Rebind(`SELECT `+trashColumns+` FROM "canvas_trash" `+clause), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer internal.Closed(rows)
+
+	var found = []ascanvas.DeletedCanvas{}
+
+	for rows.Next() {
+		var (
+			d                               ascanvas.DeletedCanvas
+			createdAt, updatedAt, deletedAt int64
+		)
+
+		err = rows.Scan(
+			&d.Id,
+			&d.Name,
+			&d.Content,
+			&d.Width,
+			&d.Height,
+			&createdAt,
+			&updatedAt,
+			&d.CreatedBy,
+			&d.UpdatedBy,
+			&deletedAt,
+			&d.DeletedBy,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		d.CreatedAt = ascanvas.FromUnixNano(createdAt)
+		d.UpdatedAt = ascanvas.FromUnixNano(updatedAt)
+		d.DeletedAt = ascanvas.FromUnixNano(deletedAt)
+
+		found = append(found, d)
+	}
+
+	return found, rows.Err()
+}
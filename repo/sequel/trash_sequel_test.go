@@ -0,0 +1,116 @@
+package sequel_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	bm "github.com/fluxynet/ascanvas/broadcaster/memory"
+	"github.com/fluxynet/ascanvas/conformance"
+	"github.com/fluxynet/ascanvas/internal"
+	"github.com/fluxynet/ascanvas/repo/sequel"
+)
+
+func TestTrash(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		conformance.TestTrash(t, func(t *testing.T) ascanvas.CanvasTrash {
+			var db = makeDb()
+			t.Cleanup(func() { internal.Closed(db) })
+
+			return &sequel.Trash{DB: db, Dialect: sequel.DialectSQLite}
+		})
+	})
+
+	for _, d := range testDatabases {
+		var dsn = os.Getenv(d.Env)
+		if dsn == "" {
+			continue
+		}
+
+		t.Run(d.Driver, func(t *testing.T) {
+			conformance.TestTrash(t, func(t *testing.T) ascanvas.CanvasTrash {
+				var db = openTestDb(t, d.Driver, dsn)
+				t.Cleanup(func() { internal.Closed(db) })
+
+				return &sequel.Trash{DB: db, Dialect: sequel.DialectOf(d.Driver)}
+			})
+		})
+	}
+}
+
+// failingRepo deletes or creates canvases, then fails
+type failingRepo struct {
+	ascanvas.CanvasRepository
+}
+
+var errFailing = errors.New("failing")
+
+func (r failingRepo) Create(ctx context.Context, canvas ascanvas.Canvas) error {
+	_ = r.CanvasRepository.Create(ctx, canvas)
+	return errFailing
+}
+
+func (r failingRepo) Delete(ctx context.Context, id string) error {
+	_ = r.CanvasRepository.Delete(ctx, id)
+	return errFailing
+}
+
+func TestTransactor_Trash(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		db          = makeDb()
+		broadcaster = bm.New()
+		repo        = &sequel.Repository{DB: db}
+		trash       = &sequel.Trash{DB: db}
+		s           = ascanvas.CanvasService{
+			Repo:        failingRepo{CanvasRepository: repo},
+			BroadCaster: broadcaster,
+			Logger:      zaptest.NewLogger(t),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Trash:       trash,
+			Transactor:  sequel.Transactor{DB: db},
+		}
+		canvas = ascanvas.Canvas{Id: "1", Name: "One", Content: "1", Width: 1, Height: 1}
+	)
+
+	defer internal.Closed(db)
+	defer internal.Closed(broadcaster)
+
+	if err := repo.Create(ctx, canvas); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := s.Delete(ctx, "1"); !errors.Is(err, errFailing) {
+		t.Errorf("Delete() error = %v, want %v", err, errFailing)
+	}
+
+	if _, err := repo.Get(ctx, "1"); err != nil {
+		t.Errorf("Get() after failed Delete() error = %v, want the canvas rolled back", err)
+	}
+
+	if got, _ := trash.List(ctx); len(got) != 0 {
+		t.Errorf("trash after failed Delete() got = %v, want empty", got)
+	}
+
+	s.Repo = repo
+	if err := s.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	s.Repo = failingRepo{CanvasRepository: repo}
+	if _, err := s.Restore(ctx, "1"); !errors.Is(err, errFailing) {
+		t.Errorf("Restore() error = %v, want %v", err, errFailing)
+	}
+
+	if _, err := repo.Get(ctx, "1"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Get() after failed Restore() error = %v, want ErrNotFound", err)
+	}
+
+	if _, err := trash.Get(ctx, "1"); err != nil {
+		t.Errorf("trash after failed Restore() error = %v, want the canvas rolled back", err)
+	}
+}
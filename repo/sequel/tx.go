@@ -0,0 +1,56 @@
+package sequel
+
+import (
+	"context"
+	"database/sql"
+)
+
+// querier is either a database or a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// txKey of the transaction on a database carried by a context
+type txKey struct {
+	db *sql.DB
+}
+
+// conn to run queries on: the transaction on db carried by ctx if any, db itself otherwise
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{db: db}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// InTx runs fn in a transaction on db carried by the context it is given, so that all repositories of this package
+// on db take part in it; the transaction of ctx is joined if any, otherwise it is committed once fn succeeds
+// and rolled back if it fails
+func InTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{db: db}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	var tx, err = db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(context.WithValue(ctx, txKey{db: db}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Transactor is an ascanvas.Transactor of the repositories of this package on DB
+type Transactor struct {
+	DB *sql.DB
+}
+
+func (t Transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return InTx(ctx, t.DB, fn)
+}
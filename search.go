@@ -0,0 +1,201 @@
+package ascanvas
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DefaultSearchLimit is the number of results of a search when no limit is given
+const DefaultSearchLimit = 50
+
+// SearchArgs to find canvases by name or content
+type SearchArgs struct {
+	// Query is found as is in the name or content of canvases, ignoring case
+	Query string `json:"query"`
+	Limit int    `json:"limit"`
+}
+
+func (a SearchArgs) Validate() error {
+	var errs []string
+
+	if a.Query == "" {
+		errs = append(errs, "Query cannot be empty")
+	}
+
+	if a.Limit < 0 {
+		errs = append(errs, "Limit cannot be negative")
+	} else if a.Limit > MaxListLimit {
+		errs = append(errs, fmt.Sprintf("Limit cannot exceed %d", MaxListLimit))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidInput, strings.Join(errs, ", "))
+}
+
+// CanvasSearcher is implemented by repositories able to search by themselves, typically using an index;
+// other repositories are searched with SearchCanvases
+type CanvasSearcher interface {
+	Search(ctx context.Context, args SearchArgs) ([]CanvasSummary, error)
+}
+
+// SearchCanvases in memory; matches in names come first, then by name and id
+func SearchCanvases(canvases []Canvas, args SearchArgs) []CanvasSummary {
+	var (
+		q       = strings.ToLower(args.Query)
+		found   []Canvas
+		inNames = make(map[string]bool)
+	)
+
+	for i := range canvases {
+		var inName = strings.Contains(strings.ToLower(canvases[i].Name), q)
+
+		if inName || strings.Contains(strings.ToLower(canvases[i].Content), q) {
+			found = append(found, canvases[i])
+			inNames[canvases[i].Id] = inName
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		var a, b = found[i], found[j]
+		if inNames[a.Id] != inNames[b.Id] {
+			return inNames[a.Id]
+		}
+
+		return a.Name < b.Name || (a.Name == b.Name && a.Id < b.Id)
+	})
+
+	if args.Limit > 0 && len(found) > args.Limit {
+		found = found[:args.Limit]
+	}
+
+	var results = make([]CanvasSummary, len(found))
+	for i := range found {
+		results[i] = found[i].Summary()
+	}
+
+	return results
+}
+
+// Search canvases by name or content
+func (s CanvasService) Search(ctx context.Context, args SearchArgs) ([]CanvasSummary, error) {
+	s.Logger.Debug("Search::Validating", zap.String("query", args.Query), zap.Int("limit", args.Limit))
+
+	if err := args.Validate(); err != nil {
+		s.Logger.Debug("Search::Invalid", zap.Error(err))
+		return nil, err
+	}
+
+	if args.Limit == 0 {
+		args.Limit = DefaultSearchLimit
+	}
+
+	var (
+		results []CanvasSummary
+		err     error
+	)
+
+	if searcher, ok := s.Repo.(CanvasSearcher); ok {
+		results, err = searcher.Search(ctx, args)
+	} else {
+		var canvases []Canvas
+		if canvases, err = s.Repo.List(ctx); err == nil {
+			results = SearchCanvases(canvases, args)
+		}
+	}
+
+	if err == nil {
+		s.Logger.Debug("Search::Found", zap.Int("count", len(results)))
+	} else {
+		s.Logger.Error("Search::Failed", zap.Error(err))
+	}
+
+	return results, err
+}
+
+// PatternArgs to find a multi-line snippet within a canvas
+type PatternArgs struct {
+	// Pattern is made of rows separated by new lines; leading and trailing new lines are ignored.
+	// Rows may be of different lengths; spaces are significant.
+	Pattern string `json:"pattern"`
+}
+
+func (a PatternArgs) Validate() error {
+	if strings.Trim(a.Pattern, "\r\n") == "" {
+		return fmt.Errorf("%w: Pattern cannot be empty", ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// rows of the pattern
+func (a PatternArgs) rows() []string {
+	return strings.Split(strings.Trim(strings.ReplaceAll(a.Pattern, "\r\n", "\n"), "\n"), "\n")
+}
+
+// Find the top left coordinates of every occurrence of a pattern, row by row
+func (c Canvas) Find(args PatternArgs) []Coordinates {
+	var (
+		rows  = args.rows()
+		found = []Coordinates{}
+		width = 0
+	)
+
+	for _, r := range rows {
+		if len(r) > width {
+			width = len(r)
+		}
+	}
+
+	if len(c.Content) < c.Width*c.Height {
+		return found
+	}
+
+	for y := 0; y+len(rows) <= c.Height; y++ {
+		for x := 0; x+width <= c.Width; x++ {
+			if c.matchesAt(rows, x, y) {
+				found = append(found, Coordinates{X: x, Y: y})
+			}
+		}
+	}
+
+	return found
+}
+
+func (c Canvas) matchesAt(rows []string, x, y int) bool {
+	for i, r := range rows {
+		var start = (y+i)*c.Width + x
+		if c.Content[start:start+len(r)] != r {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FindPattern within a canvas, returning the top left coordinates of every occurrence
+func (s CanvasService) FindPattern(ctx context.Context, id string, args PatternArgs) ([]Coordinates, error) {
+	s.Logger.Debug("FindPattern::Validating", zap.String("id", id))
+
+	if err := args.Validate(); err != nil {
+		s.Logger.Debug("FindPattern::Invalid", zap.Error(err))
+		return nil, err
+	}
+
+	var canvas, err = s.Repo.Get(ctx, id)
+	if err != nil {
+		s.Logger.Debug("FindPattern::GetFailed", zap.Error(err))
+		return nil, err
+	}
+
+	var found = canvas.Find(args)
+	s.Logger.Debug("FindPattern::Found", zap.Int("count", len(found)))
+
+	return found, nil
+}
@@ -0,0 +1,141 @@
+package ascanvas_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/broadcaster/memory"
+	"github.com/fluxynet/ascanvas/internal"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
+)
+
+var searched = []ascanvas.Canvas{
+	{Id: "1", Name: "Cat", Content: `/\_/\ ( o.o)`, Width: 6, Height: 2},
+	{Id: "2", Name: "Dog", Content: "catalog", Width: 7, Height: 1},
+	{Id: "3", Name: "Bird", Content: "~~v~~", Width: 5, Height: 1},
+	{Id: "4", Name: "Another cat", Content: ".....", Width: 5, Height: 1},
+}
+
+func TestSearchCanvases(t *testing.T) {
+	tests := []struct {
+		name string
+		args ascanvas.SearchArgs
+		want []string
+	}{
+		{name: "name first, ignoring case", args: ascanvas.SearchArgs{Query: "CAT"}, want: []string{"4", "1", "2"}},
+		{name: "limit", args: ascanvas.SearchArgs{Query: "cat", Limit: 2}, want: []string{"4", "1"}},
+		{name: "motif", args: ascanvas.SearchArgs{Query: "o.o"}, want: []string{"1"}},
+		{name: "short", args: ascanvas.SearchArgs{Query: "v"}, want: []string{"3"}},
+		{name: "none", args: ascanvas.SearchArgs{Query: "fish"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got = []string{}
+			for _, r := range ascanvas.SearchCanvases(searched, tt.args) {
+				got = append(got, r.Id)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchCanvases() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanvas_Find(t *testing.T) {
+	var canvas = internal.CanvasFromText("1", "Foo", `
++-+...+-+
+| |.+-+ |
++-+.| |-+
+....+-+..`)
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []ascanvas.Coordinates
+	}{
+		{
+			name:    "boxes",
+			pattern: "\n+-+\n| |\n+-+\n",
+			want:    []ascanvas.Coordinates{{X: 0, Y: 0}, {X: 4, Y: 1}},
+		},
+		{
+			name:    "ragged rows",
+			pattern: "+-+\n|",
+			want:    []ascanvas.Coordinates{{X: 0, Y: 0}, {X: 4, Y: 1}},
+		},
+		{
+			name:    "single row",
+			pattern: "..",
+			want:    []ascanvas.Coordinates{{X: 3, Y: 0}, {X: 4, Y: 0}, {X: 0, Y: 3}, {X: 1, Y: 3}, {X: 2, Y: 3}, {X: 7, Y: 3}},
+		},
+		{
+			name:    "crlf",
+			pattern: "+-+\r\n| |",
+			want:    []ascanvas.Coordinates{{X: 0, Y: 0}, {X: 4, Y: 1}},
+		},
+		{
+			name:    "larger than canvas",
+			pattern: "..........",
+			want:    []ascanvas.Coordinates{},
+		},
+		{
+			name:    "absent",
+			pattern: "@",
+			want:    []ascanvas.Coordinates{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canvas.Find(ascanvas.PatternArgs{Pattern: tt.pattern}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanvasService_Search(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		repo = rm.New()
+		s    = ascanvas.CanvasService{
+			Repo:        repo,
+			BroadCaster: memory.New(),
+			Logger:      zaptest.NewLogger(t),
+		}
+	)
+
+	for i := range searched {
+		if err := repo.Create(ctx, searched[i]); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	if _, err := s.Search(ctx, ascanvas.SearchArgs{}); !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("Search() without query error = %v, want ErrInvalidInput", err)
+	}
+
+	if got, err := s.Search(ctx, ascanvas.SearchArgs{Query: "~v~"}); err != nil || !reflect.DeepEqual(got, []ascanvas.CanvasSummary{searched[2].Summary()}) {
+		t.Errorf("Search() got = %v, %v", got, err)
+	}
+
+	if _, err := s.FindPattern(ctx, "1", ascanvas.PatternArgs{Pattern: "\n\n"}); !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("FindPattern() without pattern error = %v, want ErrInvalidInput", err)
+	}
+
+	if _, err := s.FindPattern(ctx, "404", ascanvas.PatternArgs{Pattern: "x"}); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("FindPattern() of unknown canvas error = %v, want ErrNotFound", err)
+	}
+
+	var want = []ascanvas.Coordinates{{X: 0, Y: 0}}
+	if got, err := s.FindPattern(ctx, "1", ascanvas.PatternArgs{Pattern: "/\\\n( "}); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("FindPattern() got = %v, %v; want %v", got, err, want)
+	}
+}
@@ -0,0 +1,144 @@
+package ascanvas
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// TextFormat of canvases rendered as text
+type TextFormat string
+
+const (
+	// TextPlain is one row per line, without decoration
+	TextPlain TextFormat = "text"
+
+	// TextANSI is one row per line, colored with escape codes so that the canvas stands out in a terminal
+	TextANSI TextFormat = "ansi"
+
+	// TextHTML is a standalone html document with the canvas in a <pre> element
+	TextHTML TextFormat = "html"
+)
+
+// ContentType of text in the format
+func (f TextFormat) ContentType() string {
+	if f == TextHTML {
+		return "text/html; charset=utf-8"
+	}
+
+	return "text/plain; charset=utf-8"
+}
+
+// TextArgs to render a canvas as text; zero values are replaced by defaults
+type TextArgs struct {
+	Format TextFormat `json:"format"`
+
+	// Foreground and Background colors, as #rgb or #rrggbb; unused by TextPlain
+	Foreground string `json:"foreground"`
+	Background string `json:"background"`
+}
+
+// WithDefaults for zero values
+func (a TextArgs) WithDefaults() TextArgs {
+	if a.Format == "" {
+		a.Format = TextPlain
+	}
+
+	if a.Foreground == "" {
+		a.Foreground = DefaultForeground
+	}
+
+	if a.Background == "" {
+		a.Background = DefaultBackground
+	}
+
+	return a
+}
+
+// Validate args, once defaults are applied
+func (a TextArgs) Validate() error {
+	var errs []string
+
+	if a.Format != TextPlain && a.Format != TextANSI && a.Format != TextHTML {
+		errs = append(errs, "Format must be text, ansi or html")
+	}
+
+	if _, err := parseColor(a.Foreground); err != nil {
+		errs = append(errs, "Foreground must be a color as #rgb or #rrggbb")
+	}
+
+	if _, err := parseColor(a.Background); err != nil {
+		errs = append(errs, "Background must be a color as #rgb or #rrggbb")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidInput, strings.Join(errs, ", "))
+}
+
+// Rows of the canvas from top to bottom; missing content is padded with spaces and
+// characters that are not printable ascii are replaced by a question mark, as they are drawn in images
+func (c Canvas) Rows() []string {
+	var rows = make([]string, c.Height)
+
+	for y := range rows {
+		var b = []byte(strings.Repeat(" ", c.Width))
+
+		for x := range b {
+			if p := y*c.Width + x; p < len(c.Content) {
+				b[x] = printable(c.Content[p])
+			}
+		}
+
+		rows[y] = string(b)
+	}
+
+	return rows
+}
+
+func printable(c byte) byte {
+	if c < ' ' || c > '~' {
+		return '?'
+	}
+
+	return c
+}
+
+// WriteText of the canvas, as per args once defaults are applied
+func (c Canvas) WriteText(w io.Writer, args TextArgs) error {
+	args = args.WithDefaults()
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	var (
+		fg, _ = parseColor(args.Foreground)
+		bg, _ = parseColor(args.Background)
+		b     = bufio.NewWriter(w)
+	)
+
+	switch args.Format {
+	case TextPlain:
+		for _, row := range c.Rows() {
+			b.WriteString(row)
+			b.WriteByte('\n')
+		}
+	case TextANSI:
+		// colors are reset before every line break, so that terminals do not paint the rest of lines
+		for _, row := range c.Rows() {
+			fmt.Fprintf(b, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm%s\x1b[0m\n", fg.R, fg.G, fg.B, bg.R, bg.G, bg.B, row)
+		}
+	case TextHTML:
+		fmt.Fprintf(b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(c.Name))
+		fmt.Fprintf(b, "<style>pre{display:inline-block;margin:0;padding:1ch;line-height:1.2;color:#%02x%02x%02x;background:#%02x%02x%02x}</style>\n", fg.R, fg.G, fg.B, bg.R, bg.G, bg.B)
+		b.WriteString("</head>\n<body>\n<pre>")
+		b.WriteString(html.EscapeString(strings.Join(c.Rows(), "\n")))
+		b.WriteString("</pre>\n</body>\n</html>\n")
+	}
+
+	return b.Flush()
+}
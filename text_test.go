@@ -0,0 +1,106 @@
+package ascanvas_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fluxynet/ascanvas"
+)
+
+func TestCanvas_Rows(t *testing.T) {
+	tests := []struct {
+		name   string
+		canvas ascanvas.Canvas
+		want   []string
+	}{
+		{
+			name:   "empty",
+			canvas: ascanvas.Canvas{},
+			want:   []string{},
+		},
+		{
+			name:   "rows",
+			canvas: ascanvas.Canvas{Content: "ab cd ", Width: 3, Height: 2},
+			want:   []string{"ab ", "cd "},
+		},
+		{
+			name:   "short content",
+			canvas: ascanvas.Canvas{Content: "abcd", Width: 3, Height: 2},
+			want:   []string{"abc", "d  "},
+		},
+		{
+			name:   "not printable",
+			canvas: ascanvas.Canvas{Content: "a\x1b\n\xff", Width: 2, Height: 2},
+			want:   []string{"a?", "??"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.canvas.Rows(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rows() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanvas_WriteText(t *testing.T) {
+	var canvas = ascanvas.Canvas{Name: "<b>Box</b>", Content: "+-+|&|+-+", Width: 3, Height: 3}
+
+	tests := []struct {
+		name    string
+		args    ascanvas.TextArgs
+		want    string
+		wantErr error
+	}{
+		{
+			name: "text by default",
+			want: "+-+\n|&|\n+-+\n",
+		},
+		{
+			name: "text ignores colors",
+			args: ascanvas.TextArgs{Format: ascanvas.TextPlain, Foreground: "#f00"},
+			want: "+-+\n|&|\n+-+\n",
+		},
+		{
+			name: "ansi",
+			args: ascanvas.TextArgs{Format: ascanvas.TextANSI, Foreground: "#f00", Background: "#000080"},
+			want: "\x1b[38;2;255;0;0m\x1b[48;2;0;0;128m+-+\x1b[0m\n" +
+				"\x1b[38;2;255;0;0m\x1b[48;2;0;0;128m|&|\x1b[0m\n" +
+				"\x1b[38;2;255;0;0m\x1b[48;2;0;0;128m+-+\x1b[0m\n",
+		},
+		{
+			name: "html",
+			args: ascanvas.TextArgs{Format: ascanvas.TextHTML},
+			want: "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>&lt;b&gt;Box&lt;/b&gt;</title>\n" +
+				"<style>pre{display:inline-block;margin:0;padding:1ch;line-height:1.2;color:#000000;background:#ffffff}</style>\n" +
+				"</head>\n<body>\n<pre>+-+\n|&amp;|\n+-+</pre>\n</body>\n</html>\n",
+		},
+		{
+			name:    "invalid format",
+			args:    ascanvas.TextArgs{Format: "rtf"},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+		{
+			name:    "invalid color",
+			args:    ascanvas.TextArgs{Format: ascanvas.TextANSI, Background: "navy"},
+			wantErr: ascanvas.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+
+			if err := canvas.WriteText(&b, tt.args); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WriteText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := b.String(); got != tt.want {
+				t.Errorf("WriteText() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
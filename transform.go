@@ -73,6 +73,41 @@ func TransformFloodfill(canvas *Canvas, args TransformFloodfillArgs) error {
 	return nil
 }
 
+// floodfillReach is the cells a floodfill starting at start goes through, i.e. those of the same character connected to it
+func floodfillReach(canvas Canvas, start Coordinates) []Coordinates {
+	if !canvas.Contains(start) || len(canvas.Content) < canvas.Width*canvas.Height {
+		return nil
+	}
+
+	var (
+		pattern = canvas.Content[start.Y*canvas.Width+start.X]
+		visited = make([]bool, len(canvas.Content))
+		queue   = []Coordinates{start}
+		reached []Coordinates
+	)
+
+	visited[start.Y*canvas.Width+start.X] = true
+
+	for len(queue) != 0 {
+		var p = queue[0]
+		queue = queue[1:]
+		reached = append(reached, p)
+
+		for _, next := range []Coordinates{{X: p.X, Y: p.Y - 1}, {X: p.X, Y: p.Y + 1}, {X: p.X + 1, Y: p.Y}, {X: p.X - 1, Y: p.Y}} {
+			if !canvas.Contains(next) {
+				continue
+			}
+
+			if i := next.Y*canvas.Width + next.X; !visited[i] && canvas.Content[i] == pattern {
+				visited[i] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return reached
+}
+
 func transformFloodfill(canvas *Canvas, args TransformFloodfillArgs, grid [][]string, pattern string) {
 	var (
 		queue   = []Coordinates{args.Start}
@@ -123,3 +158,40 @@ func transformFloodfill(canvas *Canvas, args TransformFloodfillArgs, grid [][]st
 
 	canvas.FromGrid(grid)
 }
+
+// Revert returns the rectangles which, drawn on after, turn the cells changed since before back as they were;
+// one rectangle per run of changed cells of a row having the same previous character
+func Revert(before, after Canvas) []TransformRectangleArgs {
+	var rects []TransformRectangleArgs
+
+	if before.Width != after.Width || len(before.Content) != len(after.Content) {
+		return nil
+	}
+
+	for p := 0; p < len(after.Content); p++ {
+		if before.Content[p] == after.Content[p] {
+			continue
+		}
+
+		var (
+			x    = p % after.Width
+			last = len(rects) - 1
+		)
+
+		// extends the previous rectangle when it ends just before, on the same row, with the same character
+		if last >= 0 && rects[last].TopLeft.Y == p/after.Width &&
+			rects[last].TopLeft.X+rects[last].Width == x && rects[last].Fill == before.Content[p:p+1] {
+			rects[last].Width++
+			continue
+		}
+
+		rects = append(rects, TransformRectangleArgs{
+			TopLeft: Coordinates{X: x, Y: p / after.Width},
+			Width:   1,
+			Height:  1,
+			Fill:    before.Content[p : p+1],
+		})
+	}
+
+	return rects
+}
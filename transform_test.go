@@ -822,3 +822,67 @@ OOOOOXXXXX------------------
 		})
 	}
 }
+
+func TestRevert(t *testing.T) {
+	tests := []struct {
+		name   string
+		before *ascanvas.Canvas
+		after  *ascanvas.Canvas
+		want   []ascanvas.TransformRectangleArgs
+	}{
+		{
+			name:   "unchanged",
+			before: internal.CanvasFromText("1", "canvas 1", "\n...\n..."),
+			after:  internal.CanvasFromText("1", "canvas 1", "\n...\n..."),
+		},
+		{
+			name:   "runs of a row",
+			before: internal.CanvasFromText("1", "canvas 1", "\n..ab\n...."),
+			after:  internal.CanvasFromText("1", "canvas 1", "\nxxxx\n.x.x"),
+			want: []ascanvas.TransformRectangleArgs{
+				{TopLeft: ascanvas.Coordinates{X: 0, Y: 0}, Width: 2, Height: 1, Fill: "."},
+				{TopLeft: ascanvas.Coordinates{X: 2, Y: 0}, Width: 1, Height: 1, Fill: "a"},
+				{TopLeft: ascanvas.Coordinates{X: 3, Y: 0}, Width: 1, Height: 1, Fill: "b"},
+				{TopLeft: ascanvas.Coordinates{X: 1, Y: 1}, Width: 1, Height: 1, Fill: "."},
+				{TopLeft: ascanvas.Coordinates{X: 3, Y: 1}, Width: 1, Height: 1, Fill: "."},
+			},
+		},
+		{
+			name:   "not across rows",
+			before: internal.CanvasFromText("1", "canvas 1", "\n..\n.."),
+			after:  internal.CanvasFromText("1", "canvas 1", "\n.o\no."),
+			want: []ascanvas.TransformRectangleArgs{
+				{TopLeft: ascanvas.Coordinates{X: 1, Y: 0}, Width: 1, Height: 1, Fill: "."},
+				{TopLeft: ascanvas.Coordinates{X: 0, Y: 1}, Width: 1, Height: 1, Fill: "."},
+			},
+		},
+		{
+			name:   "resized",
+			before: internal.CanvasFromText("1", "canvas 1", "\n..\n.."),
+			after:  internal.CanvasFromText("1", "canvas 1", "\n...\n..."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got = ascanvas.Revert(*tt.before, *tt.after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Revert() got = %v, want %v", got, tt.want)
+			}
+
+			if len(tt.before.Content) != len(tt.after.Content) {
+				return
+			}
+
+			for _, args := range got {
+				if err := ascanvas.TransformRectangle(tt.after, args); err != nil {
+					t.Fatalf("TransformRectangle() error = %v", err)
+				}
+			}
+
+			if tt.after.Content != tt.before.Content {
+				t.Errorf("Revert() drawn got = %q, want %q", tt.after.Content, tt.before.Content)
+			}
+		})
+	}
+}
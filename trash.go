@@ -0,0 +1,239 @@
+package ascanvas
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CanvasEventRestored is when a canvas is taken out of the trash
+const CanvasEventRestored CanvasEventName = "RESTORED"
+
+// DeletedCanvas is a Canvas in the trash
+type DeletedCanvas struct {
+	Canvas
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by,omitempty"`
+}
+
+// AsLogFields is a helper for logging
+func (d DeletedCanvas) AsLogFields() []zap.Field {
+	return append(
+		d.Canvas.AsLogFields(),
+		zap.Time("DeletedAt", d.DeletedAt),
+		zap.String("DeletedBy", d.DeletedBy),
+	)
+}
+
+// CanvasTrash keeps deleted canvases until they are restored or purged
+type CanvasTrash interface {
+	// Put a deleted canvas in the trash, replacing any other with the same id
+	Put(ctx context.Context, canvas DeletedCanvas) error
+
+	// Get a canvas from the trash; ErrNotFound when it is not there
+	Get(ctx context.Context, id string) (*DeletedCanvas, error)
+
+	// List canvases in the trash, most recently deleted first
+	List(ctx context.Context) ([]DeletedCanvas, error)
+
+	// Remove a canvas from the trash; removing one which is not there is not an error
+	Remove(ctx context.Context, id string) error
+
+	// Expire removes and returns canvases deleted before a time
+	Expire(ctx context.Context, before time.Time) ([]DeletedCanvas, error)
+}
+
+// ListTrash of deleted canvases that can still be restored
+func (s CanvasService) ListTrash(ctx context.Context) ([]DeletedCanvas, error) {
+	if s.Trash == nil {
+		return nil, ErrNotSupported
+	}
+
+	s.Logger.Debug("ListTrash::Fetching")
+
+	var items, err = s.Trash.List(ctx)
+	if err == nil {
+		s.Logger.Debug("ListTrash::Fetched", zap.Int("count", len(items)))
+	} else {
+		s.Logger.Error("ListTrash::Failed", zap.Error(err))
+	}
+
+	return items, err
+}
+
+// Restore a canvas from the trash, as it was when deleted
+func (s CanvasService) Restore(ctx context.Context, id string) (*Canvas, error) {
+	if s.Trash == nil {
+		return nil, ErrNotSupported
+	}
+
+	s.Logger.Debug("Restore::Fetching", zap.String("id", id))
+
+	var deleted, err = s.Trash.Get(ctx, id)
+	if err == ErrNotFound {
+		s.Logger.Debug("Restore::NotFound", zap.String("id", id))
+		return nil, err
+	} else if err != nil {
+		s.Logger.Error("Restore::Fetch::Failed", zap.Error(err))
+		return nil, err
+	}
+
+	var canvas = deleted.Canvas
+
+	// taken out of the trash first, the canvas being put back if it cannot be created
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		if err := s.Trash.Remove(ctx, id); err != nil {
+			s.Logger.Error("Restore::Remove::Failed", zap.Error(err))
+			return err
+		}
+
+		if err := s.Repo.Create(ctx, canvas); err != nil {
+			s.Logger.Error("Restore::Create::Failed", zap.Error(err))
+			s.undo(ctx, "Restore", func() error { return s.Trash.Put(ctx, *deleted) })
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Debug("Restore::Restored", canvas.AsLogFields()...)
+	s.Broadcast(ctx, s.BroadCaster, s.Logger, CanvasEvent{
+		Name:   CanvasEventRestored,
+		Canvas: canvas,
+	})
+
+	return &canvas, nil
+}
+
+// Purge a canvas permanently, be it in the trash or not; ErrNotFound when it is nowhere to be found
+func (s CanvasService) Purge(ctx context.Context, id string) error {
+	s.Logger.Debug("Purge::Fetching", zap.String("id", id))
+
+	var (
+		found       bool
+		canvas, err = s.Repo.Get(ctx, id)
+	)
+
+	switch err {
+	case nil:
+		found = true
+
+		if err = s.Repo.Delete(ctx, id); err != nil {
+			s.Logger.Error("Purge::Delete::Failed", zap.Error(err))
+			return err
+		}
+
+		s.Broadcast(ctx, s.BroadCaster, s.Logger, CanvasEvent{
+			Name:   CanvasEventDeleted,
+			Canvas: *canvas,
+		})
+	case ErrNotFound:
+	default:
+		s.Logger.Error("Purge::Fetch::Failed", zap.Error(err))
+		return err
+	}
+
+	if s.Trash != nil {
+		if _, err = s.Trash.Get(ctx, id); err == nil {
+			found = true
+			err = s.Trash.Remove(ctx, id)
+		}
+
+		if err != nil && err != ErrNotFound {
+			s.Logger.Error("Purge::Trash::Failed", zap.Error(err))
+			return err
+		}
+	}
+
+	// the history of a canvas deleted without trash is all there is left of it
+	if !found && s.History != nil {
+		var revisions, err = s.History.List(ctx, id)
+		if err != nil {
+			s.Logger.Error("Purge::History::Failed", zap.Error(err))
+			return err
+		}
+
+		found = len(revisions) != 0
+	}
+
+	if !found {
+		s.Logger.Debug("Purge::NotFound", zap.String("id", id))
+		return ErrNotFound
+	}
+
+	s.deleteRegisters(ctx, id)
+	s.deleteFrames(ctx, id)
+	s.deleteHistory(ctx, id)
+	s.Logger.Debug("Purge::Purged", zap.String("id", id))
+
+	return nil
+}
+
+// PurgeExpired removes canvases deleted longer than retention ago from the trash; meant to be called periodically
+func (s CanvasService) PurgeExpired(ctx context.Context, retention time.Duration) error {
+	if s.Trash == nil {
+		return nil
+	}
+
+	var expired, err = s.Trash.Expire(ctx, s.now().Add(-retention))
+	if err != nil {
+		s.Logger.Error("PurgeExpired::Failed", zap.Error(err))
+		return err
+	}
+
+	for i := range expired {
+		s.deleteRegisters(ctx, expired[i].Id)
+		s.deleteFrames(ctx, expired[i].Id)
+		s.deleteHistory(ctx, expired[i].Id)
+		s.Logger.Debug("PurgeExpired::Purged", expired[i].AsLogFields()...)
+	}
+
+	return nil
+}
+
+// trash a canvas instead of deleting it, taking it out of the trash again if it cannot be deleted
+func (s CanvasService) trash(ctx context.Context, canvas Canvas) error {
+	var deleted = DeletedCanvas{
+		Canvas:    canvas,
+		DeletedAt: s.now(),
+		DeletedBy: Author(ctx),
+	}
+
+	return s.inTx(ctx, func(ctx context.Context) error {
+		if err := s.Trash.Put(ctx, deleted); err != nil {
+			return err
+		}
+
+		if err := s.Repo.Delete(ctx, canvas.Id); err != nil {
+			s.undo(ctx, "Delete", func() error { return s.Trash.Remove(ctx, canvas.Id) })
+			return err
+		}
+
+		return nil
+	})
+}
+
+// inTx runs fn in one transaction with the Transactor, or as is without
+func (s CanvasService) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.Transactor == nil {
+		return fn(ctx)
+	}
+
+	return s.Transactor.InTx(ctx, fn)
+}
+
+// undo a step of op when a later one failed, unless the transaction of the Transactor is rolled back anyway
+func (s CanvasService) undo(ctx context.Context, op string, step func() error) {
+	if s.Transactor != nil {
+		return
+	}
+
+	if err := step(); err != nil {
+		s.Logger.Error(op+"::Undo::Failed", zap.Error(err))
+	}
+}
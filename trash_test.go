@@ -0,0 +1,257 @@
+package ascanvas_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	mb "github.com/fluxynet/ascanvas/broadcaster/mocks"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
+)
+
+// events broadcast so far, by name
+func broadcast(brd *mb.CanvasBroadcaster) []ascanvas.CanvasEventName {
+	var names []ascanvas.CanvasEventName
+
+	for _, c := range brd.Calls {
+		names = append(names, c.Arguments.Get(1).(ascanvas.CanvasEvent).Name)
+	}
+
+	return names
+}
+
+func TestCanvasService_Trash(t *testing.T) {
+	var (
+		ctx   = ascanvas.WithAuthor(context.Background(), "carol")
+		clock = now
+		brd   = &mb.CanvasBroadcaster{}
+		s     = ascanvas.CanvasService{
+			Repo:        rm.New(),
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Trash:       rm.NewTrash(),
+			Clock: func() time.Time {
+				return clock
+			},
+		}
+		canvases = []ascanvas.Canvas{
+			{Id: "1", Name: "One", Content: "1", Width: 1, Height: 1},
+			{Id: "2", Name: "Two", Content: "2", Width: 1, Height: 1},
+		}
+	)
+
+	brd.On("Broadcast", mock.Anything, mock.Anything).Return(nil)
+
+	for i := range canvases {
+		if err := s.Repo.Create(ctx, canvases[i]); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	if err := s.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	clock = clock.Add(time.Hour)
+	if err := s.Delete(ctx, "2"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := s.Get(ctx, "1"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Get() of deleted canvas error = %v, want ErrNotFound", err)
+	}
+
+	var trashed, err = s.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("ListTrash() error = %v", err)
+	}
+
+	var want = []ascanvas.DeletedCanvas{
+		{Canvas: canvases[1], DeletedAt: now.Add(time.Hour), DeletedBy: "carol"},
+		{Canvas: canvases[0], DeletedAt: now, DeletedBy: "carol"},
+	}
+
+	if !reflect.DeepEqual(trashed, want) {
+		t.Errorf("ListTrash() got = %v, want %v", trashed, want)
+	}
+
+	restored, err := s.Restore(ctx, "1")
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	} else if !reflect.DeepEqual(*restored, canvases[0]) {
+		t.Errorf("Restore() got = %v, want %v", restored, canvases[0])
+	}
+
+	if got, err := s.Get(ctx, "1"); err != nil || !reflect.DeepEqual(*got, canvases[0]) {
+		t.Errorf("Get() of restored canvas got = %v, %v", got, err)
+	}
+
+	if _, err = s.Restore(ctx, "1"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Restore() again error = %v, want ErrNotFound", err)
+	}
+
+	// only the canvas deleted more than a day ago goes
+	clock = clock.Add(24 * time.Hour)
+	if err = s.PurgeExpired(ctx, 24*time.Hour); err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+
+	if trashed, _ = s.ListTrash(ctx); len(trashed) != 1 {
+		t.Errorf("ListTrash() after PurgeExpired got = %v, want 1 canvas", trashed)
+	}
+
+	clock = clock.Add(time.Hour)
+	if err = s.PurgeExpired(ctx, 24*time.Hour); err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+
+	if trashed, _ = s.ListTrash(ctx); len(trashed) != 0 {
+		t.Errorf("ListTrash() after PurgeExpired got = %v, want none", trashed)
+	}
+
+	// purging skips the trash
+	if err = s.Purge(ctx, "1"); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+
+	if _, err = s.Restore(ctx, "1"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Restore() of purged canvas error = %v, want ErrNotFound", err)
+	}
+
+	if err = s.Purge(ctx, "1"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Purge() again error = %v, want ErrNotFound", err)
+	}
+
+	var events = []ascanvas.CanvasEventName{
+		ascanvas.CanvasEventDeleted,
+		ascanvas.CanvasEventDeleted,
+		ascanvas.CanvasEventRestored,
+		ascanvas.CanvasEventDeleted,
+	}
+
+	if got := broadcast(brd); !reflect.DeepEqual(got, events) {
+		t.Errorf("events got = %v, want %v", got, events)
+	}
+}
+
+func TestCanvasService_TrashDisabled(t *testing.T) {
+	var (
+		ctx = context.Background()
+		brd = &mb.CanvasBroadcaster{}
+		s   = ascanvas.CanvasService{
+			Repo:        rm.New(),
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			Broadcast:   ascanvas.SyncBroadcast,
+		}
+	)
+
+	brd.On("Broadcast", mock.Anything, mock.Anything).Return(nil)
+
+	if _, err := s.ListTrash(ctx); !errors.Is(err, ascanvas.ErrNotSupported) {
+		t.Errorf("ListTrash() error = %v, want ErrNotSupported", err)
+	}
+
+	if _, err := s.Restore(ctx, "1"); !errors.Is(err, ascanvas.ErrNotSupported) {
+		t.Errorf("Restore() error = %v, want ErrNotSupported", err)
+	}
+
+	if err := s.PurgeExpired(ctx, time.Hour); err != nil {
+		t.Errorf("PurgeExpired() error = %v, want nil", err)
+	}
+
+	if err := s.Repo.Create(ctx, ascanvas.Canvas{Id: "1", Content: "x", Width: 1, Height: 1}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := s.Purge(ctx, "1"); err != nil {
+		t.Errorf("Purge() error = %v", err)
+	}
+
+	if _, err := s.Get(ctx, "1"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Get() of purged canvas error = %v, want ErrNotFound", err)
+	}
+}
+
+// failingRepo fails to create or delete canvases, after doing it if done is set
+type failingRepo struct {
+	ascanvas.CanvasRepository
+	done bool
+}
+
+var errFailing = errors.New("failing")
+
+func (r failingRepo) Create(ctx context.Context, canvas ascanvas.Canvas) error {
+	if r.done {
+		_ = r.CanvasRepository.Create(ctx, canvas)
+	}
+
+	return errFailing
+}
+
+func (r failingRepo) Delete(ctx context.Context, id string) error {
+	if r.done {
+		_ = r.CanvasRepository.Delete(ctx, id)
+	}
+
+	return errFailing
+}
+
+func TestCanvasService_TrashUndo(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		brd   = &mb.CanvasBroadcaster{}
+		repo  = rm.New()
+		trash = rm.NewTrash()
+		s     = ascanvas.CanvasService{
+			Repo:        failingRepo{CanvasRepository: repo},
+			BroadCaster: brd,
+			Logger:      zaptest.NewLogger(t),
+			Broadcast:   ascanvas.SyncBroadcast,
+			Trash:       trash,
+			Clock:       ascanvas.StaticClock(now),
+		}
+		canvas = ascanvas.Canvas{Id: "1", Name: "One", Content: "1", Width: 1, Height: 1}
+	)
+
+	brd.On("Broadcast", mock.Anything, mock.Anything).Return(nil)
+
+	if err := repo.Create(ctx, canvas); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := s.Delete(ctx, "1"); !errors.Is(err, errFailing) {
+		t.Errorf("Delete() error = %v, want %v", err, errFailing)
+	}
+
+	if got, _ := trash.List(ctx); len(got) != 0 {
+		t.Errorf("trash after failed Delete() got = %v, want empty", got)
+	}
+
+	if err := repo.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if err := trash.Put(ctx, ascanvas.DeletedCanvas{Canvas: canvas, DeletedAt: now}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if _, err := s.Restore(ctx, "1"); !errors.Is(err, errFailing) {
+		t.Errorf("Restore() error = %v, want %v", err, errFailing)
+	}
+
+	if _, err := trash.Get(ctx, "1"); err != nil {
+		t.Errorf("trash after failed Restore() error = %v, want the canvas still there", err)
+	}
+
+	if got := broadcast(brd); len(got) != 0 {
+		t.Errorf("events got = %v, want none", got)
+	}
+}
@@ -1,16 +1,30 @@
 package canvas
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fluxynet/ascanvas"
 	"github.com/fluxynet/ascanvas/web"
 )
 
+// EventSession is sent to an observer which joined a canvas, with its own ascanvas.Presence
+const EventSession = "SESSION"
+
 type WebCanvas struct {
-	Service *ascanvas.CanvasService
-	GetID   web.IDGetter
+	Service   *ascanvas.CanvasService
+	GetID     web.IDGetter
+	GetLockID web.IDGetter
+	GetFrame  web.IDGetter
+
+	// Version of the application, recorded in backups
+	Version string
 }
 
 // Create http.HandleFunc compatible handler for listing ascanvas.Canvas
@@ -18,6 +32,7 @@ type WebCanvas struct {
 // @Accept json
 // @Produce json
 // @Param CreateArgs body ascanvas.CreateArgs true "Canvas creation details"
+// @Param X-Author header string false "Author of the canvas"
 // @Success 201 {array} ascanvas.Canvas
 // @Failure 400 {object} web.Response
 // @Failure 500 {object} web.Response
@@ -28,7 +43,7 @@ func (s WebCanvas) Create(w http.ResponseWriter, r *http.Request) {
 		canvas *ascanvas.Canvas
 		err    error
 
-		ctx = r.Context()
+		ctx = withAuthor(r.Context(), r)
 	)
 
 	err = web.ReadJsonBodyInto(r, &args)
@@ -49,28 +64,142 @@ func (s WebCanvas) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 // List http.HandleFunc compatible handler for listing ascanvas.Canvas
-// @Summary "List all canvas items"
+// @Summary "List canvas items, a page at a time when a limit is given"
+// @Description The cursor of the next page, if any, is in the Link header
 // @Accept json
 // @Produce json
+// @Param limit query int false "Size of a page; all canvas items are listed when omitted"
+// @Param cursor query string false "Cursor of the page, as found in the Link header"
+// @Param name query string false "Only canvas items with a name containing this, ignoring case"
+// @Param prefix query string false "Only canvas items with a name starting with this, ignoring case"
+// @Param sort query string false "id (default), name, created or updated"
+// @Param order query string false "asc (default) or desc"
+// @Param view query string false "summary to leave out the content of canvas items"
 // @Success 200 {array} ascanvas.Canvas
+// @Failure 400 {object} web.Response
 // @Failure 500 {object} web.Response
 // @Router / [get]
 func (s WebCanvas) List(w http.ResponseWriter, r *http.Request) {
-	var items, err = s.Service.List(r.Context())
+	var args, err = listArgs(r)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := s.Service.ListPage(r.Context(), args)
+	if err != nil {
+		web.JsonError(w, httpStatus(err), err)
+		return
+	}
+
+	if page.Next != "" {
+		var next, first = *r.URL, *r.URL
+		var q = r.URL.Query()
+
+		q.Set("cursor", page.Next)
+		next.RawQuery = q.Encode()
+
+		q.Del("cursor")
+		first.RawQuery = q.Encode()
+
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next", <%s>; rel="first"`, next.RequestURI(), first.RequestURI()))
+	}
+
+	if !args.Summary {
+		web.Json(w, http.StatusOK, page.Canvases)
+		return
+	}
+
+	var summaries = make([]ascanvas.CanvasSummary, len(page.Canvases))
+	for i := range page.Canvases {
+		summaries[i] = page.Canvases[i].Summary()
+	}
+
+	web.Json(w, http.StatusOK, summaries)
+}
+
+// listArgs from the query string of a request
+func listArgs(r *http.Request) (ascanvas.ListArgs, error) {
+	var (
+		q    = r.URL.Query()
+		args = ascanvas.ListArgs{
+			Cursor: q.Get("cursor"),
+			Name:   q.Get("name"),
+			Prefix: q.Get("prefix"),
+			Sort:   ascanvas.ListSort(q.Get("sort")),
+		}
+	)
+
+	if l := q.Get("limit"); l != "" {
+		var err error
+		if args.Limit, err = strconv.Atoi(l); err != nil {
+			return args, fmt.Errorf("%w: limit must be a number", ascanvas.ErrInvalidInput)
+		}
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		args.Desc = true
+	default:
+		return args, fmt.Errorf("%w: order must be asc or desc", ascanvas.ErrInvalidInput)
+	}
+
+	switch q.Get("view") {
+	case "", "full":
+	case "summary":
+		args.Summary = true
+	default:
+		return args, fmt.Errorf("%w: view must be full or summary", ascanvas.ErrInvalidInput)
+	}
+
+	return args, nil
+}
+
+// Search http.HandleFunc compatible handler for finding canvas items by name or content
+// @Summary "Find canvas items whose name or content contains a text, ignoring case"
+// @Produce json
+// @Param q query string true "Text to find, such as a word or an ascii motif"
+// @Param limit query int false "Maximum number of results"
+// @Success 200 {array} ascanvas.CanvasSummary
+// @Failure 400 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Router /search [get]
+func (s WebCanvas) Search(w http.ResponseWriter, r *http.Request) {
+	var (
+		q    = r.URL.Query()
+		args = ascanvas.SearchArgs{Query: q.Get("q")}
+		err  error
+	)
+
+	if l := q.Get("limit"); l != "" {
+		if args.Limit, err = strconv.Atoi(l); err != nil {
+			web.JsonError(w, http.StatusBadRequest, fmt.Errorf("%w: limit must be a number", ascanvas.ErrInvalidInput))
+			return
+		}
+	}
+
+	results, err := s.Service.Search(r.Context(), args)
 	if err == nil {
-		web.Json(w, http.StatusOK, items)
+		web.Json(w, http.StatusOK, results)
 		return
 	}
 
-	web.JsonError(w, http.StatusInternalServerError, err)
+	web.JsonError(w, httpStatus(err), err)
 }
 
 // Get http.HandleFunc compatible handler for getting a specific ascanvas.Canvas
-// @Summary Get a specific canvas by id
+// @Summary Get a specific canvas by id, as json or rendered as text, chosen by format or the Accept header
 // @Accept json
 // @Produce json
+// @Produce plain
+// @Produce html
 // @Param id path string true "Identifier of canvas to fetch"
+// @Param format query string false "Rendering, takes precedence over the Accept header" Enums(json, text, ansi, html)
+// @Param fg query string false "Foreground color of ansi and html renderings, as #rgb or #rrggbb"
+// @Param bg query string false "Background color of ansi and html renderings, as #rgb or #rrggbb"
 // @Success 200 {object} ascanvas.Canvas
+// @Failure 400 {object} web.Response
 // @Failure 404 {object} web.Response
 // @Failure 500 {object} web.Response
 // @Router /{id} [get]
@@ -79,6 +208,7 @@ func (s WebCanvas) Get(w http.ResponseWriter, r *http.Request) {
 		id, err = s.GetID(r)
 
 		canvas *ascanvas.Canvas
+		args   ascanvas.TextArgs
 	)
 
 	if err != nil {
@@ -86,20 +216,76 @@ func (s WebCanvas) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if args, err = textArgs(r); err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	canvas, err = s.Service.Get(r.Context(), id)
+	if err != nil {
+		web.JsonError(w, httpStatus(err), err)
+		return
+	}
 
-	if err == nil {
+	w.Header().Set("Vary", "Accept")
+
+	if args.Format == "" {
 		web.Json(w, http.StatusOK, canvas)
 		return
 	}
 
-	web.JsonError(w, http.StatusInternalServerError, err)
+	var b bytes.Buffer
+	if err = canvas.WriteText(&b, args); err != nil {
+		web.JsonError(w, httpStatus(err), err)
+		return
+	}
+
+	web.Print(w, http.StatusOK, args.Format.ContentType(), b.Bytes())
+}
+
+// textArgs from the query string, or the Accept header when no format is given; an empty Format means json
+func textArgs(r *http.Request) (ascanvas.TextArgs, error) {
+	var (
+		q    = r.URL.Query()
+		args = ascanvas.TextArgs{
+			Foreground: q.Get("fg"),
+			Background: q.Get("bg"),
+		}
+	)
+
+	switch q.Get("format") {
+	case "json":
+	case "text":
+		args.Format = ascanvas.TextPlain
+	case "ansi":
+		args.Format = ascanvas.TextANSI
+	case "html":
+		args.Format = ascanvas.TextHTML
+	case "":
+		switch web.Negotiate(r, web.ContentTypeJSON, web.ContentTypeText, web.ContentTypeANSI, web.ContentTypeHTML) {
+		case web.ContentTypeText:
+			args.Format = ascanvas.TextPlain
+		case web.ContentTypeANSI:
+			args.Format = ascanvas.TextANSI
+		case web.ContentTypeHTML:
+			args.Format = ascanvas.TextHTML
+		}
+	default:
+		return args, fmt.Errorf("%w: format must be json, text, ansi or html", ascanvas.ErrInvalidInput)
+	}
+
+	if args.Format == "" {
+		return args, nil
+	}
+
+	return args, args.WithDefaults().Validate()
 }
 
 // Delete http.HandleFunc compatible handler for deleting a specific ascanvas.Canvas
 // @Summary "Delete a specific canvas item by id"
 // @Accept json
 // @Param id path string true "Identifier of canvas to delete"
+// @Param X-Author header string false "Author of the deletion, when deleted canvases go to the trash"
 // @Success 204
 // @Failure 404 {object} web.Response
 // @Failure 500 {object} web.Response
@@ -112,7 +298,7 @@ func (s WebCanvas) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.Service.Delete(r.Context(), id)
+	err = s.Service.Delete(withAuthor(r.Context(), r), id)
 
 	if err == nil {
 		w.WriteHeader(http.StatusNoContent)
@@ -131,6 +317,8 @@ func (s WebCanvas) Delete(w http.ResponseWriter, r *http.Request) {
 // @Router /events [get]
 
 // Observe http.HandleFunc compatible handler for server-sent events of ascanvas.Canvas
+// When a name is given, the observer joins the canvas: a SESSION event identifies its ascanvas.Presence,
+// and a LEAVE event is emitted once the connection drops.
 // @Summary "Obtain an SSE live stream of canvas events for a specific canvas id"
 // @Accept json
 // @Produce text/event-stream
@@ -139,15 +327,18 @@ func (s WebCanvas) Delete(w http.ResponseWriter, r *http.Request) {
 // @Failure 500
 // @Router /{id}/events [get]
 // @Param id path string true "Identifier of canvas to observe"
+// @Param name query string false "Display name to join the canvas with"
 func (s WebCanvas) Observe(w http.ResponseWriter, r *http.Request) {
 	var (
 		f, ok = w.(http.Flusher)
 		ctx   = r.Context()
+		name  = r.URL.Query().Get("name")
 
-		id     string
-		err    error
-		stop   ascanvas.StopObserveFunc
-		events <-chan ascanvas.CanvasEvent
+		id       string
+		err      error
+		stop     ascanvas.StopObserveFunc
+		events   <-chan ascanvas.CanvasEvent
+		presence *ascanvas.Presence
 	)
 
 	if !ok {
@@ -165,18 +356,152 @@ func (s WebCanvas) Observe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if name != "" && id != ascanvas.ObserveALL {
+		presence, err = s.Service.Join(ctx, id, ascanvas.JoinArgs{Name: name})
+		if err != nil {
+			stop()
+			web.JsonError(w, httpStatus(err), err)
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", web.ContentTypeEventStream)
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
+	// clients know they are connected before the first event
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+
 	go func(stop ascanvas.StopObserveFunc) {
-		<-ctx.Done()
-		stop()
+		var heartbeat = time.NewTicker(ascanvas.PresenceHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-heartbeat.C:
+				if presence != nil {
+					s.Service.Heartbeat(ctx, id, *presence)
+				}
+			case <-ctx.Done():
+				stop()
+
+				if presence != nil {
+					s.Service.Leave(context.Background(), id, *presence)
+				}
+
+				return
+			}
+		}
 	}(stop)
 
+	if presence != nil {
+		_ = web.PrintJSONStream(w, f, EventSession, presence)
+	}
+
 	for event := range events {
-		_ = web.PrintJSONStream(w, f, string(event.Name), event.Canvas)
+		_ = web.PrintJSONStream(w, f, string(event.Name), eventData(event))
+	}
+}
+
+// Find http.HandleFunc compatible handler for finding a multi-line snippet within a canvas
+// @Summary "Find where a multi-line snippet occurs within a specific canvas"
+// @Accept json
+// @Produce json
+// @Param id path string true "Identifier of canvas"
+// @Param Pattern body ascanvas.PatternArgs true "Snippet to find, rows separated by new lines"
+// @Success 200 {array} ascanvas.Coordinates
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Router /{id}/find [post]
+func (s WebCanvas) Find(w http.ResponseWriter, r *http.Request) {
+	var (
+		args ascanvas.PatternArgs
+		id   string
+		err  error
+	)
+
+	id, err = s.GetID(r)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = web.ReadJsonBodyInto(r, &args)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	found, err := s.Service.FindPattern(r.Context(), id, args)
+	if err == nil {
+		web.Json(w, http.StatusOK, found)
+		return
 	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// Cursor http.HandleFunc compatible handler for publishing the cursor and selection of an observer
+// @Summary "Publish cursor position and selection of a session on a specific canvas"
+// @Accept json
+// @Param id path string true "Identifier of canvas being observed"
+// @Param Cursor body ascanvas.CursorArgs true "Cursor details"
+// @Success 204
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Failure 501 {object} web.Response
+// @Router /{id}/cursor [post]
+func (s WebCanvas) Cursor(w http.ResponseWriter, r *http.Request) {
+	var (
+		args ascanvas.CursorArgs
+		id   string
+		err  error
+	)
+
+	id, err = s.GetID(r)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = web.ReadJsonBodyInto(r, &args)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = s.Service.MoveCursor(r.Context(), id, args)
+	if err == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// Presence http.HandleFunc compatible handler for listing observers present on a canvas
+// @Summary "List observers present on a specific canvas"
+// @Produce json
+// @Param id path string true "Identifier of canvas"
+// @Success 200 {array} ascanvas.Presence
+// @Failure 400 {object} web.Response
+// @Router /{id}/presence [get]
+func (s WebCanvas) Presence(w http.ResponseWriter, r *http.Request) {
+	var id, err = s.GetID(r)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if s.Service.Presences == nil {
+		web.Json(w, http.StatusOK, []ascanvas.Presence{})
+		return
+	}
+
+	web.Json(w, http.StatusOK, s.Service.Presences.List(id))
 }
 
 // Rectangle http.HandleFunc compatible handler for performing ascanvas.Canvas ascanvas.TransformRectangle
@@ -187,7 +512,10 @@ func (s WebCanvas) Observe(w http.ResponseWriter, r *http.Request) {
 // @Param Transformation body ascanvas.TransformRectangleArgs true "Rectangle transformation details"
 // @Success 200 {object} web.Response
 // @Failure 400
+// @Failure 423
 // @Failure 500
+// @Param X-Lock-Token header string false "Tokens of locks held"
+// @Param X-Author header string false "Author of the change"
 // @Router /{id}/rectangle [patch]
 func (s WebCanvas) Rectangle(w http.ResponseWriter, r *http.Request) {
 	var (
@@ -196,7 +524,7 @@ func (s WebCanvas) Rectangle(w http.ResponseWriter, r *http.Request) {
 		id             string
 		err            error
 
-		ctx = r.Context()
+		ctx = withAuthor(withLockTokens(r), r)
 	)
 
 	id, err = s.GetID(r)
@@ -215,11 +543,9 @@ func (s WebCanvas) Rectangle(w http.ResponseWriter, r *http.Request) {
 	if err == nil {
 		web.Json(w, http.StatusOK, canvas)
 		return
-	} else if errors.Is(err, ascanvas.ErrInvalidInput) {
-		web.JsonError(w, http.StatusBadRequest, err)
-	} else {
-		web.JsonError(w, http.StatusInternalServerError, err)
 	}
+
+	web.JsonError(w, httpStatus(err), err)
 }
 
 // Floodfill http.HandleFunc compatible handler for performing ascanvas.Canvas ascanvas.TransformFloodfill
@@ -230,7 +556,10 @@ func (s WebCanvas) Rectangle(w http.ResponseWriter, r *http.Request) {
 // @Param Transformation body ascanvas.TransformFloodfillArgs true "Flood fill transformation details"
 // @Success 200 {object} web.Response
 // @Failure 400
+// @Failure 423
 // @Failure 500
+// @Param X-Lock-Token header string false "Tokens of locks held"
+// @Param X-Author header string false "Author of the change"
 // @Router /{id}/floodfill [patch]
 func (s WebCanvas) Floodfill(w http.ResponseWriter, r *http.Request) {
 	var (
@@ -239,7 +568,7 @@ func (s WebCanvas) Floodfill(w http.ResponseWriter, r *http.Request) {
 		id             string
 		err            error
 
-		ctx = r.Context()
+		ctx = withAuthor(withLockTokens(r), r)
 	)
 
 	id, err = s.GetID(r)
@@ -258,9 +587,103 @@ func (s WebCanvas) Floodfill(w http.ResponseWriter, r *http.Request) {
 	if err == nil {
 		web.Json(w, http.StatusOK, canvas)
 		return
-	} else if errors.Is(err, ascanvas.ErrInvalidInput) {
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// Sync http.HandleFunc compatible handler for merging timestamped cell writes with ascanvas.CanvasService Sync
+// @Summary "Merge timestamped cell writes and obtain those missing"
+// @Accept json
+// @Produce json
+// @Param id path string true "Identifier of canvas to synchronize"
+// @Param SyncArgs body ascanvas.SyncArgs true "Cell writes made since the last sync"
+// @Param X-Author header string false "Author of the change"
+// @Success 200 {object} ascanvas.SyncResult
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 501 {object} web.Response
+// @Router /{id}/sync [post]
+func (s WebCanvas) Sync(w http.ResponseWriter, r *http.Request) {
+	var (
+		args   ascanvas.SyncArgs
+		result *ascanvas.SyncResult
+		id     string
+		err    error
+	)
+
+	id, err = s.GetID(r)
+	if err != nil {
 		web.JsonError(w, http.StatusBadRequest, err)
-	} else {
-		web.JsonError(w, http.StatusInternalServerError, err)
+		return
 	}
+
+	err = web.ReadJsonBodyInto(r, &args)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err = s.Service.Sync(withAuthor(withLockTokens(r), r), id, args)
+	if err == nil {
+		web.Json(w, http.StatusOK, result)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// httpStatus maps service errors to http status codes
+func httpStatus(err error) int {
+	switch {
+	case errors.Is(err, ascanvas.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, ascanvas.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ascanvas.ErrOutOfBounds):
+		return http.StatusBadRequest
+	case errors.Is(err, ascanvas.ErrLocked):
+		return http.StatusLocked
+	case errors.Is(err, ascanvas.ErrNotSupported):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// eventData is the payload sent to observers for an event
+func eventData(event ascanvas.CanvasEvent) interface{} {
+	switch {
+	case event.Presence != nil:
+		return event.Presence
+	case event.Lock != nil:
+		return event.Lock
+	case event.Frames != nil:
+		return struct {
+			Id     string                `json:"id"`
+			Frames []ascanvas.FrameDelay `json:"frames"`
+		}{Id: event.Canvas.Id, Frames: event.Frames}
+	default:
+		return event.Canvas
+	}
+}
+
+// withLockTokens passes lock tokens from request headers into the context
+func withLockTokens(r *http.Request) context.Context {
+	var tokens []string
+
+	for _, h := range r.Header.Values(web.HeaderLockToken) {
+		for _, t := range strings.Split(h, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+
+	return ascanvas.WithLockTokens(r.Context(), tokens...)
+}
+
+// withAuthor passes the author of changes from request headers into the context
+func withAuthor(ctx context.Context, r *http.Request) context.Context {
+	return ascanvas.WithAuthor(ctx, strings.TrimSpace(r.Header.Get(web.HeaderAuthor)))
 }
@@ -0,0 +1,31 @@
+package canvas
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web"
+)
+
+// Backup http.HandleFunc compatible handler for downloading all ascanvas.Canvas items as a zip archive
+// @Summary "Download a zip archive of all canvases, each as json and as text, with a manifest of checksums"
+// @Description The archive can be restored with the restore subcommand
+// @Produce application/zip
+// @Success 200 {file} binary
+// @Failure 500 {object} web.Response
+// @Router /export.zip [get]
+func (s WebCanvas) Backup(w http.ResponseWriter, r *http.Request) {
+	var (
+		b             bytes.Buffer
+		manifest, err = s.Service.Backup(r.Context(), &b, s.Version)
+	)
+
+	if err != nil {
+		web.JsonError(w, httpStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+manifest.Filename()+`"`)
+	web.Print(w, http.StatusOK, ascanvas.ContentTypeZip, b.Bytes())
+}
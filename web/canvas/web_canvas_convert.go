@@ -0,0 +1,93 @@
+package canvas
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web"
+)
+
+// Convert http.HandleFunc compatible handler for creating an ascanvas.Canvas from an image
+// @Summary "Create a canvas from a png, jpeg or gif image, drawn with characters by luminance"
+// @Accept png
+// @Accept jpeg
+// @Accept gif
+// @Accept mpfd
+// @Produce json
+// @Param name query string false "Name of the canvas; with a file upload, the name field or the file name by default"
+// @Param width query int false "Width of the canvas in characters, 80 by default"
+// @Param aspect query number false "Width of cells divided by their height, 0.5 by default for terminals"
+// @Param dither query bool false "Floyd-Steinberg dithering"
+// @Param ramp query string false "Characters from the lightest to the darkest"
+// @Param file formData file false "Image file, with multipart/form-data"
+// @Param X-Author header string false "Author of the canvas"
+// @Success 200 {object} ascanvas.Canvas
+// @Failure 400 {object} web.Response
+// @Failure 413 {object} web.Response
+// @Failure 415 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Router /convert [post]
+func (s WebCanvas) Convert(w http.ResponseWriter, r *http.Request) {
+	var (
+		u      upload
+		args   ascanvas.ConvertArgs
+		canvas *ascanvas.Canvas
+		err    error
+
+		ctx = withAuthor(r.Context(), r)
+	)
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportBytes)
+
+	if u, err = readUpload(r, "image/png", "image/jpeg", "image/gif"); err != nil {
+		web.JsonError(w, importStatus(err), err)
+		return
+	}
+
+	if args, err = convertArgs(u); err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	canvas, err = s.Service.Convert(ctx, bytes.NewReader(u.Data), args)
+	if err == nil {
+		web.Json(w, http.StatusOK, canvas)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// convertArgs from the fields of an upload
+func convertArgs(u upload) (ascanvas.ConvertArgs, error) {
+	var (
+		args = ascanvas.ConvertArgs{
+			Name: u.Name,
+			Ramp: u.Fields.Get("ramp"),
+		}
+		err error
+	)
+
+	if v := u.Fields.Get("width"); v != "" {
+		if args.Width, err = strconv.Atoi(v); err != nil {
+			return args, fmt.Errorf("%w: width must be a number", ascanvas.ErrInvalidInput)
+		}
+	}
+
+	if v := u.Fields.Get("aspect"); v != "" {
+		if args.Aspect, err = strconv.ParseFloat(v, 64); err != nil {
+			return args, fmt.Errorf("%w: aspect must be a number", ascanvas.ErrInvalidInput)
+		}
+	}
+
+	if v := u.Fields.Get("dither"); v != "" {
+		if args.Dither, err = strconv.ParseBool(v); err != nil {
+			return args, fmt.Errorf("%w: dither must be true or false", ascanvas.ErrInvalidInput)
+		}
+	}
+
+	return args, nil
+}
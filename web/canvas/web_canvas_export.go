@@ -0,0 +1,98 @@
+package canvas
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web"
+)
+
+// Export http.HandleFunc compatible handler for rendering a specific ascanvas.Canvas as an image
+// @Summary "Render a canvas as a png, svg or gif image, drawn with an embedded monospace font, or as an asciicast"
+// @Description Animated canvases are exported with all their frames as gif or cast, with their first frame otherwise
+// @Produce png
+// @Produce image/svg+xml
+// @Produce image/gif
+// @Produce application/x-asciicast
+// @Param id path string true "Identifier of canvas to export"
+// @Param format query string false "Image format, or cast for an asciicast v2 recording" Enums(png, svg, gif, cast)
+// @Param cell_width query int false "Width of every character in pixels"
+// @Param cell_height query int false "Height of every character in pixels"
+// @Param padding query int false "Padding around the canvas in pixels"
+// @Param fg query string false "Foreground color, as #rgb or #rrggbb"
+// @Param bg query string false "Background color, as #rgb or #rrggbb"
+// @Success 200 {file} binary
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Router /{id}/export [get]
+func (s WebCanvas) Export(w http.ResponseWriter, r *http.Request) {
+	var (
+		id, err = s.GetID(r)
+
+		args ascanvas.ImageArgs
+		img  []byte
+	)
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if args, err = imageArgs(r); err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var ctype = args.WithDefaults().Format.ContentType()
+
+	if args.Format == formatCast {
+		img, err = s.Service.ExportCast(r.Context(), id)
+		ctype = ascanvas.ContentTypeCast
+	} else {
+		img, err = s.Service.ExportImage(r.Context(), id, args)
+	}
+
+	if err != nil {
+		web.JsonError(w, httpStatus(err), err)
+		return
+	}
+
+	web.Print(w, http.StatusOK, ctype, img)
+}
+
+// formatCast exports an asciicast recording rather than an image
+const formatCast ascanvas.ImageFormat = "cast"
+
+// imageArgs from the query string
+func imageArgs(r *http.Request) (ascanvas.ImageArgs, error) {
+	var (
+		q    = r.URL.Query()
+		args = ascanvas.ImageArgs{
+			Format:     ascanvas.ImageFormat(q.Get("format")),
+			Foreground: q.Get("fg"),
+			Background: q.Get("bg"),
+		}
+		ints = []struct {
+			name string
+			dst  *int
+		}{
+			{name: "cell_width", dst: &args.CellWidth},
+			{name: "cell_height", dst: &args.CellHeight},
+			{name: "padding", dst: &args.Padding},
+		}
+	)
+
+	for _, i := range ints {
+		if v := q.Get(i.name); v != "" {
+			var err error
+			if *i.dst, err = strconv.Atoi(v); err != nil {
+				return args, fmt.Errorf("%w: %s must be a number", ascanvas.ErrInvalidInput, i.name)
+			}
+		}
+	}
+
+	return args, nil
+}
@@ -0,0 +1,160 @@
+package canvas
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web"
+)
+
+// Frames http.HandleFunc compatible handler for listing frames of a specific ascanvas.Canvas
+// @Summary "List frames of a canvas in order, the first one being the canvas itself"
+// @Produce json
+// @Param id path string true "Identifier of canvas"
+// @Success 200 {array} ascanvas.Frame
+// @Failure 404 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Router /{id}/frames [get]
+func (s WebCanvas) Frames(w http.ResponseWriter, r *http.Request) {
+	var (
+		id, err = s.GetID(r)
+		frames  []ascanvas.Frame
+	)
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	frames, err = s.Service.ListFrames(r.Context(), id)
+	if err == nil {
+		web.Json(w, http.StatusOK, frames)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// DuplicateFrame http.HandleFunc compatible handler for copying a frame of a specific ascanvas.Canvas
+// @Summary "Duplicate a frame of a canvas, inserting the copy right after it"
+// @Produce json
+// @Param id path string true "Identifier of canvas"
+// @Param frame path int true "Position of the frame, starting at 0"
+// @Success 201 {array} ascanvas.Frame
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Failure 501 {object} web.Response
+// @Router /{id}/frames/{frame}/duplicate [post]
+func (s WebCanvas) DuplicateFrame(w http.ResponseWriter, r *http.Request) {
+	var (
+		id, index, err = s.frame(r)
+		frames         []ascanvas.Frame
+	)
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	frames, err = s.Service.DuplicateFrame(r.Context(), id, index)
+	if err == nil {
+		web.Json(w, http.StatusCreated, frames)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// FrameDelay http.HandleFunc compatible handler for changing how long a frame of a specific ascanvas.Canvas is shown
+// @Summary "Change the delay before the next frame of a canvas is shown"
+// @Accept json
+// @Produce json
+// @Param id path string true "Identifier of canvas"
+// @Param frame path int true "Position of the frame, starting at 0"
+// @Param FrameDelayArgs body ascanvas.FrameDelayArgs true "Delay in milliseconds"
+// @Success 200 {array} ascanvas.Frame
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Failure 501 {object} web.Response
+// @Router /{id}/frames/{frame} [patch]
+func (s WebCanvas) FrameDelay(w http.ResponseWriter, r *http.Request) {
+	var (
+		id, index, err = s.frame(r)
+		args           ascanvas.FrameDelayArgs
+		frames         []ascanvas.Frame
+	)
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = web.ReadJsonBodyInto(r, &args)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	frames, err = s.Service.SetFrameDelay(r.Context(), id, index, args)
+	if err == nil {
+		web.Json(w, http.StatusOK, frames)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// DeleteFrame http.HandleFunc compatible handler for removing a frame of a specific ascanvas.Canvas
+// @Summary "Delete a frame of a canvas, other than the first one which is the canvas itself"
+// @Produce json
+// @Param id path string true "Identifier of canvas"
+// @Param frame path int true "Position of the frame, starting at 1"
+// @Success 200 {array} ascanvas.Frame
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Failure 501 {object} web.Response
+// @Router /{id}/frames/{frame} [delete]
+func (s WebCanvas) DeleteFrame(w http.ResponseWriter, r *http.Request) {
+	var (
+		id, index, err = s.frame(r)
+		frames         []ascanvas.Frame
+	)
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	frames, err = s.Service.DeleteFrame(r.Context(), id, index)
+	if err == nil {
+		web.Json(w, http.StatusOK, frames)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// frame of a canvas from the request, as its id and the position of the frame
+func (s WebCanvas) frame(r *http.Request) (string, int, error) {
+	var id, err = s.GetID(r)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var frame string
+	if frame, err = s.GetFrame(r); err != nil {
+		return "", 0, err
+	}
+
+	var index int
+	if index, err = strconv.Atoi(frame); err != nil {
+		return "", 0, fmt.Errorf("%w: frame must be a number", ascanvas.ErrInvalidInput)
+	}
+
+	return id, index, nil
+}
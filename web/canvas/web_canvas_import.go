@@ -0,0 +1,160 @@
+package canvas
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+	"github.com/fluxynet/ascanvas/web"
+)
+
+// MaxImportBytes is the largest request body accepted by Import
+const MaxImportBytes = 4 << 20
+
+// Import http.HandleFunc compatible handler for creating an ascanvas.Canvas from text
+// @Summary "Create a canvas from text, one row per line, sent as is or as a file upload"
+// @Description Tabs are expanded into spaces and lines shorter than the longest one are padded with fill
+// @Accept plain
+// @Accept mpfd
+// @Produce json
+// @Param name query string false "Name of the canvas; with a file upload, the name field or the file name by default"
+// @Param fill query string false "Character padding short lines, a space by default"
+// @Param file formData file false "Text file, with multipart/form-data"
+// @Param X-Author header string false "Author of the canvas"
+// @Success 200 {object} ascanvas.Canvas
+// @Failure 400 {object} web.Response
+// @Failure 413 {object} web.Response
+// @Failure 415 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Router /import [post]
+func (s WebCanvas) Import(w http.ResponseWriter, r *http.Request) {
+	var (
+		args   ascanvas.ImportArgs
+		canvas *ascanvas.Canvas
+		err    error
+
+		ctx = withAuthor(r.Context(), r)
+	)
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportBytes)
+
+	if args, err = importArgs(r); err != nil {
+		web.JsonError(w, importStatus(err), err)
+		return
+	}
+
+	canvas, err = s.Service.Import(ctx, args)
+	if err == nil {
+		web.Json(w, http.StatusOK, canvas)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+var errUnsupportedMediaType = errors.New("unsupported media type")
+
+// importStatus of errors reading an upload
+func importStatus(err error) int {
+	switch {
+	case errors.Is(err, errUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	// http.MaxBytesReader tells so only by its message
+	case err != nil && strings.Contains(err.Error(), "request body too large"):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// importArgs from a text/plain body or a multipart/form-data upload
+func importArgs(r *http.Request) (ascanvas.ImportArgs, error) {
+	var u, err = readUpload(r, web.ContentTypeText)
+	if err != nil {
+		return ascanvas.ImportArgs{}, err
+	}
+
+	return ascanvas.ImportArgs{
+		Name: u.Name,
+		Text: string(u.Data),
+		Fill: u.Fields.Get("fill"),
+	}, nil
+}
+
+// upload of a file, sent as is or as a multipart/form-data file field
+type upload struct {
+	Data []byte
+
+	// Name of the upload as per the name field, or the file name without extension
+	Name string
+
+	// Fields of the query string, overridden by form fields
+	Fields url.Values
+}
+
+// readUpload of a file of one of mediaTypes, sent as is or as the file field of a multipart/form-data body
+func readUpload(r *http.Request, mediaTypes ...string) (upload, error) {
+	var (
+		u = upload{Fields: r.URL.Query()}
+
+		expecting = fmt.Errorf("%w: expecting %s or multipart/form-data", errUnsupportedMediaType, strings.Join(mediaTypes, ", "))
+	)
+
+	var mediaType, _, err = mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return u, expecting
+	}
+
+	switch {
+	case mediaType == "multipart/form-data":
+		if err = r.ParseMultipartForm(MaxImportBytes); err != nil {
+			return u, err
+		}
+
+		for k, v := range r.MultipartForm.Value {
+			u.Fields[k] = v
+		}
+
+		var file, header, err = r.FormFile("file")
+		if err != nil {
+			return u, fmt.Errorf("%w: file is missing", ascanvas.ErrInvalidInput)
+		}
+
+		defer internal.Closed(file)
+
+		if u.Data, err = io.ReadAll(file); err != nil {
+			return u, err
+		}
+
+		if u.Name = u.Fields.Get("name"); u.Name == "" {
+			u.Name = strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+		}
+	case contains(mediaTypes, mediaType):
+		if u.Data, err = io.ReadAll(r.Body); err != nil {
+			return u, err
+		}
+
+		u.Name = u.Fields.Get("name")
+	default:
+		return u, expecting
+	}
+
+	return u, nil
+}
+
+func contains(values []string, v string) bool {
+	for i := range values {
+		if values[i] == v {
+			return true
+		}
+	}
+
+	return false
+}
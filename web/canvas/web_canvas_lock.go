@@ -0,0 +1,158 @@
+package canvas
+
+import (
+	"net/http"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web"
+)
+
+// Lock http.HandleFunc compatible handler for locking a region of a specific ascanvas.Canvas
+// @Summary "Lock a rectangular region of a specific canvas"
+// @Accept json
+// @Produce json
+// @Param id path string true "Identifier of canvas to lock"
+// @Param LockArgs body ascanvas.LockArgs true "Lock details"
+// @Success 201 {object} ascanvas.Lock
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 423 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Router /{id}/locks [post]
+func (s WebCanvas) Lock(w http.ResponseWriter, r *http.Request) {
+	var (
+		args ascanvas.LockArgs
+		lock *ascanvas.Lock
+		id   string
+		err  error
+	)
+
+	id, err = s.GetID(r)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = web.ReadJsonBodyInto(r, &args)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lock, err = s.Service.AcquireLock(r.Context(), id, args)
+	if err == nil {
+		web.Json(w, http.StatusCreated, lock)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// Locks http.HandleFunc compatible handler for listing active locks of a specific ascanvas.Canvas
+// @Summary "List active locks of a specific canvas"
+// @Produce json
+// @Param id path string true "Identifier of canvas"
+// @Success 200 {array} ascanvas.Lock
+// @Failure 500 {object} web.Response
+// @Router /{id}/locks [get]
+func (s WebCanvas) Locks(w http.ResponseWriter, r *http.Request) {
+	var (
+		id, err = s.GetID(r)
+		locks   []ascanvas.Lock
+	)
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	locks, err = s.Service.ListLocks(r.Context(), id)
+	if err == nil {
+		web.Json(w, http.StatusOK, locks)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// RenewLock http.HandleFunc compatible handler for extending the expiry of a lock
+// @Summary "Renew a lock held on a specific canvas"
+// @Accept json
+// @Produce json
+// @Param id path string true "Identifier of canvas"
+// @Param lock path string true "Identifier of lock"
+// @Param X-Lock-Token header string true "Token of the lock"
+// @Param RenewLockArgs body ascanvas.RenewLockArgs true "Renewal details"
+// @Success 200 {object} ascanvas.Lock
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 423 {object} web.Response
+// @Router /{id}/locks/{lock} [patch]
+func (s WebCanvas) RenewLock(w http.ResponseWriter, r *http.Request) {
+	var (
+		args   ascanvas.RenewLockArgs
+		lock   *ascanvas.Lock
+		id     string
+		lockID string
+		err    error
+	)
+
+	id, err = s.GetID(r)
+	if err == nil {
+		lockID, err = s.GetLockID(r)
+	}
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = web.ReadJsonBodyInto(r, &args)
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lock, err = s.Service.RenewLock(withLockTokens(r), id, lockID, args)
+	if err == nil {
+		web.Json(w, http.StatusOK, lock)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// Unlock http.HandleFunc compatible handler for releasing a lock
+// @Summary "Release a lock held on a specific canvas"
+// @Param id path string true "Identifier of canvas"
+// @Param lock path string true "Identifier of lock"
+// @Param X-Lock-Token header string true "Token of the lock"
+// @Success 204
+// @Failure 404 {object} web.Response
+// @Failure 423 {object} web.Response
+// @Router /{id}/locks/{lock} [delete]
+func (s WebCanvas) Unlock(w http.ResponseWriter, r *http.Request) {
+	var (
+		id     string
+		lockID string
+		err    error
+	)
+
+	id, err = s.GetID(r)
+	if err == nil {
+		lockID, err = s.GetLockID(r)
+	}
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = s.Service.ReleaseLock(withLockTokens(r), id, lockID)
+	if err == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
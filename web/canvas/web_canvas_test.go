@@ -1,10 +1,19 @@
 package canvas_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
 	"database/sql"
+	"image"
+	"image/color"
+	"image/png"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/zap/zaptest"
 	_ "modernc.org/sqlite"
@@ -23,13 +32,16 @@ func makeDb() *sql.DB {
 		panic("failed to open database connection: " + err.Error())
 	} else if err = db.Ping(); err != nil {
 		panic("failed to ping database: " + err.Error())
-	} else if _, err = db.Exec(sequel.SQLiteSchemaInit); err != nil {
+	} else if _, err = sequel.NewMigrator(db, "sqlite").Up(context.Background()); err != nil {
 		panic("failed to initialize schema: " + err.Error())
 	}
 
 	return db
 }
 
+// zeroTimes are the timestamps of canvases in responses, as the clock of services under test is stopped at the zero time
+const zeroTimes = `"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"`
+
 func makeWebCanvas(t *testing.T, db *sql.DB) canvas.WebCanvas {
 	return canvas.WebCanvas{
 		GetID: web.StaticIDGetter("1", nil),
@@ -39,6 +51,7 @@ func makeWebCanvas(t *testing.T, db *sql.DB) canvas.WebCanvas {
 			Logger:      zaptest.NewLogger(t),
 			GenerateID:  ascanvas.StaticUUIDGenerator("1", nil),
 			Broadcast:   ascanvas.SyncBroadcast,
+			Clock:       ascanvas.StaticClock(time.Time{}),
 		},
 	}
 }
@@ -89,7 +102,7 @@ func TestWebCanvas(t *testing.T) {
 						Want: internal.HttpTestWant{
 							Status: http.StatusOK,
 							Header: headerJSON,
-							Body:   `{"id":"1","name":"F1","content":"` + str24x9 + `","width":24,"height":9}`,
+							Body:   `{"id":"1","name":"F1","content":"` + str24x9 + `","width":24,"height":9,` + zeroTimes + `}`,
 						},
 					},
 				},
@@ -165,7 +178,7 @@ func TestWebCanvas(t *testing.T) {
 						Want: internal.HttpTestWant{
 							Status: http.StatusOK,
 							Header: headerJSON,
-							Body:   `{"id":"1","name":"F2","content":"` + str21x8 + `","width":21,"height":8}`,
+							Body:   `{"id":"1","name":"F2","content":"` + str21x8 + `","width":21,"height":8,` + zeroTimes + `}`,
 						},
 					},
 				},
@@ -266,7 +279,7 @@ OOOOOXXXXX
 						Want: internal.HttpTestWant{
 							Status: http.StatusOK,
 							Header: headerJSON,
-							Body:   `{"id":"1","name":"F3","content":"` + str28x12 + `","width":28,"height":12}`,
+							Body:   `{"id":"1","name":"F3","content":"` + str28x12 + `","width":28,"height":12,` + zeroTimes + `}`,
 						},
 					},
 				},
@@ -413,3 +426,1025 @@ OOOOOXXXXX------------------
 		})
 	}
 }
+
+func TestWebCanvas_List(t *testing.T) {
+	var (
+		db   = makeDb()
+		repo = &sequel.Repository{DB: db}
+		list = makeWebCanvas(t, db).List
+		next = ascanvas.CursorAfter(ascanvas.Canvas{Id: "1", Name: "B"}, ascanvas.ListArgs{Sort: ascanvas.SortName})
+	)
+
+	defer internal.Closed(db)
+
+	for _, c := range []ascanvas.Canvas{
+		{Id: "1", Name: "B", Content: "..", Width: 2, Height: 1},
+		{Id: "2", Name: "C", Content: "xx", Width: 1, Height: 2},
+		{Id: "3", Name: "A", Content: "o", Width: 1, Height: 1},
+	} {
+		if err := repo.Create(context.Background(), c); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		req  internal.HttpTest
+	}{
+		{
+			name: "all",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body:   `[{"id":"1","name":"B","content":"..","width":2,"height":1,` + zeroTimes + `},{"id":"2","name":"C","content":"xx","width":1,"height":2,` + zeroTimes + `},{"id":"3","name":"A","content":"o","width":1,"height":1,` + zeroTimes + `}]`,
+				},
+			},
+		},
+		{
+			name: "first page of summaries by name",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/api/?limit=2&sort=name&view=summary", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{
+						"Content-Type": {web.ContentTypeJSON},
+						"Link":         {`</api/?cursor=` + next + `&limit=2&sort=name&view=summary>; rel="next", </api/?limit=2&sort=name&view=summary>; rel="first"`},
+					},
+					Body: `[{"id":"3","name":"A","width":1,"height":1},{"id":"1","name":"B","width":2,"height":1}]`,
+				},
+			},
+		},
+		{
+			name: "last page of summaries by name",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/api/?limit=2&sort=name&view=summary&cursor=" + next, Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body:   `[{"id":"2","name":"C","width":1,"height":2}]`,
+				},
+			},
+		},
+		{
+			name: "filtered",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/?name=c&order=desc", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body:   `[{"id":"2","name":"C","content":"xx","width":1,"height":2,` + zeroTimes + `}]`,
+				},
+			},
+		},
+		{
+			name: "invalid limit",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/?limit=all", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusBadRequest,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body:   `{"error":"invalid input: limit must be a number"}`,
+				},
+			},
+		},
+		{
+			name: "cursor of another sort",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/?limit=2&cursor=" + next, Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusBadRequest,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body:   `{"error":"invalid input: Cursor does not match Sort"}`,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Assert(t, list)
+		})
+	}
+}
+
+func TestWebCanvas_Author(t *testing.T) {
+	var (
+		db = makeDb()
+		wc = makeWebCanvas(t, db)
+	)
+
+	defer internal.Closed(db)
+
+	var clock = time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+	wc.Service.Clock = func() time.Time {
+		return clock
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		req     internal.HttpTest
+	}{
+		{
+			name:    "create",
+			handler: wc.Create,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{
+					Path:   "/",
+					Method: http.MethodPost,
+					Header: map[string][]string{web.HeaderAuthor: {"alice"}},
+					Body:   `{"name":"Dot","fill":".","width":1,"height":1}`,
+				},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body: `{"id":"1","name":"Dot","content":".","width":1,"height":1,` +
+						`"created_at":"2021-11-01T12:00:00Z","updated_at":"2021-11-01T12:00:00Z","created_by":"alice","updated_by":"alice"}`,
+				},
+			},
+		},
+		{
+			name:    "update",
+			handler: wc.Floodfill,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{
+					Path:   "/",
+					Method: http.MethodPatch,
+					Header: map[string][]string{web.HeaderAuthor: {"bob"}},
+					Body:   `{"start":{"x":0,"y":0},"fill":"x"}`,
+				},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body: `{"id":"1","name":"Dot","content":"x","width":1,"height":1,` +
+						`"created_at":"2021-11-01T12:00:00Z","updated_at":"2021-11-01T12:05:00Z","created_by":"alice","updated_by":"bob"}`,
+				},
+			},
+		},
+		{
+			name:    "get",
+			handler: wc.Get,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}, "Vary": {"Accept"}},
+					Body: `{"id":"1","name":"Dot","content":"x","width":1,"height":1,` +
+						`"created_at":"2021-11-01T12:00:00Z","updated_at":"2021-11-01T12:05:00Z","created_by":"alice","updated_by":"bob"}`,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.req.Assert(t, tt.handler) {
+			t.Fatalf("%s failed", tt.name)
+		}
+
+		clock = clock.Add(5 * time.Minute)
+	}
+}
+
+func TestWebCanvas_Trash(t *testing.T) {
+	var (
+		db = makeDb()
+		wc = makeWebCanvas(t, db)
+	)
+
+	defer internal.Closed(db)
+
+	wc.Service.Trash = &sequel.Trash{DB: db}
+
+	if err := wc.Service.Repo.Create(context.Background(), ascanvas.Canvas{Id: "1", Name: "Dot", Content: ".", Width: 1, Height: 1}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var (
+		json    = map[string][]string{"Content-Type": {web.ContentTypeJSON}}
+		dot     = `{"id":"1","name":"Dot","content":".","width":1,"height":1,` + zeroTimes
+		missing = internal.HttpTestWant{Status: http.StatusNotFound, Header: json, Body: `{"error":"item not found"}`}
+	)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		req     internal.HttpTest
+	}{
+		{
+			name:    "delete",
+			handler: wc.Delete,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/", Method: http.MethodDelete, Header: map[string][]string{web.HeaderAuthor: {"carol"}}},
+				Want:    internal.HttpTestWant{Status: http.StatusNoContent, Header: map[string][]string{}},
+			},
+		},
+		{
+			name:    "trash",
+			handler: wc.Trash,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/trash", Method: http.MethodGet},
+				Want:    internal.HttpTestWant{Status: http.StatusOK, Header: json, Body: `[` + dot + `,"deleted_at":"0001-01-01T00:00:00Z","deleted_by":"carol"}]`},
+			},
+		},
+		{
+			name:    "restore",
+			handler: wc.Restore,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/", Method: http.MethodPost},
+				Want:    internal.HttpTestWant{Status: http.StatusOK, Header: json, Body: dot + `}`},
+			},
+		},
+		{
+			name:    "restore again",
+			handler: wc.Restore,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/", Method: http.MethodPost},
+				Want:    missing,
+			},
+		},
+		{
+			name:    "purge",
+			handler: wc.Purge,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/", Method: http.MethodDelete},
+				Want:    internal.HttpTestWant{Status: http.StatusNoContent, Header: map[string][]string{}},
+			},
+		},
+		{
+			name:    "purge again",
+			handler: wc.Purge,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/", Method: http.MethodDelete},
+				Want:    missing,
+			},
+		},
+		{
+			name:    "trash emptied",
+			handler: wc.Trash,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/trash", Method: http.MethodGet},
+				Want:    internal.HttpTestWant{Status: http.StatusOK, Header: json, Body: `[]`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.req.Assert(t, tt.handler) {
+			t.Fatalf("%s failed", tt.name)
+		}
+	}
+}
+
+func TestWebCanvas_Frames(t *testing.T) {
+	var (
+		db = makeDb()
+		wc = makeWebCanvas(t, db)
+	)
+
+	defer internal.Closed(db)
+
+	wc.Service.Frames = &sequel.Frames{DB: db}
+
+	if err := wc.Service.Repo.Create(context.Background(), ascanvas.Canvas{Id: "1", Name: "Dots", Content: "..", Width: 2, Height: 1}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var (
+		json  = map[string][]string{"Content-Type": {web.ContentTypeJSON}}
+		first = web.StaticIDGetter("0", nil)
+		next  = web.StaticIDGetter("1", nil)
+	)
+
+	tests := []struct {
+		name    string
+		frame   web.IDGetter
+		handler func(wc canvas.WebCanvas) http.HandlerFunc
+		req     internal.HttpTest
+	}{
+		{
+			name:    "list",
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.Frames },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/frames", Method: http.MethodGet},
+				Want:    internal.HttpTestWant{Status: http.StatusOK, Header: json, Body: `[{"content":"..","delay":100}]`},
+			},
+		},
+		{
+			name:    "duplicate",
+			frame:   first,
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.DuplicateFrame },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/frames/0/duplicate", Method: http.MethodPost},
+				Want:    internal.HttpTestWant{Status: http.StatusCreated, Header: json, Body: `[{"content":"..","delay":100},{"content":"..","delay":100}]`},
+			},
+		},
+		{
+			name:    "draw on frame",
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.Floodfill },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/floodfill", Method: http.MethodPatch, Body: `{"start":{"x":0,"y":0},"fill":"o","frame":1}`},
+				Want:    internal.HttpTestWant{Status: http.StatusOK, Header: json, Body: `{"id":"1","name":"Dots","content":"oo","width":2,"height":1,` + zeroTimes + `}`},
+			},
+		},
+		{
+			name:    "delay",
+			frame:   next,
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.FrameDelay },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/frames/1", Method: http.MethodPatch, Body: `{"delay":250}`},
+				Want:    internal.HttpTestWant{Status: http.StatusOK, Header: json, Body: `[{"content":"..","delay":100},{"content":"oo","delay":250}]`},
+			},
+		},
+		{
+			name:    "delay too short",
+			frame:   next,
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.FrameDelay },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/frames/1", Method: http.MethodPatch, Body: `{"delay":1}`},
+				Want:    internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: delay must be between 20 and 60000"}`},
+			},
+		},
+		{
+			name:    "frame not a number",
+			frame:   web.StaticIDGetter("last", nil),
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.DeleteFrame },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/frames/last", Method: http.MethodDelete},
+				Want:    internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: frame must be a number"}`},
+			},
+		},
+		{
+			name:    "delete first",
+			frame:   first,
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.DeleteFrame },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/frames/0", Method: http.MethodDelete},
+				Want:    internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: the first frame is the canvas itself and cannot be deleted"}`},
+			},
+		},
+		{
+			name:    "delete",
+			frame:   next,
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.DeleteFrame },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/frames/1", Method: http.MethodDelete},
+				Want:    internal.HttpTestWant{Status: http.StatusOK, Header: json, Body: `[{"content":"..","delay":100}]`},
+			},
+		},
+		{
+			name:    "delete again",
+			frame:   next,
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.DeleteFrame },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/frames/1", Method: http.MethodDelete},
+				Want:    internal.HttpTestWant{Status: http.StatusNotFound, Header: json, Body: `{"error":"item not found: frame 1"}`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		wc.GetFrame = tt.frame
+
+		if !tt.req.Assert(t, tt.handler(wc)) {
+			t.Fatalf("%s failed", tt.name)
+		}
+	}
+
+	wc.Service.Frames = nil
+	wc.GetFrame = first
+
+	internal.HttpTest{
+		Request: internal.HttpTestRequest{Path: "/frames/0/duplicate", Method: http.MethodPost},
+		Want:    internal.HttpTestWant{Status: http.StatusNotImplemented, Header: json, Body: `{"error":"not supported"}`},
+	}.Assert(t, wc.DuplicateFrame)
+}
+
+func TestWebCanvas_GetRendered(t *testing.T) {
+	var (
+		db = makeDb()
+		wc = makeWebCanvas(t, db)
+	)
+
+	defer internal.Closed(db)
+
+	if err := wc.Service.Repo.Create(context.Background(), ascanvas.Canvas{Id: "1", Name: "Dot", Content: ". ", Width: 1, Height: 2}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var (
+		json = `{"id":"1","name":"Dot","content":". ","width":1,"height":2,` + zeroTimes + `}`
+		html = "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Dot</title>\n" +
+			"<style>pre{display:inline-block;margin:0;padding:1ch;line-height:1.2;color:#000000;background:#ffffff}</style>\n" +
+			"</head>\n<body>\n<pre>.\n </pre>\n</body>\n</html>\n"
+		rendered = func(ctype, body string) internal.HttpTestWant {
+			return internal.HttpTestWant{
+				Status: http.StatusOK,
+				Header: map[string][]string{"Content-Type": {ctype}, "Vary": {"Accept"}},
+				Body:   body,
+			}
+		}
+	)
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		want   internal.HttpTestWant
+	}{
+		{name: "json by default", path: "/", want: rendered(web.ContentTypeJSON, json)},
+		{name: "json for any", path: "/", accept: "*/*", want: rendered(web.ContentTypeJSON, json)},
+		{name: "json for unknown", path: "/", accept: "image/gif", want: rendered(web.ContentTypeJSON, json)},
+		{name: "text accepted", path: "/", accept: "text/plain", want: rendered("text/plain; charset=utf-8", ".\n \n")},
+		{name: "ansi accepted", path: "/", accept: "text/x-ansi", want: rendered("text/plain; charset=utf-8", "\x1b[38;2;0;0;0m\x1b[48;2;255;255;255m.\x1b[0m\n\x1b[38;2;0;0;0m\x1b[48;2;255;255;255m \x1b[0m\n")},
+		{name: "html from browser", path: "/", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: rendered("text/html; charset=utf-8", html)},
+		{name: "quality", path: "/", accept: "text/html;q=0.5, text/plain", want: rendered("text/plain; charset=utf-8", ".\n \n")},
+		{name: "text of any kind", path: "/", accept: "text/*", want: rendered("text/plain; charset=utf-8", ".\n \n")},
+		{name: "format over accept", path: "/?format=json", accept: "text/plain", want: rendered(web.ContentTypeJSON, json)},
+		{name: "text format", path: "/?format=text", want: rendered("text/plain; charset=utf-8", ".\n \n")},
+		{name: "ansi format with colors", path: "/?format=ansi&fg=%23fff&bg=%23000", want: rendered("text/plain; charset=utf-8", "\x1b[38;2;255;255;255m\x1b[48;2;0;0;0m.\x1b[0m\n\x1b[38;2;255;255;255m\x1b[48;2;0;0;0m \x1b[0m\n")},
+		{name: "html format", path: "/?format=html", want: rendered("text/html; charset=utf-8", html)},
+		{
+			name: "invalid format",
+			path: "/?format=xml",
+			want: internal.HttpTestWant{
+				Status: http.StatusBadRequest,
+				Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+				Body:   `{"error":"invalid input: format must be json, text, ansi or html"}`,
+			},
+		},
+		{
+			name: "invalid color",
+			path: "/?format=ansi&fg=red",
+			want: internal.HttpTestWant{
+				Status: http.StatusBadRequest,
+				Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+				Body:   `{"error":"invalid input: Foreground must be a color as #rgb or #rrggbb"}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req = internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: tt.path, Method: http.MethodGet},
+				Want:    tt.want,
+			}
+
+			if tt.accept != "" {
+				req.Request.Header = map[string][]string{"Accept": {tt.accept}}
+			}
+
+			req.Assert(t, wc.Get)
+		})
+	}
+
+	wc.GetID = web.StaticIDGetter("2", nil)
+
+	internal.HttpTest{
+		Request: internal.HttpTestRequest{Path: "/", Method: http.MethodGet},
+		Want:    internal.HttpTestWant{Status: http.StatusNotFound, Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}}, Body: `{"error":"item not found"}`},
+	}.Assert(t, wc.Get)
+}
+
+func TestWebCanvas_Import(t *testing.T) {
+	var (
+		json   = map[string][]string{"Content-Type": {web.ContentTypeJSON}}
+		text   = map[string][]string{"Content-Type": {"text/plain; charset=utf-8"}, web.HeaderAuthor: {"alice"}}
+		upload = map[string][]string{"Content-Type": {"multipart/form-data; boundary=xyz"}}
+		arrow  = `{"id":"1","name":"Arrow","content":"==oo  ","width":3,"height":2,` + zeroTimes
+	)
+
+	tests := []struct {
+		name string
+		req  internal.HttpTest
+	}{
+		{
+			name: "text",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/import?name=Arrow", Method: http.MethodPost, Header: text, Body: "==o\no\n"},
+				Want:    internal.HttpTestWant{Status: http.StatusOK, Header: json, Body: arrow + `,"created_by":"alice","updated_by":"alice"}`},
+			},
+		},
+		{
+			name: "text with fill",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/import?name=Arrow&fill=.", Method: http.MethodPost, Header: text, Body: "==o\no"},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: json,
+					Body:   `{"id":"1","name":"Arrow","content":"==oo..","width":3,"height":2,` + zeroTimes + `,"created_by":"alice","updated_by":"alice"}`,
+				},
+			},
+		},
+		{
+			name: "upload named after file",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{
+					Path:   "/import",
+					Method: http.MethodPost,
+					Header: upload,
+					Body: "--xyz\r\n" +
+						"Content-Disposition: form-data; name=\"file\"; filename=\"Arrow.txt\"\r\n" +
+						"Content-Type: text/plain\r\n\r\n" +
+						"==o\r\no\r\n" +
+						"\r\n--xyz--\r\n",
+				},
+				Want: internal.HttpTestWant{Status: http.StatusOK, Header: json, Body: arrow + `}`},
+			},
+		},
+		{
+			name: "upload with fields",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{
+					Path:   "/import?name=Ignored",
+					Method: http.MethodPost,
+					Header: upload,
+					Body: "--xyz\r\n" +
+						"Content-Disposition: form-data; name=\"name\"\r\n\r\nDart\r\n" +
+						"--xyz\r\n" +
+						"Content-Disposition: form-data; name=\"fill\"\r\n\r\n~\r\n" +
+						"--xyz\r\n" +
+						"Content-Disposition: form-data; name=\"file\"; filename=\"arrow.txt\"\r\n\r\n" +
+						"==o\no" +
+						"\r\n--xyz--\r\n",
+				},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: json,
+					Body:   `{"id":"1","name":"Dart","content":"==oo~~","width":3,"height":2,` + zeroTimes + `}`,
+				},
+			},
+		},
+		{
+			name: "upload without file",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{
+					Path:   "/import?name=Arrow",
+					Method: http.MethodPost,
+					Header: upload,
+					Body:   "--xyz\r\nContent-Disposition: form-data; name=\"fill\"\r\n\r\n~\r\n--xyz--\r\n",
+				},
+				Want: internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: file is missing"}`},
+			},
+		},
+		{
+			name: "unnamed",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/import", Method: http.MethodPost, Header: text, Body: "x"},
+				Want:    internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: name cannot be empty"}`},
+			},
+		},
+		{
+			name: "not printable",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/import?name=Bell", Method: http.MethodPost, Header: text, Body: "\a"},
+				Want: internal.HttpTestWant{
+					Status: http.StatusBadRequest,
+					Header: json,
+					Body:   `{"error":"invalid input: line 1 has characters that are not printable ascii"}`,
+				},
+			},
+		},
+		{
+			name: "too large",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/import?name=Big", Method: http.MethodPost, Header: text, Body: strings.Repeat("x", canvas.MaxImportBytes+1)},
+				Want:    internal.HttpTestWant{Status: http.StatusRequestEntityTooLarge, Header: json, Body: `{"error":"http: request body too large"}`},
+			},
+		},
+		{
+			name: "json",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/import?name=Arrow", Method: http.MethodPost, Header: json, Body: `{}`},
+				Want: internal.HttpTestWant{
+					Status: http.StatusUnsupportedMediaType,
+					Header: json,
+					Body:   `{"error":"unsupported media type: expecting text/plain or multipart/form-data"}`,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var db = makeDb()
+			defer internal.Closed(db)
+
+			tt.req.Assert(t, makeWebCanvas(t, db).Import)
+		})
+	}
+}
+
+func TestWebCanvas_Convert(t *testing.T) {
+	var (
+		img  = image.NewGray(image.Rect(0, 0, 4, 2))
+		b    bytes.Buffer
+		json = map[string][]string{"Content-Type": {web.ContentTypeJSON}}
+	)
+
+	// white on the right half
+	for y := 0; y < 2; y++ {
+		img.SetGray(2, y, color.Gray{Y: 0xff})
+		img.SetGray(3, y, color.Gray{Y: 0xff})
+	}
+
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  internal.HttpTest
+	}{
+		{
+			name: "png",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{
+					Path:   "/convert?name=Half&width=2&aspect=1&ramp=%20%23&dither=false",
+					Method: http.MethodPost,
+					Header: map[string][]string{"Content-Type": {"image/png"}},
+					Body:   b.String(),
+				},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: json,
+					Body:   `{"id":"1","name":"Half","content":"# ","width":2,"height":1,` + zeroTimes + `}`,
+				},
+			},
+		},
+		{
+			name: "not an image",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{
+					Path:   "/convert?name=Half",
+					Method: http.MethodPost,
+					Header: map[string][]string{"Content-Type": {"image/png"}},
+					Body:   "hello",
+				},
+				Want: internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: not a png, jpeg or gif image"}`},
+			},
+		},
+		{
+			name: "invalid width",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{
+					Path:   "/convert?name=Half&width=wide",
+					Method: http.MethodPost,
+					Header: map[string][]string{"Content-Type": {"image/png"}},
+					Body:   b.String(),
+				},
+				Want: internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: width must be a number"}`},
+			},
+		},
+		{
+			name: "text",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/convert?name=Half", Method: http.MethodPost, Header: map[string][]string{"Content-Type": {"text/plain"}}, Body: "x"},
+				Want: internal.HttpTestWant{
+					Status: http.StatusUnsupportedMediaType,
+					Header: json,
+					Body:   `{"error":"unsupported media type: expecting image/png, image/jpeg, image/gif or multipart/form-data"}`,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var db = makeDb()
+			defer internal.Closed(db)
+
+			tt.req.Assert(t, makeWebCanvas(t, db).Convert)
+		})
+	}
+}
+
+func TestWebCanvas_Export(t *testing.T) {
+	var (
+		db = makeDb()
+		wc = makeWebCanvas(t, db)
+	)
+
+	defer internal.Closed(db)
+
+	if err := wc.Service.Repo.Create(context.Background(), ascanvas.Canvas{Id: "1", Name: "Dash", Content: "-", Width: 1, Height: 1}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var json = map[string][]string{"Content-Type": {web.ContentTypeJSON}}
+
+	tests := []struct {
+		name string
+		req  internal.HttpTest
+	}{
+		{
+			name: "svg",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/export?format=svg&cell_width=6&cell_height=9&padding=1&fg=%23f00", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {"image/svg+xml"}},
+					Body: `<svg xmlns="http://www.w3.org/2000/svg" width="8" height="11" viewBox="0 0 8 11" shape-rendering="crispEdges">
+<title>Dash</title>
+<rect width="8" height="11" fill="#ffffff"/>
+<path fill="#ff0000" d="M1 5h5v1h-5z"/>
+</svg>
+`,
+				},
+			},
+		},
+		{
+			name: "cast",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/export?format=cast", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {ascanvas.ContentTypeCast}},
+					Body: `{"version":2,"width":1,"height":1,"title":"Dash"}
+[0,"o","\u001b[H\u001b[2J-"]
+[0.1,"o",""]
+`,
+				},
+			},
+		},
+		{
+			name: "not a number",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/export?padding=wide", Method: http.MethodGet},
+				Want:    internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: padding must be a number"}`},
+			},
+		},
+		{
+			name: "invalid format",
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/export?format=bmp", Method: http.MethodGet},
+				Want:    internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: Format must be png, svg or gif"}`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Assert(t, wc.Export)
+		})
+	}
+
+	wc.GetID = web.StaticIDGetter("2", nil)
+
+	internal.HttpTest{
+		Request: internal.HttpTestRequest{Path: "/export", Method: http.MethodGet},
+		Want:    internal.HttpTestWant{Status: http.StatusNotFound, Header: json, Body: `{"error":"item not found"}`},
+	}.Assert(t, wc.Export)
+}
+
+func TestWebCanvas_Backup(t *testing.T) {
+	var (
+		db = makeDb()
+		wc = makeWebCanvas(t, db)
+	)
+
+	defer internal.Closed(db)
+
+	wc.Version = "v1.2.3"
+
+	if err := wc.Service.Repo.Create(context.Background(), ascanvas.Canvas{Id: "1", Name: "Dash", Content: "-", Width: 1, Height: 1}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var w = httptest.NewRecorder()
+	wc.Backup(w, httptest.NewRequest(http.MethodGet, "/export.zip", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Backup() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var wantHeader = http.Header{
+		"Content-Type":        {ascanvas.ContentTypeZip},
+		"Content-Disposition": {`attachment; filename="ascanvas-00010101-000000.zip"`},
+	}
+
+	if got := w.Header(); !reflect.DeepEqual(got, wantHeader) {
+		t.Errorf("Backup() header = %v, want %v", got, wantHeader)
+	}
+
+	var z, err = zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+
+	var names []string
+	for _, f := range z.File {
+		names = append(names, f.Name)
+	}
+
+	if want := []string{"canvases/1.json", "canvases/1.txt", "manifest.json"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Backup() files = %v, want %v", names, want)
+	}
+}
+
+func TestWebCanvas_Search(t *testing.T) {
+	var (
+		db = makeDb()
+		wc = makeWebCanvas(t, db)
+	)
+
+	defer internal.Closed(db)
+
+	if err := (&sequel.Repository{DB: db}).Create(context.Background(), *internal.CanvasFromText("1", "Cat", `
+/\_/\
+(o.o)`)); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		req     internal.HttpTest
+	}{
+		{
+			name:    "search",
+			handler: wc.Search,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/search?q=O.O", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body:   `[{"id":"1","name":"Cat","width":5,"height":2}]`,
+				},
+			},
+		},
+		{
+			name:    "search nothing",
+			handler: wc.Search,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/search?q=dog", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body:   `[]`,
+				},
+			},
+		},
+		{
+			name:    "search without query",
+			handler: wc.Search,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/search", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusBadRequest,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body:   `{"error":"invalid input: Query cannot be empty"}`,
+				},
+			},
+		},
+		{
+			name:    "find",
+			handler: wc.Find,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/", Method: http.MethodPost, Body: `{"pattern":"_/\\\n.o)"}`},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body:   `[{"x":2,"y":0}]`,
+				},
+			},
+		},
+		{
+			name:    "find nothing",
+			handler: wc.Find,
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/", Method: http.MethodPost, Body: `{"pattern":"x"}`},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {web.ContentTypeJSON}},
+					Body:   `[]`,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Assert(t, tt.handler)
+		})
+	}
+}
+
+func TestWebCanvas_Timelapse(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = makeDb()
+		wc  = makeWebCanvas(t, db)
+	)
+
+	defer internal.Closed(db)
+
+	wc.Service.History = &sequel.History{DB: db}
+
+	if _, err := wc.Service.Create(ctx, ascanvas.CreateArgs{Name: "Dots", Fill: ".", Width: 2, Height: 1}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	} else if _, err = wc.Service.ApplyRectangle(ctx, "1", ascanvas.TransformRectangleArgs{Width: 1, Height: 1, Fill: "#"}); err != nil {
+		t.Fatalf("ApplyRectangle() error = %v", err)
+	}
+
+	var (
+		json   = map[string][]string{"Content-Type": {web.ContentTypeJSON}}
+		stream = map[string][]string{
+			"Content-Type":  {web.ContentTypeEventStream},
+			"Cache-Control": {"no-cache"},
+			"Connection":    {"keep-alive"},
+		}
+	)
+
+	tests := []struct {
+		name    string
+		handler func(wc canvas.WebCanvas) http.HandlerFunc
+		req     internal.HttpTest
+	}{
+		{
+			name:    "cast",
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.Timelapse },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/timelapse?format=cast&speed=2", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: map[string][]string{"Content-Type": {ascanvas.ContentTypeCast}},
+					Body: `{"version":2,"width":2,"height":1,"title":"Dots"}` + "\n" +
+						`[0,"o","\u001b[H\u001b[2J.."]` + "\n" +
+						`[0.02,"o","\u001b[H\u001b[2J#."]` + "\n" +
+						`[2.02,"o",""]` + "\n",
+				},
+			},
+		},
+		{
+			name:    "replay",
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.ReplayTimelapse },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/timelapse/events", Method: http.MethodGet},
+				Want: internal.HttpTestWant{
+					Status: http.StatusOK,
+					Header: stream,
+					Body: "event: REVISION\ndata: {\"id\":\"1\",\"name\":\"Dots\",\"content\":\"..\",\"width\":2,\"height\":1," + zeroTimes + ",\"delay\":20}\n\n" +
+						"event: REVISION\ndata: {\"id\":\"1\",\"name\":\"Dots\",\"content\":\"#.\",\"width\":2,\"height\":1," + zeroTimes + ",\"delay\":2000}\n\n" +
+						"event: END\ndata: {\"id\":\"1\"}\n\n",
+				},
+			},
+		},
+		{
+			name:    "speed not a number",
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.ReplayTimelapse },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/timelapse/events?speed=fast", Method: http.MethodGet},
+				Want:    internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: speed must be a number"}`},
+			},
+		},
+		{
+			name:    "speed too low",
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.Timelapse },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/timelapse?speed=-1", Method: http.MethodGet},
+				Want:    internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: speed must be greater than 0 and at most 86400"}`},
+			},
+		},
+		{
+			name:    "not animated",
+			handler: func(wc canvas.WebCanvas) http.HandlerFunc { return wc.Timelapse },
+			req: internal.HttpTest{
+				Request: internal.HttpTestRequest{Path: "/timelapse?format=png", Method: http.MethodGet},
+				Want:    internal.HttpTestWant{Status: http.StatusBadRequest, Header: json, Body: `{"error":"invalid input: format must be gif or cast"}`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.req.Assert(t, tt.handler(wc)) {
+			t.Fatalf("%s failed", tt.name)
+		}
+	}
+
+	// a time-lapse of a deleted canvas is replayed from its history
+	if err := wc.Service.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	var rec = httptest.NewRecorder()
+	if wc.Timelapse(rec, httptest.NewRequest(http.MethodGet, "/timelapse", nil)); rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/gif" {
+		t.Errorf("Timelapse() of deleted canvas got %d %s, want a gif", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	wc.GetID = web.StaticIDGetter("2", nil)
+
+	internal.HttpTest{
+		Request: internal.HttpTestRequest{Path: "/timelapse", Method: http.MethodGet},
+		Want:    internal.HttpTestWant{Status: http.StatusNotFound, Header: json, Body: `{"error":"item not found: no history of canvas 2"}`},
+	}.Assert(t, wc.Timelapse)
+
+	wc.Service.History = nil
+
+	internal.HttpTest{
+		Request: internal.HttpTestRequest{Path: "/timelapse/events", Method: http.MethodGet},
+		Want:    internal.HttpTestWant{Status: http.StatusNotImplemented, Header: json, Body: `{"error":"not supported"}`},
+	}.Assert(t, wc.ReplayTimelapse)
+}
@@ -0,0 +1,172 @@
+package canvas
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web"
+)
+
+const (
+	// EventRevision is sent for every revision of a time-lapse replayed, with the canvas as it was and its delay
+	EventRevision = "REVISION"
+
+	// EventEnd is sent right after the last revision of a time-lapse replayed, which is shown from then on
+	EventEnd = "END"
+)
+
+// Timelapse http.HandleFunc compatible handler for exporting the history of a specific ascanvas.Canvas
+// @Summary "Export a time-lapse of a canvas, deleted or not, replaying every revision of its history"
+// @Description Revisions are shown as long as they took to be made at the speed given, between 20ms and 2s
+// @Produce image/gif
+// @Produce application/x-asciicast
+// @Param id path string true "Identifier of canvas"
+// @Param format query string false "Animated gif, or cast for an asciicast v2 recording" Enums(gif, cast)
+// @Param speed query number false "Speed of the replay relative to the time edits took, 60 by default"
+// @Param cell_width query int false "Width of every character in pixels"
+// @Param cell_height query int false "Height of every character in pixels"
+// @Param padding query int false "Padding around the canvas in pixels"
+// @Param fg query string false "Foreground color, as #rgb or #rrggbb"
+// @Param bg query string false "Background color, as #rgb or #rrggbb"
+// @Success 200 {file} binary
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Failure 501 {object} web.Response
+// @Router /{id}/timelapse [get]
+func (s WebCanvas) Timelapse(w http.ResponseWriter, r *http.Request) {
+	var (
+		id, err = s.GetID(r)
+
+		args  ascanvas.TimelapseArgs
+		image ascanvas.ImageArgs
+		b     []byte
+		ctype string
+	)
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if args, err = timelapseArgs(r); err == nil {
+		image, err = imageArgs(r)
+	}
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch image.Format {
+	case formatCast:
+		b, err = s.Service.ExportTimelapseCast(r.Context(), id, args)
+		ctype = ascanvas.ContentTypeCast
+	case ascanvas.ImageGIF, "":
+		b, err = s.Service.ExportTimelapseGIF(r.Context(), id, args, image)
+		ctype = ascanvas.ImageGIF.ContentType()
+	default:
+		err = fmt.Errorf("%w: format must be gif or cast", ascanvas.ErrInvalidInput)
+	}
+
+	if err != nil {
+		web.JsonError(w, httpStatus(err), err)
+		return
+	}
+
+	web.Print(w, http.StatusOK, ctype, b)
+}
+
+// ReplayTimelapse http.HandleFunc compatible handler for replaying the history of a specific ascanvas.Canvas as events
+// @Summary "Replay a time-lapse of a canvas, deleted or not, as a REVISION event per revision of its history, then END"
+// @Description Every revision is sent with the canvas as it was and its delay in milliseconds, the next one after it
+// @Produce text/event-stream
+// @Param id path string true "Identifier of canvas"
+// @Param speed query number false "Speed of the replay relative to the time edits took, 60 by default"
+// @Success 200 {string} string
+// @Failure 400 {object} web.Response
+// @Failure 404 {object} web.Response
+// @Failure 412 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Failure 501 {object} web.Response
+// @Router /{id}/timelapse/events [get]
+func (s WebCanvas) ReplayTimelapse(w http.ResponseWriter, r *http.Request) {
+	var (
+		f, ok = w.(http.Flusher)
+		ctx   = r.Context()
+
+		id, err = s.GetID(r)
+		args    ascanvas.TimelapseArgs
+	)
+
+	if !ok {
+		web.JsonError(w, http.StatusPreconditionFailed, web.ErrStreamingNotSupported)
+		return
+	}
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if args, err = timelapseArgs(r); err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	canvas, frames, err := s.Service.Timelapse(ctx, id, args)
+	if err != nil {
+		web.JsonError(w, httpStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", web.ContentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for i, frame := range frames {
+		var revision = struct {
+			ascanvas.Canvas
+			Delay int `json:"delay"`
+		}{Canvas: *canvas, Delay: frame.Delay}
+
+		revision.Content = frame.Content
+
+		if err = web.PrintJSONStream(w, f, EventRevision, revision); err != nil {
+			return
+		} else if i == len(frames)-1 {
+			break
+		}
+
+		var next = time.NewTimer(time.Duration(frame.Delay) * time.Millisecond)
+
+		select {
+		case <-ctx.Done():
+			next.Stop()
+			return
+		case <-next.C:
+		}
+	}
+
+	_ = web.PrintJSONStream(w, f, EventEnd, struct {
+		Id string `json:"id"`
+	}{Id: canvas.Id})
+}
+
+// timelapseArgs from the query string
+func timelapseArgs(r *http.Request) (ascanvas.TimelapseArgs, error) {
+	var args ascanvas.TimelapseArgs
+
+	if v := r.URL.Query().Get("speed"); v != "" {
+		var err error
+		if args.Speed, err = strconv.ParseFloat(v, 64); err != nil {
+			return args, fmt.Errorf("%w: speed must be a number", ascanvas.ErrInvalidInput)
+		}
+	}
+
+	return args, nil
+}
@@ -0,0 +1,79 @@
+package canvas
+
+import (
+	"net/http"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/web"
+)
+
+// Trash http.HandleFunc compatible handler for listing deleted ascanvas.Canvas items that can be restored
+// @Summary "List canvas items in the trash, most recently deleted first"
+// @Produce json
+// @Success 200 {array} ascanvas.DeletedCanvas
+// @Failure 500 {object} web.Response
+// @Failure 501 {object} web.Response
+// @Router /trash [get]
+func (s WebCanvas) Trash(w http.ResponseWriter, r *http.Request) {
+	var canvases, err = s.Service.ListTrash(r.Context())
+	if err == nil {
+		web.Json(w, http.StatusOK, canvases)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// Restore http.HandleFunc compatible handler for taking a specific ascanvas.Canvas out of the trash
+// @Summary "Restore a deleted canvas from the trash"
+// @Produce json
+// @Param id path string true "Identifier of canvas to restore"
+// @Success 200 {object} ascanvas.Canvas
+// @Failure 404 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Failure 501 {object} web.Response
+// @Router /{id}/restore [post]
+func (s WebCanvas) Restore(w http.ResponseWriter, r *http.Request) {
+	var (
+		id, err = s.GetID(r)
+
+		canvas *ascanvas.Canvas
+	)
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	canvas, err = s.Service.Restore(r.Context(), id)
+	if err == nil {
+		web.Json(w, http.StatusOK, canvas)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
+
+// Purge http.HandleFunc compatible handler for deleting a specific ascanvas.Canvas permanently
+// @Summary "Delete a canvas permanently, be it in the trash or not"
+// @Param id path string true "Identifier of canvas to purge"
+// @Success 204
+// @Failure 404 {object} web.Response
+// @Failure 500 {object} web.Response
+// @Router /{id}/purge [delete]
+func (s WebCanvas) Purge(w http.ResponseWriter, r *http.Request) {
+	var id, err = s.GetID(r)
+
+	if err != nil {
+		web.JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = s.Service.Purge(r.Context(), id)
+	if err == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	web.JsonError(w, httpStatus(err), err)
+}
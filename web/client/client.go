@@ -0,0 +1,259 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+	"github.com/fluxynet/ascanvas/web"
+)
+
+// DefaultServer is where the server listens by default
+const DefaultServer = "http://127.0.0.1:1337"
+
+// DefaultTimeout of requests, replies included, other than streams of events
+const DefaultTimeout = 30 * time.Second
+
+// Client of the http api of a server
+type Client struct {
+	// Server is the base url of the server, without the /api path
+	Server string
+
+	// Author of changes, sent along with them; none when empty
+	Author string
+
+	// HTTP client of requests, with a timeout for them not to hang on an unresponsive server
+	HTTP *http.Client
+
+	// Stream client of streams of events, without a timeout as they last until closed
+	Stream *http.Client
+}
+
+// New Client of the server at a base url, DefaultServer when empty
+func New(server string) *Client {
+	if server == "" {
+		server = DefaultServer
+	}
+
+	return &Client{
+		Server: strings.TrimSuffix(server, "/"),
+		HTTP:   &http.Client{Timeout: DefaultTimeout},
+		Stream: http.DefaultClient,
+	}
+}
+
+// Error replied by the server; it wraps the ascanvas error matching its status, if any
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+func (e Error) Unwrap() error {
+	switch e.Status {
+	case http.StatusBadRequest:
+		return ascanvas.ErrInvalidInput
+	case http.StatusNotFound:
+		return ascanvas.ErrNotFound
+	case http.StatusLocked:
+		return ascanvas.ErrLocked
+	case http.StatusNotImplemented:
+		return ascanvas.ErrNotSupported
+	default:
+		return nil
+	}
+}
+
+// List canvases without their content, optionally only those with a name containing name
+func (c *Client) List(ctx context.Context, name string) ([]ascanvas.CanvasSummary, error) {
+	var (
+		summaries []ascanvas.CanvasSummary
+		q         = url.Values{"view": {"summary"}}
+	)
+
+	if name != "" {
+		q.Set("name", name)
+	}
+
+	var err = c.doJson(ctx, http.MethodGet, "/?"+q.Encode(), nil, &summaries)
+
+	return summaries, err
+}
+
+// Get a canvas
+func (c *Client) Get(ctx context.Context, id string) (*ascanvas.Canvas, error) {
+	var canvas ascanvas.Canvas
+	if err := c.doJson(ctx, http.MethodGet, "/"+url.PathEscape(id), nil, &canvas); err != nil {
+		return nil, err
+	}
+
+	return &canvas, nil
+}
+
+// Create a canvas
+func (c *Client) Create(ctx context.Context, args ascanvas.CreateArgs) (*ascanvas.Canvas, error) {
+	var canvas ascanvas.Canvas
+	if err := c.doJson(ctx, http.MethodPost, "/", args, &canvas); err != nil {
+		return nil, err
+	}
+
+	return &canvas, nil
+}
+
+// Delete a canvas
+func (c *Client) Delete(ctx context.Context, id string) error {
+	return c.doJson(ctx, http.MethodDelete, "/"+url.PathEscape(id), nil, nil)
+}
+
+// Rectangle drawn on a canvas
+func (c *Client) Rectangle(ctx context.Context, id string, args ascanvas.TransformRectangleArgs) (*ascanvas.Canvas, error) {
+	var canvas ascanvas.Canvas
+	if err := c.doJson(ctx, http.MethodPatch, "/"+url.PathEscape(id)+"/rectangle", args, &canvas); err != nil {
+		return nil, err
+	}
+
+	return &canvas, nil
+}
+
+// Floodfill of a canvas
+func (c *Client) Floodfill(ctx context.Context, id string, args ascanvas.TransformFloodfillArgs) (*ascanvas.Canvas, error) {
+	var canvas ascanvas.Canvas
+	if err := c.doJson(ctx, http.MethodPatch, "/"+url.PathEscape(id)+"/floodfill", args, &canvas); err != nil {
+		return nil, err
+	}
+
+	return &canvas, nil
+}
+
+// Import a canvas from text, one row per line
+func (c *Client) Import(ctx context.Context, args ascanvas.ImportArgs) (*ascanvas.Canvas, error) {
+	var q = url.Values{"name": {args.Name}}
+	if args.Fill != "" {
+		q.Set("fill", args.Fill)
+	}
+
+	var b, err = c.do(ctx, http.MethodPost, "/import?"+q.Encode(), web.ContentTypeText, strings.NewReader(args.Text))
+	if err != nil {
+		return nil, err
+	}
+
+	var canvas ascanvas.Canvas
+	if err = json.Unmarshal(b, &canvas); err != nil {
+		return nil, err
+	}
+
+	return &canvas, nil
+}
+
+// Export a canvas as an image as per args, or as an asciicast recording when format is cast
+func (c *Client) Export(ctx context.Context, id string, args ascanvas.ImageArgs) ([]byte, error) {
+	var q = url.Values{}
+
+	for name, v := range map[string]string{"format": string(args.Format), "fg": args.Foreground, "bg": args.Background} {
+		if v != "" {
+			q.Set(name, v)
+		}
+	}
+
+	for name, v := range map[string]int{"cell_width": args.CellWidth, "cell_height": args.CellHeight, "padding": args.Padding} {
+		if v != 0 {
+			q.Set(name, strconv.Itoa(v))
+		}
+	}
+
+	return c.do(ctx, http.MethodGet, "/"+url.PathEscape(id)+"/export?"+q.Encode(), "", nil)
+}
+
+// doJson request to path of the api with args as json body if any, decoding the reply into target if any
+func (c *Client) doJson(ctx context.Context, method string, path string, args interface{}, target interface{}) error {
+	var (
+		body  io.Reader
+		ctype string
+	)
+
+	if args != nil {
+		var b, err = json.Marshal(args)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(b)
+		ctype = web.ContentTypeJSON
+	}
+
+	var b, err = c.do(ctx, method, path, ctype, body)
+	if err != nil || target == nil {
+		return err
+	}
+
+	return json.Unmarshal(b, target)
+}
+
+// do request to path of the api, returning the body of a successful reply
+func (c *Client) do(ctx context.Context, method string, path string, ctype string, body io.Reader) ([]byte, error) {
+	var req, err = c.request(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctype != "" {
+		req.Header.Set("Content-Type", ctype)
+	}
+
+	var res *http.Response
+	if res, err = c.HTTP.Do(req); err != nil {
+		return nil, err
+	}
+
+	defer internal.Closed(res.Body)
+
+	var b []byte
+	if b, err = io.ReadAll(res.Body); err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, replyError(res.StatusCode, b)
+	}
+
+	return b, nil
+}
+
+// request to path of the api, made by Author if set
+func (c *Client) request(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	var req, err = http.NewRequestWithContext(ctx, method, c.Server+"/api"+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Author != "" {
+		req.Header.Set(web.HeaderAuthor, c.Author)
+	}
+
+	return req, nil
+}
+
+// replyError from the body of an unsuccessful reply
+func replyError(status int, body []byte) error {
+	var reply struct {
+		Error string `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &reply); err != nil || reply.Error == "" {
+		reply.Error = fmt.Sprintf("%d %s", status, http.StatusText(status))
+	}
+
+	return Error{Status: status, Message: reply.Error}
+}
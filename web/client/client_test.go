@@ -0,0 +1,263 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/broadcaster/memory"
+	rm "github.com/fluxynet/ascanvas/repo/memory"
+	"github.com/fluxynet/ascanvas/web"
+	"github.com/fluxynet/ascanvas/web/canvas"
+	"github.com/fluxynet/ascanvas/web/client"
+)
+
+// serve the api with canvases in memory, created with ids 1, 2 and so on
+func serve(t *testing.T) *client.Client {
+	var (
+		ids = []string{"1", "2", "3"}
+		wc  = canvas.WebCanvas{
+			GetID: web.ChiIDGetter,
+			Service: &ascanvas.CanvasService{
+				Repo:        rm.New(),
+				BroadCaster: memory.New(),
+				Logger:      zaptest.NewLogger(t),
+				Broadcast:   ascanvas.SyncBroadcast,
+				Clock:       ascanvas.StaticClock(time.Time{}),
+				GenerateID: func() (string, error) {
+					var id = ids[0]
+					ids = ids[1:]
+					return id, nil
+				},
+			},
+		}
+		router = chi.NewMux()
+	)
+
+	router.Route("/api", func(r chi.Router) {
+		r.Post("/import", wc.Import)
+		r.Patch("/{id}/rectangle", wc.Rectangle)
+		r.Patch("/{id}/floodfill", wc.Floodfill)
+		r.Get("/{id}/export", wc.Export)
+		r.Get("/{id}/events", wc.Observe)
+		r.Delete("/{id}", wc.Delete)
+		r.Get("/{id}", wc.Get)
+		r.Post("/", wc.Create)
+		r.Get("/", wc.List)
+	})
+
+	var server = httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return client.New(server.URL + "/")
+}
+
+func TestClient(t *testing.T) {
+	var (
+		ctx = context.Background()
+		api = serve(t)
+	)
+
+	api.Author = "carol"
+
+	var created, err = api.Create(ctx, ascanvas.CreateArgs{Name: "Box", Width: 3, Height: 2, Fill: "."})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var want = ascanvas.Canvas{Id: "1", Name: "Box", Content: "......", Width: 3, Height: 2, CreatedBy: "carol", UpdatedBy: "carol"}
+	if !reflect.DeepEqual(*created, want) {
+		t.Errorf("Create() got = %v, want %v", *created, want)
+	}
+
+	var drawn *ascanvas.Canvas
+	if drawn, err = api.Rectangle(ctx, "1", ascanvas.TransformRectangleArgs{Width: 2, Height: 2, Outline: "#"}); err != nil {
+		t.Fatalf("Rectangle() error = %v", err)
+	} else if drawn.Content != "##.##." {
+		t.Errorf("Rectangle() got = %q, want %q", drawn.Content, "##.##.")
+	}
+
+	if drawn, err = api.Floodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Start: ascanvas.Coordinates{X: 2}, Fill: "o"}); err != nil {
+		t.Fatalf("Floodfill() error = %v", err)
+	} else if drawn.Content != "##o##o" {
+		t.Errorf("Floodfill() got = %q, want %q", drawn.Content, "##o##o")
+	}
+
+	var got *ascanvas.Canvas
+	if got, err = api.Get(ctx, "1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	} else if !reflect.DeepEqual(got, drawn) {
+		t.Errorf("Get() got = %v, want %v", got, drawn)
+	}
+
+	var imported *ascanvas.Canvas
+	if imported, err = api.Import(ctx, ascanvas.ImportArgs{Name: "Text", Text: "ab\nc\n", Fill: "_"}); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	} else if imported.Id != "2" || imported.Content != "abc_" {
+		t.Errorf("Import() got = %v", imported)
+	}
+
+	var summaries []ascanvas.CanvasSummary
+	if summaries, err = api.List(ctx, "box"); err != nil {
+		t.Fatalf("List() error = %v", err)
+	} else if wantList := []ascanvas.CanvasSummary{created.Summary()}; !reflect.DeepEqual(summaries, wantList) {
+		t.Errorf("List() got = %v, want %v", summaries, wantList)
+	}
+
+	var svg []byte
+	if svg, err = api.Export(ctx, "2", ascanvas.ImageArgs{Format: ascanvas.ImageSVG, CellWidth: 6, CellHeight: 9}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	} else if len(svg) == 0 || string(svg[:4]) != "<svg" {
+		t.Errorf("Export() got = %s, want an svg image", svg)
+	}
+
+	if err = api.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err = api.Get(ctx, "1"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Get() of deleted canvas error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	var (
+		ctx = context.Background()
+		api = serve(t)
+	)
+
+	var _, err = api.Create(ctx, ascanvas.CreateArgs{Name: "Empty"})
+
+	var replied client.Error
+	if !errors.As(err, &replied) {
+		t.Fatalf("Create() error = %v, want client.Error", err)
+	}
+
+	if !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("Create() error = %v, want ErrInvalidInput", err)
+	}
+
+	if replied.Status != 400 || replied.Message != err.Error() || replied.Message == "" {
+		t.Errorf("Create() error = %#v", replied)
+	}
+
+	if _, err = api.Export(ctx, "1", ascanvas.ImageArgs{Format: "bmp"}); !errors.Is(err, ascanvas.ErrInvalidInput) {
+		t.Errorf("Export() error = %v, want ErrInvalidInput", err)
+	}
+
+	api.Server += "/missing"
+
+	if _, err = api.List(ctx, ""); err == nil || errors.Is(err, ascanvas.ErrInvalidInput) || err.Error() != "404 Not Found" {
+		t.Errorf("List() of a missing api error = %v, want 404 Not Found", err)
+	}
+}
+
+func TestClient_Events(t *testing.T) {
+	var (
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		api         = serve(t)
+	)
+
+	defer cancel()
+
+	if _, err := api.Create(ctx, ascanvas.CreateArgs{Name: "Box", Width: 2, Height: 1, Fill: "."}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var events, err = api.Events(ctx, "1")
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+
+	defer func() { _ = events.Close() }()
+
+	if _, err = api.Floodfill(ctx, "1", ascanvas.TransformFloodfillArgs{Fill: "o"}); err != nil {
+		t.Fatalf("Floodfill() error = %v", err)
+	}
+
+	if err = api.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	for _, want := range []ascanvas.CanvasEventName{ascanvas.CanvasEventUpdated, ascanvas.CanvasEventDeleted} {
+		var event client.Event
+		if event, err = events.Next(); err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+
+		if event.Name != want {
+			t.Errorf("Next() got = %s, want %s", event.Name, want)
+		}
+
+		if got, err := event.Canvas(); err != nil || got.Content != "oo" {
+			t.Errorf("Canvas() got = %v, %v, want content %q", got, err, "oo")
+		}
+	}
+
+	_ = events.Close()
+
+	if _, err = events.Next(); err == nil {
+		t.Errorf("Next() after Close() error = %v, want an error", err)
+	}
+}
+
+func TestClient_Events_Errors(t *testing.T) {
+	var api = serve(t)
+
+	api.Server += "/missing"
+
+	if _, err := api.Events(context.Background(), "1"); !errors.Is(err, ascanvas.ErrNotFound) {
+		t.Errorf("Events() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		release = make(chan struct{})
+		server  = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/1/events" {
+				<-release
+				return
+			}
+
+			w.Header().Set("Content-Type", web.ContentTypeEventStream)
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+
+			// events come whenever they happen, well after the timeout of requests
+			time.Sleep(100 * time.Millisecond)
+			_, _ = fmt.Fprint(w, "event: DELETED\ndata: {\"id\":\"1\"}\n\n")
+		}))
+		api = client.New(server.URL)
+	)
+
+	defer server.Close()
+	defer close(release)
+
+	api.HTTP.Timeout = 20 * time.Millisecond
+
+	if _, err := api.Get(ctx, "1"); err == nil {
+		t.Errorf("Get() of an unresponsive server error = %v, want a timeout", err)
+	}
+
+	var events, err = api.Events(ctx, "1")
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+
+	defer func() { _ = events.Close() }()
+
+	if event, err := events.Next(); err != nil || event.Name != ascanvas.CanvasEventDeleted {
+		t.Errorf("Next() got = %v, %v, want DELETED", event, err)
+	}
+}
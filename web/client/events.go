@@ -0,0 +1,108 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fluxynet/ascanvas"
+	"github.com/fluxynet/ascanvas/internal"
+	"github.com/fluxynet/ascanvas/web"
+)
+
+// MaxEventBytes is the largest event that can be received
+const MaxEventBytes = 16 << 20
+
+// Event received from the server
+type Event struct {
+	Name ascanvas.CanvasEventName
+
+	// Data of the event as json; a canvas for CREATED, UPDATED, DELETED and RESTORED events
+	Data json.RawMessage
+}
+
+// Canvas of the event
+func (e Event) Canvas() (*ascanvas.Canvas, error) {
+	var canvas ascanvas.Canvas
+	if err := json.Unmarshal(e.Data, &canvas); err != nil {
+		return nil, err
+	}
+
+	return &canvas, nil
+}
+
+// EventStream of events received from the server, until closed
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+// Events of a canvas as they happen, of all canvases when id is empty; the stream is connected once returned
+func (c *Client) Events(ctx context.Context, id string) (*EventStream, error) {
+	var path = "/events"
+	if id != "" {
+		path = "/" + url.PathEscape(id) + path
+	}
+
+	var req, err = c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", web.ContentTypeEventStream)
+
+	var res *http.Response
+	if res, err = c.Stream.Do(req); err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		defer internal.Closed(res.Body)
+
+		var b, _ = io.ReadAll(res.Body)
+		return nil, replyError(res.StatusCode, b)
+	}
+
+	var scanner = bufio.NewScanner(res.Body)
+	scanner.Buffer(nil, MaxEventBytes)
+
+	return &EventStream{body: res.Body, scanner: scanner}, nil
+}
+
+// Next event, waiting for it; io.EOF once the server ends the stream
+func (s *EventStream) Next() (Event, error) {
+	var (
+		event Event
+		data  [][]byte
+	)
+
+	for s.scanner.Scan() {
+		var line = s.scanner.Text()
+
+		switch {
+		case line == "" && (event.Name != "" || data != nil):
+			event.Data = bytes.Join(data, []byte("\n"))
+			return event, nil
+		case strings.HasPrefix(line, "event:"):
+			event.Name = ascanvas.CanvasEventName(strings.TrimSpace(strings.TrimPrefix(line, "event:")))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, []byte(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")))
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return event, err
+	}
+
+	return event, io.EOF
+}
+
+// Close the stream
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
@@ -21,10 +22,22 @@ const (
 	// ContentTypeHTML is the content type for HTML
 	ContentTypeHTML = "text/html"
 
+	// ContentTypeText is the content type for plain text
+	ContentTypeText = "text/plain"
+
+	// ContentTypeANSI is the content type for text with terminal escape codes; not registered, but asked for by clients
+	ContentTypeANSI = "text/x-ansi"
+
 	// ContentTypeEventStream used for SSE
 	ContentTypeEventStream = "text/event-stream"
 )
 
+// HeaderLockToken carries tokens of locks held by the client; may be repeated or comma separated
+const HeaderLockToken = "X-Lock-Token"
+
+// HeaderAuthor names who makes a change, recorded as CreatedBy or UpdatedBy of canvases
+const HeaderAuthor = "X-Author"
+
 // IDGetter gets id from a request
 type IDGetter func(r *http.Request) (string, error)
 
@@ -138,6 +151,69 @@ func ReadJsonBodyInto(r *http.Request, target interface{}) error {
 	return json.Unmarshal(b, target)
 }
 
+// Negotiate the content type preferred by the Accept header of a request among offers;
+// the first offer is preferred on ties, as well as when the header is missing or matches no offer
+func Negotiate(r *http.Request, offers ...string) string {
+	var (
+		accept = r.Header.Values("Accept")
+		best   string
+		bestQ  float64
+	)
+
+	if len(offers) == 0 {
+		return ""
+	}
+
+	for _, offer := range offers {
+		var q, specificity = 0.0, -1
+
+		for _, h := range accept {
+			for _, part := range strings.Split(h, ",") {
+				var (
+					params    = strings.Split(part, ";")
+					mediaType = strings.ToLower(strings.TrimSpace(params[0]))
+					pq        = 1.0
+					s         int
+				)
+
+				switch {
+				case mediaType == offer:
+					s = 2
+				case mediaType == "*/*":
+					s = 0
+				case strings.HasSuffix(mediaType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaType, "*")):
+					s = 1
+				default:
+					continue
+				}
+
+				for _, p := range params[1:] {
+					if v := strings.TrimSpace(p); strings.HasPrefix(v, "q=") {
+						if f, err := strconv.ParseFloat(v[2:], 64); err == nil {
+							pq = f
+						}
+					}
+				}
+
+				// the most specific media range matching an offer gives its quality
+				if s > specificity {
+					q, specificity = pq, s
+				}
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	if best == "" {
+		return offers[0]
+	}
+
+	return best
+}
+
 // Response is a generic reply
 type Response struct {
 	Message string `json:"message"`
@@ -152,11 +228,18 @@ func StaticIDGetter(id string, err error) IDGetter {
 
 // ChiIDGetter for chi mux library
 func ChiIDGetter(r *http.Request) (string, error) {
-	var id = chi.URLParam(r, "id")
+	return ChiParamGetter("id")(r)
+}
 
-	if id == "" {
-		return "", ErrIDMissing
-	}
+// ChiParamGetter gets a named url parameter for chi mux library
+func ChiParamGetter(name string) IDGetter {
+	return func(r *http.Request) (string, error) {
+		var id = chi.URLParam(r, name)
 
-	return id, nil
+		if id == "" {
+			return "", ErrIDMissing
+		}
+
+		return id, nil
+	}
 }